@@ -7,10 +7,14 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 
 	"github.com/schnauzersoft/frank-cli/pkg/deploy"
+	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/plan"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
 
 	"github.com/spf13/cobra"
 )
@@ -23,7 +27,7 @@ type Config struct {
 
 // applyCmd represents the apply command.
 var applyCmd = &cobra.Command{
-	Use:   "apply [stack]",
+	Use:   "apply [stack...] | plan-file",
 	Short: "Apply templated Kubernetes manifest files to clusters",
 	Long: `Deploy your Kubernetes applications with style and precision.
 
@@ -34,28 +38,59 @@ What apply does:
   • Creates new resources or updates existing ones intelligently
   • Adds stack tracking annotations to keep things organized
   • Waits patiently for deployments to be ready (no more guessing!)
-  • Runs multiple deployments in parallel for speed
+  • Runs independent stacks in parallel as soon as their dependencies finish
+  • Checks that dependencies left out of the selection are already healthy
+  • Optionally uses server-side apply (--server-side) so fields owned by
+    other controllers, such as HPA replica counts, are left alone
+  • Optionally prunes resources removed from a stack's manifests (--prune)
+  • Optionally rolls back stacks that fail to become ready (--atomic or
+    on_failure: rollback in the stack config)
+  • Skips stacks that depend on a failed stack, or every remaining stack
+    with --fail-fast, and exits non-zero when any stack failed
   • Gives you clear, colored logs so you know what's happening
 
 Target specific stacks:
   frank apply                    # Deploy everything
-  frank apply dev                # Deploy all dev environment stacks
-  frank apply dev/app            # Deploy all dev/app* configurations
-  frank apply dev/app.yaml       # Deploy specific configuration file`,
-	Args: cobra.MaximumNArgs(1),
+  frank apply dev                # Deploy all stacks in config/dev/
+  frank apply dev/app.yaml prod/api.yaml  # Deploy specific configuration files
+  frank apply 'prod/*-api'       # Deploy stacks matching a glob
+  frank apply 're:^prod/.*-api$' # Deploy stacks matching a regular expression
+  frank apply prod --exclude prod/legacy  # Deploy prod except prod/legacy
+  frank apply -l tier=backend    # Deploy stacks tagged tier: backend
+  frank apply dev/api --with-deps  # Deploy dev/api and every stack it depends on
+  frank apply --server-side      # Deploy everything with server-side apply
+  frank apply dev --prune        # Deploy dev and remove resources dropped from its manifests
+  frank apply prod --atomic      # Deploy prod and roll back every stack that fails
+  frank apply prod --fail-fast   # Deploy prod and stop at the first failed stack
+  frank apply prod --set image.tag=1.2.3  # Deploy prod with a template var override
+
+Apply a saved plan:
+  frank plan prod --out plan.frank
+  frank apply plan.frank         # Apply exactly what was planned, refusing if the cluster changed`,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Get the --yes flag
+		// Get the --yes, --parallelism and server-side apply flags
 		yes, _ := cmd.Flags().GetBool("yes")
+		parallelism, _ := cmd.Flags().GetInt("parallelism")
+		serverSide, _ := cmd.Flags().GetBool("server-side")
+		forceConflicts, _ := cmd.Flags().GetBool("force-conflicts")
+		prune, _ := cmd.Flags().GetBool("prune")
+		atomic, _ := cmd.Flags().GetBool("atomic")
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+
+		// A saved plan replaces the stack selection and decides whether to prune
+		var selector project.Selector
 
-		// Get stack filter from arguments
-		var stackFilter string
-		if len(args) > 0 {
-			stackFilter = args[0]
+		planFile := loadPlanFile(cmd, args)
+		if planFile != nil {
+			prune = planFile.Prune
+		} else {
+			selector = loadSelector(cmd, args)
 		}
 
 		// Show confirmation prompt unless --yes flag is used
 		if !yes {
-			if !confirmAction("apply", stackFilter) {
+			if !confirmAction("apply", confirmScope(selector, args, planFile)) {
 				fmt.Println("Canceled")
 
 				return
@@ -73,45 +108,200 @@ Target specific stacks:
 
 		logger.Debug("Found config directory", "path", configDir)
 
+		// A saved plan carries its rendered manifests, so only live config is validated
+		if planFile == nil {
+			validateConfigDirectory(logger, configDir)
+		}
+
+		if forceConflicts && !serverSide {
+			logger.Error("--force-conflicts requires --server-side")
+			os.Exit(1)
+		}
+
 		// Create deployer and run parallel applies
-		deployer, err := deploy.NewDeployer(configDir, logger)
+		deployer, err := deploy.NewDeployer(configDir, logger, deploy.Options{
+			Parallelism:    parallelism,
+			ServerSide:     serverSide,
+			ForceConflicts: forceConflicts,
+			Prune:          prune,
+			Atomic:         atomic,
+			FailFast:       failFast,
+			Overrides:      loadOverrides(cmd),
+		})
 		if err != nil {
 			logger.Error("Failed to create deployer", "error", err)
 			os.Exit(1)
 		}
 
-		results, err := deployer.DeployAll(stackFilter)
+		results, err := applyStacks(deployer, selector, planFile)
 		if err != nil {
 			logger.Error("Apply failed", "error", err)
 			os.Exit(1)
 		}
 
 		// Log results with appropriate log levels
-		for _, result := range results {
-			if result.Error != nil {
-				logger.Error("Apply failed",
-					"stack", result.StackName,
-					"context", result.Context,
-					"manifest", result.Manifest,
-					"error", result.Error,
-					"timestamp", result.Timestamp)
-			} else {
-				logger.Info("Apply successful",
-					"stack", result.StackName,
-					"context", result.Context,
-					"manifest", result.Manifest,
-					"response", result.Response,
-					"timestamp", result.Timestamp)
-			}
+		logDeploymentResults(logger, results)
+
+		// Exit non-zero when any stack failed or was skipped because of a failure
+		failed, skipped := countFailures(results)
+		if failed > 0 || skipped > 0 {
+			logger.Error("Apply finished with failures", "failed", failed, "skipped", skipped, "stacks", len(results))
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
 	applyCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	applyCmd.Flags().Int("parallelism", 4, "Maximum number of independent stacks to apply concurrently")
+	applyCmd.Flags().Bool("server-side", false, "Apply resources with server-side apply using the \"frank\" field manager")
+	applyCmd.Flags().Bool("force-conflicts", false, "Take ownership of fields managed by other field managers (requires --server-side)")
+	applyCmd.Flags().Bool("prune", false, "Delete resources that were removed from a stack's manifests after it applies successfully")
+	applyCmd.Flags().Bool("atomic", false, "Roll back every stack that fails to apply or become ready, as if it set on_failure: rollback")
+	applyCmd.Flags().Bool("fail-fast", false, "Stop starting stacks once any stack failed and skip the rest")
+	addValuesFlags(applyCmd)
+	addSelectorFlags(applyCmd)
 	rootCmd.AddCommand(applyCmd)
 }
 
+// loadPlanFile reads the saved plan when the only argument is a plan file and returns nil otherwise.
+func loadPlanFile(cmd *cobra.Command, args []string) *plan.File {
+	if len(args) != 1 || !plan.IsFile(args[0]) {
+		return nil
+	}
+
+	arg := args[0]
+	logger := GetLogger()
+
+	// A saved plan is applied exactly as planned, so flags that change the plan are rejected
+	for _, flag := range []string{"prune", "values", "set", "exclude", "selector", "with-deps", "with-dependents"} {
+		if cmd.Flags().Changed(flag) {
+			logger.Error(fmt.Sprintf("--%s cannot be used with a plan file; pass it to \"frank plan\" instead", flag))
+			os.Exit(1)
+		}
+	}
+
+	planFile, err := plan.ReadFile(arg)
+	if err != nil {
+		logger.Error("Failed to read plan file", "path", arg, "error", err)
+		os.Exit(1)
+	}
+
+	return planFile
+}
+
+// confirmScope describes what apply is about to apply for the confirmation prompt.
+func confirmScope(selector project.Selector, args []string, planFile *plan.File) string {
+	if planFile != nil {
+		return args[0]
+	}
+
+	return selector.String()
+}
+
+// applyStacks applies a saved plan when one is given and every selected stack otherwise.
+func applyStacks(deployer *deploy.Deployer, selector project.Selector, planFile *plan.File) ([]deploy.DeploymentResult, error) {
+	if planFile != nil {
+		return deployer.ApplyPlan(planFile)
+	}
+
+	return deployer.DeployAll(selector)
+}
+
+// logDeploymentResults logs the result of every applied stack.
+func logDeploymentResults(logger *slog.Logger, results []deploy.DeploymentResult) {
+	for _, result := range results {
+		logPrunedResources(logger, result.Pruned)
+		logRolledBackResources(logger, result)
+
+		switch {
+		case result.Skipped:
+			logger.Warn("Apply skipped",
+				"stack", result.StackName,
+				"context", result.Context,
+				"reason", result.SkipReason,
+				"timestamp", result.Timestamp)
+		case result.Error != nil:
+			logger.Error("Apply failed",
+				"stack", result.StackName,
+				"context", result.Context,
+				"manifest", result.Manifest,
+				"error", result.Error,
+				"timestamp", result.Timestamp)
+		default:
+			logger.Info("Apply successful",
+				"stack", result.StackName,
+				"context", result.Context,
+				"manifest", result.Manifest,
+				"resources", len(result.Resources),
+				"response", result.Response,
+				"timestamp", result.Timestamp)
+		}
+	}
+}
+
+// countFailures counts the stacks that failed and the stacks skipped because of a failure.
+func countFailures(results []deploy.DeploymentResult) (int, int) {
+	failed, skipped := 0, 0
+
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			skipped++
+		case result.Error != nil:
+			failed++
+		}
+	}
+
+	return failed, skipped
+}
+
+// logPrunedResources logs every resource pruned from a stack.
+func logPrunedResources(logger *slog.Logger, pruned []kubernetes.DeleteResult) {
+	for _, result := range pruned {
+		if result.Error != nil {
+			logger.Error("Prune failed",
+				"context", result.Context,
+				"stack", result.StackName,
+				"resource", result.ResourceType,
+				"name", result.ResourceName,
+				"namespace", result.Namespace,
+				"error", result.Error)
+		} else {
+			logger.Info("Pruned resource",
+				"context", result.Context,
+				"stack", result.StackName,
+				"resource", result.ResourceType,
+				"name", result.ResourceName,
+				"namespace", result.Namespace)
+		}
+	}
+}
+
+// logRolledBackResources logs every resource restored or deleted when a failed stack was rolled back.
+func logRolledBackResources(logger *slog.Logger, result deploy.DeploymentResult) {
+	for _, rollback := range result.RolledBack {
+		if rollback.Error != nil {
+			logger.Error("Rollback failed",
+				"context", result.Context,
+				"stack", result.StackName,
+				"resource", rollback.Resource.GetKind(),
+				"name", rollback.Resource.GetName(),
+				"namespace", rollback.Resource.GetNamespace(),
+				"operation", rollback.Operation,
+				"error", rollback.Error)
+		} else {
+			logger.Warn("Rolled back resource",
+				"context", result.Context,
+				"stack", result.StackName,
+				"resource", rollback.Resource.GetKind(),
+				"name", rollback.Resource.GetName(),
+				"namespace", rollback.Resource.GetNamespace(),
+				"operation", rollback.Operation)
+		}
+	}
+}
+
 // findConfigDirectory finds the config directory by walking up the directory tree
 // It only works if there's an actual 'config' directory, not just a config.yaml file.
 func findConfigDirectory() (string, error) {
@@ -153,9 +343,13 @@ func isValidConfigDirectory(configPath string) bool {
 		return false
 	}
 
-	// Verify it has a config.yaml file
-	configYamlPath := filepath.Join(configPath, "config.yaml")
-	_, err = os.Stat(configYamlPath)
+	// Verify it has a config.yaml or config.yml file
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		_, err = os.Stat(filepath.Join(configPath, name))
+		if err == nil {
+			return true
+		}
+	}
 
-	return err == nil
+	return false
 }
@@ -0,0 +1,185 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/schnauzersoft/frank-cli/pkg/project"
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect stack configuration",
+	Long: `Inspect how frank resolves the configuration of your stacks.
+
+Every config.yaml from the config/ directory down to a stack's directory is
+merged in order, so deeper files override the values of their ancestors.`,
+}
+
+// configShowCmd represents the config show command.
+var configShowCmd = &cobra.Command{
+	Use:   "show <stack>",
+	Short: "Show the effective configuration of a stack and where each value comes from",
+	Long: `Show the effective configuration of a stack after inheritance.
+
+For every value frank prints the config.yaml file that set it, which makes it
+easy to see why a stack deploys to a certain context or namespace. Inherited
+template vars are listed by their top-level name.
+
+The stack is selected like for apply, by name, config file, directory or glob,
+and every matching stack is shown:
+  frank config show shop-staging-api         # Stack name
+  frank config show staging/web/api          # Config file relative to config/
+  frank config show staging/web/api.yaml     # Config file with extension
+  frank config show staging/web              # Every stack below config/staging/web/`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get the global logger (configuration is already loaded in root command)
+		logger := GetLogger()
+
+		// Find the config directory
+		configDir, err := findConfigDirectory()
+		if err != nil {
+			logger.Error("Failed to find config directory", "error", err)
+			os.Exit(1)
+		}
+
+		configFiles, err := findStackConfigFiles(configDir, args[0])
+		if err != nil {
+			logger.Error("Failed to find stack", "stack", args[0], "error", err)
+			os.Exit(1)
+		}
+
+		for _, configFile := range configFiles {
+			err = printEffectiveConfig(configDir, configFile)
+			if err != nil {
+				logger.Error("Failed to resolve config", "config_file", configFile, "error", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// findStackConfigFiles finds the stack config files matching a stack pattern, the same way apply,
+// plan and delete select stacks.
+func findStackConfigFiles(configDir, ref string) ([]string, error) {
+	err := project.Selector{Stacks: []string{ref}}.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := stack.ListStacks(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("error searching config directory: %w", err)
+	}
+
+	var matches []string
+
+	for _, listing := range listings {
+		var stackName string
+		if listing.Err == nil {
+			stackName = listing.Info.Name
+		}
+
+		if project.MatchesPattern(ref, project.StackPath(configDir, listing.ConfigPath), stackName) {
+			matches = append(matches, listing.ConfigPath)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no stack named or located at %q", ref)
+	}
+
+	return matches, nil
+}
+
+// printEffectiveConfig prints the effective configuration of a stack config file with the source of every value.
+func printEffectiveConfig(configDir, configFile string) error {
+	resolved, err := stack.ResolveConfigForFile(configFile)
+	if err != nil {
+		return err
+	}
+
+	stackName, err := stack.StackName(resolved.Config, configFile)
+	if err != nil {
+		return err
+	}
+
+	projectDir := filepath.Dir(configDir)
+
+	fmt.Printf("\n=== Config for %s ===\n", stackName)
+	fmt.Printf("Config file: %s\n", relativeTo(projectDir, configFile))
+	fmt.Printf("Merged files:\n")
+
+	for _, file := range resolved.Files {
+		fmt.Printf("  - %s\n", relativeTo(projectDir, file))
+	}
+
+	fmt.Println()
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for _, field := range stack.ConfigFields {
+		value, source := effectiveValue(resolved, field, configFile)
+		fmt.Fprintf(writer, "%s:\t%s\t%s\n", field, value, relativeTo(projectDir, source))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(resolved.Config.Vars)) {
+		value, err := json.Marshal(resolved.Config.Vars[name])
+		if err != nil {
+			return fmt.Errorf("error formatting var %s: %w", name, err)
+		}
+
+		fmt.Fprintf(writer, "vars.%s:\t%s\t%s\n", name, value, relativeTo(projectDir, resolved.Sources["vars."+name]))
+	}
+
+	return writer.Flush()
+}
+
+// effectiveValue returns the displayed value of a config field and where it came from.
+func effectiveValue(resolved *stack.ResolvedConfig, field, configFile string) (string, string) {
+	value := resolved.Config.Field(field)
+	if value != "" {
+		return value, resolved.Sources[field]
+	}
+
+	// The app name defaults to the config file name
+	if field == "app" {
+		return strings.TrimSuffix(filepath.Base(configFile), filepath.Ext(configFile)), "(file name)"
+	}
+
+	return "<unset>", ""
+}
+
+// relativeTo returns the path relative to a base directory, or the path itself if it can't be made relative.
+func relativeTo(base, path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+
+	relativePath, err := filepath.Rel(base, path)
+	if err != nil {
+		return path
+	}
+
+	return relativePath
+}
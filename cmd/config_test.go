@@ -0,0 +1,85 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindStackConfigFiles(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+
+	files := map[string]string{
+		"config.yaml":           "project_code: shop\ncontext: staging\n",
+		"web/api.yaml":          "manifest: api.yaml\n",
+		"web/worker.yaml.jinja": "manifest: worker.yaml\n",
+		"web/cron.j2":           "manifest: cron.yaml\n",
+		"web/config.yml":        "namespace: web\n",
+		"web/notes/readme.md":   "not a stack\n",
+	}
+
+	writeStackConfigFiles(t, configDir, files)
+
+	tests := []struct {
+		ref      string
+		expected []string
+	}{
+		{ref: "web/api", expected: inDir(configDir, "web/api.yaml")},
+		{ref: "config/web/api.yaml", expected: inDir(configDir, "web/api.yaml")},
+		{ref: "web/worker.yaml.jinja", expected: inDir(configDir, "web/worker.yaml.jinja")},
+		{ref: "shop-staging-cron", expected: inDir(configDir, "web/cron.j2")},
+		{ref: "web", expected: inDir(configDir, "web/api.yaml", "web/cron.j2", "web/worker.yaml.jinja")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			matches, err := findStackConfigFiles(configDir, tt.ref)
+			if err != nil {
+				t.Fatalf("findStackConfigFiles() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(matches, tt.expected) {
+				t.Errorf("findStackConfigFiles(%q) = %v, want %v", tt.ref, matches, tt.expected)
+			}
+		})
+	}
+
+	_, err := findStackConfigFiles(configDir, "web/missing")
+	if err == nil {
+		t.Error("findStackConfigFiles() expected an error for a stack that doesn't exist")
+	}
+}
+
+// writeStackConfigFiles writes files with their content below a config directory.
+func writeStackConfigFiles(t *testing.T, configDir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(configDir, name)
+
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		err = os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// inDir joins file names to a directory.
+func inDir(dir string, names ...string) []string {
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+
+	return paths
+}
@@ -6,15 +6,18 @@ package cmd
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
 
 	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
 
 	"github.com/spf13/cobra"
 )
 
 // deleteCmd represents the delete command.
 var deleteCmd = &cobra.Command{
-	Use:   "delete [stack]",
+	Use:   "delete [stack...]",
 	Short: "Delete resources managed by frank",
 	Long: `Clean up your Kubernetes resources with surgical precision.
 
@@ -23,29 +26,29 @@ annotations to identify what belongs to frank vs other tools.
 
 What delete does:
   • Only resources with frankthetank.cloud/stack-name annotations
-  • Matches by stack name patterns for selective cleanup
+  • Selects stacks exactly like apply and plan for selective cleanup
   • Searches across all namespaces to find everything
+  • Cleans up every cluster context your stacks deploy to
   • Shows you exactly what it's removing with clear logs
 
 Target specific stacks:
   frank delete                    # Remove all frank-managed resources
-  frank delete dev                # Remove all dev environment resources
-  frank delete dev/app            # Remove all dev/app* stack resources
-  frank delete frank-dev-app      # Remove specific stack`,
-	Args: cobra.MaximumNArgs(1),
+  frank delete dev                # Remove the resources of all stacks in config/dev/
+  frank delete 'dev/app*'         # Remove the resources of stacks matching a glob
+  frank delete frank-dev-app      # Remove specific stack
+  frank delete -l tier=backend    # Remove the resources of stacks tagged tier: backend
+  frank delete dev/db --with-dependents  # Remove dev/db and every stack that depends on it`,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get the --yes flag
 		yes, _ := cmd.Flags().GetBool("yes")
 
-		// Get stack filter from arguments
-		var stackFilter string
-		if len(args) > 0 {
-			stackFilter = args[0]
-		}
+		// Select stacks from the arguments and selector flags
+		selector := loadSelector(cmd, args)
 
 		// Show confirmation prompt unless --yes flag is used
 		if !yes {
-			if !confirmAction("delete", stackFilter) {
+			if !confirmAction("delete", selector.String()) {
 				fmt.Println("Canceled")
 
 				return
@@ -55,28 +58,23 @@ Target specific stacks:
 		// Get the global logger from root command
 		logger := GetLogger()
 
-		logger.Info("Starting delete process", "filter", stackFilter)
-
-		// Create Kubernetes deployer for delete operations
-		deployer, err := kubernetes.NewDeployerForDelete(logger)
-		if err != nil {
-			logger.Error("Failed to create Kubernetes deployer", "error", err)
+		logger.Info("Starting delete process", "selector", selector.String())
 
-			return
-		}
+		// Determine which stacks to delete and which kubeconfig contexts they deploy to
+		contexts, stackNames := findDeleteTargets(logger, selector)
+		deployers := kubernetes.NewDeployerPool(logger, kubernetes.ApplyOptions{})
 
-		// Delete frank-managed resources (with optional filtering)
-		results, err := deployer.DeleteAllManagedResources(stackFilter)
-		if err != nil {
-			logger.Error("Delete process failed", "error", err)
+		var results []kubernetes.DeleteResult
 
-			return
+		for _, kubeContext := range contexts {
+			results = append(results, deleteInContext(logger, deployers, kubeContext, stackNames)...)
 		}
 
 		// Log results
 		for _, result := range results {
 			if result.Error != nil {
 				logger.Error("Delete failed",
+					"context", result.Context,
 					"stack", result.StackName,
 					"resource", result.ResourceType,
 					"name", result.ResourceName,
@@ -84,6 +82,7 @@ Target specific stacks:
 					"error", result.Error)
 			} else {
 				logger.Info("Delete successful",
+					"context", result.Context,
 					"stack", result.StackName,
 					"resource", result.ResourceType,
 					"name", result.ResourceName,
@@ -95,7 +94,65 @@ Target specific stacks:
 	},
 }
 
+// findDeleteTargets returns the kubeconfig contexts of the selected stacks and their names. Without
+// a selection every frank-managed resource is deleted, so no stack names are returned. Without a
+// config directory it falls back to the kubeconfig's current-context, and it exits when the
+// stacks of an existing config directory can't be loaded.
+func findDeleteTargets(logger *slog.Logger, selector project.Selector) ([]string, []string) {
+	configDir, err := findConfigDirectory()
+	if err != nil && selector.IsEmpty() {
+		logger.Debug("No config directory found, using current kubeconfig context", "error", err)
+
+		return []string{""}, nil
+	}
+
+	if err != nil {
+		logger.Error("Selecting stacks requires a config directory", "error", err)
+		os.Exit(1)
+	}
+
+	// Refuse to delete anything when the stacks can't be loaded: the current context may not be
+	// the one they deploy to, and when two stacks share a name deleting one would delete both
+	proj, err := project.Load(configDir, project.Options{Selector: selector})
+	if err != nil {
+		logger.Error("Refusing to delete", "error", err)
+		os.Exit(1)
+	}
+
+	if selector.IsEmpty() {
+		return proj.Contexts(), nil
+	}
+
+	stackNames := make([]string, 0, len(proj.Stacks))
+	for _, s := range proj.Stacks {
+		stackNames = append(stackNames, s.Info.Name)
+	}
+
+	return proj.Contexts(), stackNames
+}
+
+// deleteInContext deletes frank-managed resources from the cluster behind a single kubeconfig context.
+func deleteInContext(logger *slog.Logger, deployers *kubernetes.DeployerPool, kubeContext string, stackNames []string) []kubernetes.DeleteResult {
+	deployer, err := deployers.Get(kubeContext)
+	if err != nil {
+		logger.Error("Failed to create Kubernetes deployer", "context", kubeContext, "error", err)
+
+		return nil
+	}
+
+	// Delete frank-managed resources (of the selected stacks only, if any)
+	results, err := deployer.DeleteAllManagedResources(stackNames)
+	if err != nil {
+		logger.Error("Delete process failed", "context", kubeContext, "error", err)
+
+		return nil
+	}
+
+	return results
+}
+
 func init() {
 	deleteCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	addSelectorFlags(deleteCmd)
 	rootCmd.AddCommand(deleteCmd)
 }
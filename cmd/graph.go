@@ -0,0 +1,81 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/schnauzersoft/frank-cli/pkg/graph"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
+
+	"github.com/spf13/cobra"
+)
+
+// graphCmd represents the graph command.
+var graphCmd = &cobra.Command{
+	Use:   "graph [stack...]",
+	Short: "Show the dependency graph of the stacks",
+	Long: `Show which stacks depend on which, and in which order apply runs them.
+
+Every stack is labeled with its execution wave, the wave after the last of
+its dependencies. Stacks of the same wave can run concurrently, and apply
+starts each stack as soon as its own dependencies have finished. The graph is
+printed as a plain-text tree of each stack's dependencies, a Graphviz DOT
+graph or a Mermaid flowchart.
+
+Circular dependencies are highlighted with their full path and make the
+command fail after printing the graph.
+
+Target specific stacks:
+  frank graph                    # Show every stack
+  frank graph prod               # Show the stacks in config/prod/
+  frank graph dev/api --with-deps  # Show dev/api and everything it depends on
+  frank graph -o dot | dot -Tsvg > stacks.svg  # Render the graph with Graphviz
+  frank graph -o mermaid         # Paste into Markdown that renders Mermaid`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("output")
+
+		// Select stacks from the arguments and selector flags
+		selector := loadSelector(cmd, args)
+
+		// Get the global logger (configuration is already loaded in root command)
+		logger := GetLogger()
+
+		// Find the config directory
+		configDir, err := findConfigDirectory()
+		if err != nil {
+			logger.Error("Failed to find config directory", "error", err)
+			os.Exit(1)
+		}
+
+		dependencyGraph, err := project.LoadGraph(configDir, selector)
+		if err != nil {
+			logger.Error("Failed to load stacks", "error", err)
+			os.Exit(1)
+		}
+
+		output, err := graph.Render(dependencyGraph, format)
+		if err != nil {
+			logger.Error("Failed to render graph", "error", err)
+			os.Exit(1)
+		}
+
+		fmt.Print(output)
+
+		if cycle := dependencyGraph.Cycle(); cycle != nil {
+			logger.Error("Circular dependency detected", "cycle", strings.Join(cycle, " -> "))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	graphCmd.Flags().StringP("output", "o", graph.FormatTree, "Output format: "+strings.Join(graph.Formats, ", "))
+	addSelectorFlags(graphCmd)
+	rootCmd.AddCommand(graphCmd)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the list command.
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the stacks of the project",
+	Long: `List every stack with its context, namespace and config file.
+
+frank stops at the first stack whose configuration is incomplete or invalid,
+for example a missing context, a YAML syntax error or an unknown key. Use
+--allow-incomplete to list those stacks together with their error instead:
+  frank list                     # List all stacks
+  frank list --allow-incomplete  # Also list stacks that can't be deployed yet`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		allowIncomplete, _ := cmd.Flags().GetBool("allow-incomplete")
+
+		// Get the global logger (configuration is already loaded in root command)
+		logger := GetLogger()
+
+		// Find the config directory
+		configDir, err := findConfigDirectory()
+		if err != nil {
+			logger.Error("Failed to find config directory", "error", err)
+			os.Exit(1)
+		}
+
+		listings, err := stack.ListStacks(configDir)
+		if err != nil {
+			logger.Error("Failed to list stacks", "error", err)
+			os.Exit(1)
+		}
+
+		if !allowIncomplete {
+			for _, listing := range listings {
+				if listing.Err != nil {
+					logger.Error("Invalid stack config", "error", listing.Err, "hint", "use --allow-incomplete to list it anyway")
+					os.Exit(1)
+				}
+			}
+		}
+
+		printStackListings(filepath.Dir(configDir), listings)
+	},
+}
+
+func init() {
+	listCmd.Flags().Bool("allow-incomplete", false, "List stacks with incomplete or invalid configuration together with their error")
+	rootCmd.AddCommand(listCmd)
+}
+
+// printStackListings prints a table of stacks. Stacks that couldn't be read show their error.
+func printStackListings(projectDir string, listings []stack.StackListing) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STACK\tCONTEXT\tNAMESPACE\tCONFIG\tERROR")
+
+	for _, listing := range listings {
+		configPath := relativeTo(projectDir, listing.ConfigPath)
+
+		if listing.Err != nil {
+			fmt.Fprintf(writer, "<incomplete>\t-\t-\t%s\t%v\n", configPath, listing.Err)
+
+			continue
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", listing.Info.Name, listing.Info.Context, listing.Info.Namespace, configPath)
+	}
+
+	_ = writer.Flush()
+}
@@ -6,6 +6,8 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 
 	"github.com/schnauzersoft/frank-cli/pkg/plan"
@@ -15,7 +17,7 @@ import (
 
 // planCmd represents the plan command.
 var planCmd = &cobra.Command{
-	Use:   "plan [stack]",
+	Use:   "plan [stack...]",
 	Short: "Show what changes would be made without applying them",
 	Long: `Preview changes before applying them to your Kubernetes cluster.
 
@@ -23,27 +25,49 @@ frank will show you exactly what would change in your cluster, including:
   • New resources that would be created
   • Existing resources that would be updated
   • Resources that are already up to date
-  • Color-coded diffs showing the changes
+  • Resources that apply --prune would delete (with --prune)
+  • Color-coded unified diffs showing only the changes and their context
 
 The output is formatted in the same format as your template files (YAML or HCL)
 so you can easily see what the final manifests would look like.
 
 Target specific stacks:
   frank plan                     # Plan all stacks
-  frank plan dev                 # Plan all dev environment stacks
-  frank plan dev/app             # Plan all dev/app* configurations
-  frank plan dev/app.yaml        # Plan specific configuration file`,
-	Args: cobra.MaximumNArgs(1),
+  frank plan dev                 # Plan all stacks in config/dev/
+  frank plan dev/app.yaml        # Plan specific configuration file
+  frank plan 'prod/*-api' --exclude prod/legacy-api  # Plan stacks matching a glob, except one
+  frank plan -l tier=backend     # Plan stacks tagged tier: backend
+  frank plan dev/db --with-dependents  # Plan dev/db and every stack that depends on it
+  frank plan --no-color --context 5 > plan.txt  # Plain diff for CI logs
+  frank plan -o json --detailed-exitcode        # Structured plan for automation
+  frank plan prod --out plan.frank              # Save the plan for "frank apply plan.frank"
+  frank plan prod --values hotfix.yaml --set image.tag=1.2.3  # Plan with template var overrides`,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Get stack filter from arguments
-		var stackFilter string
-		if len(args) > 0 {
-			stackFilter = args[0]
-		}
+		// Get the --prune and diff output flags
+		prune, _ := cmd.Flags().GetBool("prune")
+		contextLines, _ := cmd.Flags().GetInt("context")
+		noColor, _ := cmd.Flags().GetBool("no-color")
+		output, _ := cmd.Flags().GetString("output")
+		detailedExitCode, _ := cmd.Flags().GetBool("detailed-exitcode")
+		out, _ := cmd.Flags().GetString("out")
+
+		// Select stacks from the arguments and selector flags
+		selector := loadSelector(cmd, args)
 
 		// Get the global logger (configuration is already loaded in root command)
 		logger := GetLogger()
 
+		if output != outputText && output != plan.OutputJSON && output != plan.OutputYAML {
+			logger.Error("Invalid output format", "output", output, "supported", "text, json, yaml")
+			os.Exit(1)
+		}
+
+		// Machine-readable output never contains color codes
+		if output != outputText {
+			noColor = true
+		}
+
 		// Find the config directory
 		configDir, err := findConfigDirectory()
 		if err != nil {
@@ -53,43 +77,159 @@ Target specific stacks:
 
 		logger.Debug("Found config directory", "path", configDir)
 
+		validateConfigDirectory(logger, configDir)
+
 		// Create plan executor and run plan
-		executor, err := plan.NewExecutor(configDir, logger)
+		executor, err := plan.NewExecutor(configDir, logger, plan.Options{
+			Prune:        prune,
+			ContextLines: contextLines,
+			NoColor:      noColor,
+			Overrides:    loadOverrides(cmd),
+		})
 		if err != nil {
 			logger.Error("Failed to create plan executor", "error", err)
 			os.Exit(1)
 		}
 
-		results, err := executor.PlanAll(stackFilter)
+		results, err := executor.PlanAll(selector)
 		if err != nil {
 			logger.Error("Plan failed", "error", err)
 			os.Exit(1)
 		}
 
+		// Save the plan so apply can execute exactly what was reviewed
+		if out != "" {
+			savePlanFile(logger, results, prune, out)
+		}
+
 		// Display plan results
-		for _, result := range results {
-			if result.Error != nil {
-				logger.Error("Plan failed",
-					"stack", result.StackName,
-					"context", result.Context,
-					"manifest", result.Manifest,
-					"error", result.Error)
-			} else {
-				fmt.Printf("\n=== Plan for %s ===\n", result.StackName)
-				fmt.Printf("Context: %s\n", result.Context)
-				fmt.Printf("Manifest: %s\n", result.Manifest)
-				fmt.Printf("Operation: %s\n", result.Operation)
-				if result.Diff != "" {
-					fmt.Printf("\nDiff:\n%s\n", result.Diff)
-				}
-				if result.ManifestContent != "" {
-					fmt.Printf("\nManifest content:\n%s\n", result.ManifestContent)
-				}
-			}
+		if output == outputText {
+			printPlanResults(logger, results)
+		} else {
+			writePlanResults(os.Stdout, logger, results, output)
+		}
+
+		if detailedExitCode {
+			os.Exit(planExitCode(results))
 		}
 	},
 }
 
+// outputText is the default, human-readable plan output format.
+const outputText = "text"
+
+// Exit codes used by plan --detailed-exitcode.
+const (
+	planExitNoChanges = 0
+	planExitError     = 1
+	planExitChanges   = 2
+)
+
 func init() {
+	planCmd.Flags().Bool("prune", false, "Also show resources that apply --prune would delete")
+	planCmd.Flags().Int("context", plan.DefaultContextLines, "Number of unchanged lines to show around each change")
+	planCmd.Flags().Bool("no-color", false, "Disable ANSI colors in diffs (useful for CI logs and PR comments)")
+	planCmd.Flags().StringP("output", "o", outputText, "Output format: text, json or yaml")
+	planCmd.Flags().String("out", "", "Save the plan to a file that \"frank apply <file>\" applies exactly as planned")
+	planCmd.Flags().Bool("detailed-exitcode", false, "Exit with 2 when changes are pending, 1 on errors and 0 when everything is up to date")
+	addValuesFlags(planCmd)
+	addSelectorFlags(planCmd)
 	rootCmd.AddCommand(planCmd)
 }
+
+// savePlanFile writes the plan to a file for a later frank apply.
+func savePlanFile(logger *slog.Logger, results []plan.PlanResult, prune bool, path string) {
+	planFile, err := plan.NewFile(results, prune)
+	if err != nil {
+		logger.Error("Failed to save plan", "error", err)
+		os.Exit(1)
+	}
+
+	err = planFile.Write(path)
+	if err != nil {
+		logger.Error("Failed to save plan", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Saved plan", "path", path, "stacks", len(planFile.Stacks), "apply", "frank apply "+path)
+}
+
+// writePlanResults writes the plan results as JSON or YAML.
+func writePlanResults(w io.Writer, logger *slog.Logger, results []plan.PlanResult, output string) {
+	data, err := plan.FormatResults(results, output)
+	if err != nil {
+		logger.Error("Failed to format plan", "error", err)
+		os.Exit(1)
+	}
+
+	_, _ = w.Write(data)
+}
+
+// printPlanResults prints every plan result as human-readable text.
+func printPlanResults(logger *slog.Logger, results []plan.PlanResult) {
+	for _, result := range results {
+		if result.Error != nil {
+			logger.Error("Plan failed",
+				"stack", result.StackName,
+				"context", result.Context,
+				"manifest", result.Manifest,
+				"error", result.Error)
+		} else {
+			printPlanResult(result)
+		}
+	}
+}
+
+// planExitCode returns the --detailed-exitcode status for a set of plan results.
+func planExitCode(results []plan.PlanResult) int {
+	code := planExitNoChanges
+
+	for _, result := range results {
+		if result.Error != nil {
+			return planExitError
+		}
+
+		if result.HasChanges() {
+			code = planExitChanges
+		}
+	}
+
+	return code
+}
+
+// printPlanResult prints the plan for a single stack.
+func printPlanResult(result plan.PlanResult) {
+	fmt.Printf("\n=== Plan for %s ===\n", result.StackName)
+	fmt.Printf("Context: %s\n", result.Context)
+	fmt.Printf("Manifest: %s\n", result.Manifest)
+	fmt.Printf("Operation: %s\n", result.Operation)
+
+	if result.Diff != "" {
+		fmt.Printf("\nDiff:\n%s\n", result.Diff)
+	}
+
+	printPrunedResources(result.Resources)
+
+	if result.ManifestContent != "" {
+		fmt.Printf("\nManifest content:\n%s\n", result.ManifestContent)
+	}
+}
+
+// printPrunedResources lists the resources a plan would delete.
+func printPrunedResources(resources []plan.ResourcePlan) {
+	header := false
+
+	for _, resource := range resources {
+		if resource.Operation != "delete" {
+			continue
+		}
+
+		if !header {
+			fmt.Printf("\nWould prune:\n")
+
+			header = true
+		}
+
+		fmt.Printf("  - %s\n", resource)
+	}
+}
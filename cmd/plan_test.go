@@ -0,0 +1,69 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/schnauzersoft/frank-cli/pkg/plan"
+)
+
+func TestPlanJSONOutputWithSavedPlan(t *testing.T) {
+	err := planCmd.Flags().Set("output", plan.OutputJSON)
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = planCmd.Flags().Set("output", outputText) })
+
+	var stdout, stderr bytes.Buffer
+
+	logger := newLogger(logOutput(planCmd, &stdout, &stderr), slog.LevelInfo)
+	results := []plan.PlanResult{
+		{
+			Context:         "dev",
+			StackName:       "dev-web",
+			Manifest:        "web.yaml",
+			ManifestContent: "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+			Resources:       []plan.ResourcePlan{{APIVersion: "v1", Kind: "Service", Name: "web", Operation: "create"}},
+		},
+	}
+
+	// Run the output steps of frank plan -o json --out plan.frank
+	savePlanFile(logger, results, false, filepath.Join(t.TempDir(), "plan.frank"))
+	writePlanResults(&stdout, logger, results, plan.OutputJSON)
+
+	var decoded []map[string]any
+
+	err = json.Unmarshal(stdout.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("stdout is not valid JSON: %v\n%s", err, stdout.String())
+	}
+
+	if len(decoded) != 1 || decoded[0]["stack_name"] != "dev-web" {
+		t.Errorf("stdout = %s, want the plan of dev-web", stdout.String())
+	}
+
+	if !strings.Contains(stderr.String(), "Saved plan") {
+		t.Errorf("stderr = %q, want the saved plan log", stderr.String())
+	}
+}
+
+func TestLogOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if w := logOutput(planCmd, &stdout, &stderr); w != &stdout {
+		t.Errorf("logOutput() for text output is not stdout")
+	}
+
+	if w := logOutput(applyCmd, &stdout, &stderr); w != &stdout {
+		t.Errorf("logOutput() for a command without --output is not stdout")
+	}
+}
@@ -6,6 +6,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
@@ -48,9 +49,7 @@ Example usage:
 		}
 
 		// Set up colored structured logging with configured log level
-		logger = slog.New(tint.NewHandler(os.Stdout, &tint.Options{
-			Level: appConfig.GetLogLevel(),
-		}))
+		logger = newLogger(logOutput(cmd, os.Stdout, os.Stderr), appConfig.GetLogLevel())
 
 		// Log configuration sources for debugging
 		sources := config.GetConfigSources()
@@ -60,6 +59,24 @@ Example usage:
 	},
 }
 
+// newLogger creates a colored structured logger that writes to w.
+func newLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(tint.NewHandler(w, &tint.Options{
+		Level: level,
+	}))
+}
+
+// logOutput returns where logs are written. Commands that write machine-readable output to stdout,
+// such as plan -o json or graph -o dot, log to stderr so the output can be parsed.
+func logOutput(cmd *cobra.Command, stdout, stderr io.Writer) io.Writer {
+	output := cmd.Flags().Lookup("output")
+	if output != nil && output.Value.String() != output.DefValue {
+		return stderr
+	}
+
+	return stdout
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
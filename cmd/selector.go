@@ -0,0 +1,51 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/schnauzersoft/frank-cli/pkg/project"
+
+	"github.com/spf13/cobra"
+)
+
+// addSelectorFlags adds the --exclude, -l, --with-deps and --with-dependents stack selector flags to a command.
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("exclude", nil, "Exclude stacks matching a pattern (can be repeated)")
+	cmd.Flags().StringArrayP("selector", "l", nil, "Select stacks by tag, e.g. tier=backend (can be repeated, all must match)")
+	cmd.Flags().Bool("with-deps", false, "Also select every stack the selected stacks depend on")
+	cmd.Flags().Bool("with-dependents", false, "Also select every stack that depends on the selected stacks")
+}
+
+// loadSelector builds the stack selector of a command from its stack arguments and selector flags.
+func loadSelector(cmd *cobra.Command, args []string) project.Selector {
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	tagSelectors, _ := cmd.Flags().GetStringArray("selector")
+	withDeps, _ := cmd.Flags().GetBool("with-deps")
+	withDependents, _ := cmd.Flags().GetBool("with-dependents")
+
+	tags, err := project.ParseTags(tagSelectors)
+	if err != nil {
+		GetLogger().Error("Invalid stack selector", "error", err)
+		os.Exit(1)
+	}
+
+	selector := project.Selector{
+		Stacks:         args,
+		Exclude:        exclude,
+		Tags:           tags,
+		WithDeps:       withDeps,
+		WithDependents: withDependents,
+	}
+
+	err = selector.Validate()
+	if err != nil {
+		GetLogger().Error("Invalid stack selector", "error", err)
+		os.Exit(1)
+	}
+
+	return selector
+}
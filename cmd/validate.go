@@ -0,0 +1,89 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/schnauzersoft/frank-cli/pkg/validate"
+
+	"github.com/spf13/cobra"
+)
+
+// validateCmd represents the validate command.
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check every config file for unknown keys, invalid values and broken dependencies",
+	Long: `Validate the whole config/ directory without contacting a cluster.
+
+frank checks every config.yaml and stack config file and reports all problems
+at once, each with its file and line:
+  • Unknown keys, such as "depend_on:" or "timout:"
+  • Values of the wrong type, such as a timeout that isn't a duration
+  • Stacks without a manifest, context or project_code
+  • Dependencies on stacks that don't exist, cycles and duplicate stack names
+
+apply and plan run the same checks before they start.`,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		// Get the global logger (configuration is already loaded in root command)
+		logger := GetLogger()
+
+		// Find the config directory
+		configDir, err := findConfigDirectory()
+		if err != nil {
+			logger.Error("Failed to find config directory", "error", err)
+			os.Exit(1)
+		}
+
+		report, err := validate.ConfigDir(configDir)
+		if err != nil {
+			logger.Error("Failed to validate config directory", "error", err)
+			os.Exit(1)
+		}
+
+		projectDir := filepath.Dir(configDir)
+
+		for _, problem := range report.Problems {
+			problem.Path = relativeTo(projectDir, problem.Path)
+			fmt.Println(problem)
+		}
+
+		if len(report.Problems) > 0 {
+			fmt.Printf("\n%d problems found in %d files\n", len(report.Problems), len(report.Files))
+			os.Exit(1)
+		}
+
+		fmt.Printf("All %d config files are valid\n", len(report.Files))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+// validateConfigDirectory stops the command when the config directory has problems, logging each of them.
+func validateConfigDirectory(logger *slog.Logger, configDir string) {
+	report, err := validate.ConfigDir(configDir)
+	if err != nil {
+		logger.Error("Failed to validate config directory", "error", err)
+		os.Exit(1)
+	}
+
+	projectDir := filepath.Dir(configDir)
+
+	for _, problem := range report.Problems {
+		problem.Path = relativeTo(projectDir, problem.Path)
+		logger.Error("Invalid config", "problem", problem.String())
+	}
+
+	if len(report.Problems) > 0 {
+		logger.Error("Config validation failed", "problems", len(report.Problems), "hint", "run frank validate for details")
+		os.Exit(1)
+	}
+}
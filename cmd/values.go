@@ -0,0 +1,33 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+
+	"github.com/spf13/cobra"
+)
+
+// addValuesFlags adds the --values and --set template var flags to a command.
+func addValuesFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("values", nil, "Values file layered onto the template vars of every stack (can be repeated)")
+	cmd.Flags().StringArray("set", nil, "Set a template var, e.g. image.tag=1.2.3 (can be repeated, applied last)")
+}
+
+// loadOverrides reads the --values files and --set values of a command.
+func loadOverrides(cmd *cobra.Command) stack.Overrides {
+	valuesFiles, _ := cmd.Flags().GetStringSlice("values")
+	setValues, _ := cmd.Flags().GetStringArray("set")
+
+	overrides, err := stack.LoadOverrides(valuesFiles, setValues)
+	if err != nil {
+		GetLogger().Error("Failed to load values", "error", err)
+		os.Exit(1)
+	}
+
+	return overrides
+}
@@ -11,417 +11,239 @@ import (
 	"io"
 	"log/slog"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/plan"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
 	"github.com/schnauzersoft/frank-cli/pkg/stack"
 	"github.com/schnauzersoft/frank-cli/pkg/template"
 
 	"gopkg.in/yaml.v3"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
-// ManifestConfig represents manifest-specific configuration.
-type ManifestConfig struct {
-	Manifest  string         `yaml:"manifest"`
-	Timeout   time.Duration  `yaml:"timeout"`
-	Version   string         `yaml:"version"`
-	Vars      map[string]any `yaml:"vars"`
-	DependsOn []string       `yaml:"depends_on"`
-}
-
 // DeploymentResult represents the result of a deployment operation.
 type DeploymentResult struct {
 	Context   string
 	StackName string
 	Manifest  string
 	Response  string
-	Error     error
-	Timestamp time.Time
+	Resources []kubernetes.DeployResult
+	Pruned    []kubernetes.DeleteResult
+	// RolledBack lists the resources restored or deleted after the stack failed, when it was rolled back.
+	RolledBack []kubernetes.RollbackResult
+	// Skipped is set when the stack wasn't applied because a dependency failed or the apply
+	// stopped after a failure, and SkipReason says why.
+	Skipped    bool
+	SkipReason string
+	Error      error
+	Timestamp  time.Time
+}
+
+// Options controls how stacks are applied.
+type Options struct {
+	// Parallelism is the maximum number of stacks applied concurrently.
+	Parallelism int
+	// ServerSide applies resources with server-side apply using the "frank" field manager.
+	ServerSide bool
+	// ForceConflicts takes ownership of fields owned by other field managers during server-side apply.
+	ForceConflicts bool
+	// Prune deletes objects of a stack that are no longer in its manifests after the stack applied successfully.
+	Prune bool
+	// FailFast stops starting stacks once any stack failed. Stacks that already run are finished.
+	FailFast bool
+	// Atomic rolls back every stack that fails to apply or become ready, as if it set on_failure: rollback.
+	Atomic bool
+	// Overrides are template vars from --values and --set, applied after every config and values file.
+	Overrides stack.Overrides
+}
+
+// stackHealthChecker checks that a stack with the given manifest content is deployed and healthy.
+type stackHealthChecker interface {
+	CheckStackHealth(stackName string, manifestContent []byte) error
+}
+
+// stackRollbacker undoes the changes an apply made to a stack.
+type stackRollbacker interface {
+	Rollback(results []kubernetes.DeployResult, stackName string) []kubernetes.RollbackResult
 }
 
 // Deployer handles parallel application operations.
 type Deployer struct {
 	configDir        string
 	logger           *slog.Logger
-	k8sDeployer      *kubernetes.Deployer
+	options          Options
+	deployers        *kubernetes.DeployerPool
 	templateRenderer *template.Renderer
 }
 
 // NewDeployer creates a new Deployer instance.
-func NewDeployer(configDir string, logger *slog.Logger) (*Deployer, error) {
-	// Create Kubernetes client configuration
-	config, err := createKubernetesConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kubernetes config: %w", err)
-	}
-
-	// Create Kubernetes deployer
-	k8sDeployer, err := kubernetes.NewDeployer(config, logger)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kubernetes deployer: %w", err)
+// Kubernetes clients are created lazily, one per kubeconfig context used by the stacks.
+func NewDeployer(configDir string, logger *slog.Logger, options Options) (*Deployer, error) {
+	applyOptions := kubernetes.ApplyOptions{
+		ServerSide:     options.ServerSide,
+		ForceConflicts: options.ForceConflicts,
 	}
 
 	return &Deployer{
 		configDir:        configDir,
 		logger:           logger,
-		k8sDeployer:      k8sDeployer,
+		options:          options,
+		deployers:        kubernetes.NewDeployerPool(logger, applyOptions),
 		templateRenderer: template.NewRenderer(logger),
 	}, nil
 }
 
-// DeployAll performs application of all manifest configs in dependency order.
-func (d *Deployer) DeployAll(stackFilter string) ([]DeploymentResult, error) {
-	// Find all YAML config files
-	configFiles, err := d.findAllConfigFiles()
+// DeployAll applies the selected stacks in dependency order.
+func (d *Deployer) DeployAll(selector project.Selector) ([]DeploymentResult, error) {
+	// Load the stacks with their manifests, template vars and dependencies
+	proj, err := project.Load(d.configDir, project.Options{Selector: selector, Overrides: d.options.Overrides})
 	if err != nil {
-		return nil, fmt.Errorf("error finding config files: %w", err)
-	}
-
-	if len(configFiles) == 0 {
-		return nil, errors.New("no config files found")
-	}
-
-	// Filter config files by stack if filter is provided
-	if stackFilter != "" {
-		configFiles = d.filterConfigFilesByStack(configFiles, stackFilter)
-		if len(configFiles) == 0 {
-			return nil, fmt.Errorf("no config files found matching stack filter: %s", stackFilter)
-		}
-	}
-
-	d.logger.Debug("Found config files", "count", len(configFiles), "files", configFiles, "filter", stackFilter)
-
-	// Collect stack info and dependencies for all config files
-	stacksWithDeps, err := d.collectStacksWithDependencies(configFiles)
-	if err != nil {
-		return nil, fmt.Errorf("error collecting stack info and dependencies: %w", err)
-	}
-
-	// Resolve dependencies and get execution order
-	orderedStacks, err := stack.ResolveDependencies(stacksWithDeps)
-	if err != nil {
-		return nil, fmt.Errorf("error resolving dependencies: %w", err)
+		return nil, err
 	}
 
-	d.logger.Debug("Resolved execution order", "stacks", len(orderedStacks))
+	d.logger.Debug("Resolved execution order", "stacks", len(proj.Stacks), "selector", selector.String(), "parallelism", d.options.Parallelism)
 
-	// Execute stacks in dependency order
-	deploymentResults := make([]DeploymentResult, 0, len(orderedStacks))
-
-	for _, stackInfo := range orderedStacks {
-		d.logger.Debug("Starting apply", "config_file", stackInfo.ConfigPath, "stack", stackInfo.Name)
-		result := d.deploySingleConfig(stackInfo.ConfigPath)
-		deploymentResults = append(deploymentResults, result)
-
-		// If deployment failed, we might want to stop or continue depending on requirements
-		if result.Error != nil {
-			d.logger.Error("Deployment failed", "stack", stackInfo.Name, "error", result.Error)
-			// For now, continue with other deployments, but this could be configurable
-		}
-	}
+	// Execute stacks as soon as their dependencies have finished
+	deploymentResults := runStacks(proj.Graph, d.options.Parallelism, d.options.FailFast, func(stackInfo *stack.StackInfo) DeploymentResult {
+		return d.deployStack(proj.Stack(stackInfo.Name))
+	})
 
 	d.logger.Debug("All applies completed", "total", len(deploymentResults))
 
 	return deploymentResults, nil
 }
 
-// collectStacksWithDependencies collects stack information and dependencies for all config files.
-func (d *Deployer) collectStacksWithDependencies(configFiles []string) ([]stack.StackWithDependencies, error) {
-	stacksWithDeps := make([]stack.StackWithDependencies, 0, len(configFiles))
+// deployStack deploys a single stack from the dependency graph.
+func (d *Deployer) deployStack(s *project.Stack) DeploymentResult {
+	d.logger.Debug("Starting apply", "config_file", s.Info.ConfigPath, "stack", s.Info.Name, "context", s.Info.Context, "manifest", s.ManifestPath)
 
-	for _, configFile := range configFiles {
-		// Get stack info
-		stackInfo, err := stack.GetStackInfo(configFile)
-		if err != nil {
-			d.logger.Warn("Failed to get stack info", "config_file", configFile, "error", err)
-
-			continue
-		}
+	timestamp := time.Now()
 
-		// Get manifest config to extract dependencies
-		manifestConfig, err := d.readManifestConfig(configFile)
-		if err != nil {
-			d.logger.Warn("Failed to read manifest config", "config_file", configFile, "error", err)
+	// Dependencies that aren't applied with the stack must already be running
+	err := verifyDependencies(s.ExternalDependencies, d.dependencyManifest, func(kubeContext string) (stackHealthChecker, error) {
+		return d.deployers.Get(kubeContext)
+	})
+	if err != nil {
+		d.logger.Error("Deployment failed", "stack", s.Info.Name, "error", err)
 
-			continue
+		return DeploymentResult{
+			Context:   s.Info.Context,
+			StackName: s.Info.Name,
+			Manifest:  s.Config.Manifest,
+			Error:     err,
+			Timestamp: timestamp,
 		}
-
-		stacksWithDeps = append(stacksWithDeps, stack.StackWithDependencies{
-			StackInfo: stackInfo,
-			DependsOn: manifestConfig.DependsOn,
-		})
 	}
 
-	return stacksWithDeps, nil
-}
-
-// findAllConfigFiles finds all YAML config files in the config directory and subdirectories.
-func (d *Deployer) findAllConfigFiles() ([]string, error) {
-	var configFiles []string
-
-	err := filepath.Walk(d.configDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		if d.isConfigFile(info.Name()) {
-			configFiles = append(configFiles, path)
-		}
-
-		return nil
-	})
-
-	return configFiles, err
-}
+	// Render the manifest if it's a template, then validate and apply it
+	manifestData := d.prepareManifest(s, timestamp)
+	result := d.validateAndApplyManifest(manifestData, s.Config, s.Info, nil, timestamp)
 
-// isConfigFile checks if a file is a valid config file.
-func (d *Deployer) isConfigFile(filename string) bool {
-	// Check for YAML files (but not config.yaml)
-	if d.isYAMLFile(filename) && !d.isConfigYAML(filename) {
-		return true
+	// Stacks that depend on a failed stack are skipped by the scheduler
+	if result.Error != nil {
+		d.logger.Error("Deployment failed", "stack", s.Info.Name, "error", result.Error)
 	}
 
-	// Check for Jinja templates
-	return d.isJinjaFile(filename)
-}
-
-// isYAMLFile checks if file is a YAML file.
-func (d *Deployer) isYAMLFile(filename string) bool {
-	return strings.HasSuffix(filename, ".yaml") || strings.HasSuffix(filename, ".yml")
-}
-
-// isConfigYAML checks if file is a config.yaml file.
-func (d *Deployer) isConfigYAML(filename string) bool {
-	return filename == "config.yaml" || filename == "config.yml"
-}
-
-// isJinjaFile checks if file is a Jinja template.
-func (d *Deployer) isJinjaFile(filename string) bool {
-	return strings.HasSuffix(filename, ".jinja") || strings.HasSuffix(filename, ".j2")
+	return result
 }
 
-// filterConfigFilesByStack filters config files based on stack pattern.
-func (d *Deployer) filterConfigFilesByStack(configFiles []string, stackFilter string) []string {
-	var filteredFiles []string
+// verifyDependencies checks that every dependency left out of the selection is deployed and healthy.
+func verifyDependencies(dependencies []*project.Stack, manifestFor func(s *project.Stack) ([]byte, error), checkerFor func(kubeContext string) (stackHealthChecker, error)) error {
+	var errs []error
 
-	for _, configFile := range configFiles {
-		// Convert file path to stack pattern for matching
-		// Remove config directory prefix and .yaml/.yml extension
-		relativePath := strings.TrimPrefix(configFile, d.configDir+"/")
-		stackPattern := strings.TrimSuffix(relativePath, ".yaml")
-		stackPattern = strings.TrimSuffix(stackPattern, ".yml")
-
-		// Check if the stack pattern matches the filter
-		if d.matchesStackFilter(stackPattern, stackFilter) {
-			filteredFiles = append(filteredFiles, configFile)
+	for _, dependency := range dependencies {
+		err := checkDependency(dependency, manifestFor, checkerFor)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("dependency %s is not selected and not ready: %w", dependency.Info.Name, err))
 		}
 	}
 
-	return filteredFiles
+	return errors.Join(errs...)
 }
 
-// matchesStackFilter checks if a stack pattern matches the given filter.
-func (d *Deployer) matchesStackFilter(stackPattern, filter string) bool {
-	// Empty filter should not match anything
-	if filter == "" {
-		return false
-	}
-
-	// Check exact match
-	if stackPattern == filter {
-		return true
-	}
-
-	// Check prefix matches
-	if d.matchesPrefixPattern(stackPattern, filter) {
-		return true
+// checkDependency checks that a dependency is deployed and healthy, looking for the objects of its manifest.
+func checkDependency(dependency *project.Stack, manifestFor func(s *project.Stack) ([]byte, error), checkerFor func(kubeContext string) (stackHealthChecker, error)) error {
+	checker, err := checkerFor(dependency.Info.Context)
+	if err != nil {
+		return err
 	}
 
-	// Check path pattern with dashes
-	if d.matchesPathPattern(stackPattern, filter) {
-		return true
+	manifestContent, err := manifestFor(dependency)
+	if err != nil {
+		return err
 	}
 
-	return false
+	return checker.CheckStackHealth(dependency.Info.Name, manifestContent)
 }
 
-// matchesPrefixPattern checks if stack pattern starts with filter.
-func (d *Deployer) matchesPrefixPattern(stackPattern, filter string) bool {
-	// Directory matching: "dev" matches "dev/app"
-	if strings.HasPrefix(stackPattern, filter+"/") {
-		return true
+// dependencyManifest returns the manifest content of a dependency, rendering it if it's a template.
+func (d *Deployer) dependencyManifest(s *project.Stack) ([]byte, error) {
+	if !d.templateRenderer.IsTemplateFile(s.ManifestPath) {
+		return d.manifestContent(s.ManifestPath)
 	}
 
-	// Partial matching: "dev" matches "dev-app"
-	if strings.HasPrefix(stackPattern, filter) {
-		return true
-	}
+	content, result := d.renderTemplate(s, time.Now())
 
-	return false
+	return content, result.Error
 }
 
-// matchesPathPattern checks if filter matches as a path pattern with dashes.
-func (d *Deployer) matchesPathPattern(stackPattern, filter string) bool {
-	// Convert "dev/app" to "dev-app" and check if stack starts with it
-	filterWithDashes := strings.ReplaceAll(filter, "/", "-")
-	if strings.HasPrefix(stackPattern, filterWithDashes) {
-		return true
-	}
-
-	// Check file pattern matching: "dev/app" matches "dev/app.yaml" -> "dev/app"
-	if strings.HasPrefix(stackPattern, filter) && len(stackPattern) > len(filter) {
-		nextChar := stackPattern[len(filter) : len(filter)+1]
-
-		return nextChar == "/" || nextChar == "-" || nextChar == "_"
+// prepareManifest renders the manifest of a stack if it's a template and returns its path otherwise.
+func (d *Deployer) prepareManifest(s *project.Stack, timestamp time.Time) any {
+	if !d.templateRenderer.IsTemplateFile(s.ManifestPath) {
+		return s.ManifestPath
 	}
 
-	return false
-}
-
-// deploySingleConfig deploys a single config file.
-func (d *Deployer) deploySingleConfig(configPath string) DeploymentResult {
-	timestamp := time.Now()
-
-	// Read manifest config
-	manifestConfig, stackInfo, result := d.readConfigAndStackInfo(configPath, timestamp)
+	content, result := d.renderTemplate(s, timestamp)
 	if result.Error != nil {
-		return result
-	}
-
-	// Find and prepare manifest file
-	manifestData, result := d.findAndPrepareManifest(manifestConfig, stackInfo, timestamp)
-	if result.Error != nil {
-		return result
-	}
-
-	// Validate and apply manifest
-	return d.validateAndApplyManifest(manifestData, manifestConfig, stackInfo, timestamp)
-}
-
-// readConfigAndStackInfo reads the manifest config and gets stack info.
-func (d *Deployer) readConfigAndStackInfo(configPath string, timestamp time.Time) (*ManifestConfig, *stack.StackInfo, DeploymentResult) {
-	// Read the manifest config
-	manifestConfig, err := d.readManifestConfig(configPath)
-	if err != nil {
-		d.logger.Debug("Failed to read manifest config", "config_file", configPath, "error", err)
-
-		return nil, nil, DeploymentResult{
-			Context:   "unknown",
-			StackName: "unknown",
-			Manifest:  filepath.Base(configPath),
-			Response:  "",
-			Error:     fmt.Errorf("error reading config: %w", err),
-			Timestamp: timestamp,
-		}
-	}
-
-	d.logger.Debug("Read manifest config", "config_file", configPath, "manifest", manifestConfig.Manifest)
-
-	// Get stack information
-	stackInfo, err := stack.GetStackInfo(configPath)
-	if err != nil {
-		d.logger.Debug("Failed to get stack info", "error", err)
+		// If template rendering fails, fall back to treating it as a regular file
+		d.logger.Warn("Template rendering failed, treating as regular file", "template", s.ManifestPath, "error", result.Error)
 
-		return nil, nil, DeploymentResult{
-			Context:   "unknown",
-			StackName: stack.GenerateFallbackStackName(configPath),
-			Manifest:  manifestConfig.Manifest,
-			Response:  "",
-			Error:     fmt.Errorf("error getting stack info: %w", err),
-			Timestamp: timestamp,
-		}
+		return s.ManifestPath
 	}
 
-	d.logger.Debug("Generated stack info", "stack_name", stackInfo.Name, "context", stackInfo.Context, "project_code", stackInfo.ProjectCode, "namespace", stackInfo.Namespace, "app", stackInfo.App, "version", stackInfo.Version)
-	d.logger.Debug("Using Kubernetes client", "context", stackInfo.Context)
-
-	return manifestConfig, stackInfo, DeploymentResult{}
-}
-
-// findAndPrepareManifest finds the manifest file and renders it if it's a template.
-func (d *Deployer) findAndPrepareManifest(manifestConfig *ManifestConfig, stackInfo *stack.StackInfo, timestamp time.Time) (any, DeploymentResult) {
-	// Find the manifest file
-	manifestPath, err := d.findManifestFile(manifestConfig.Manifest)
-	if err != nil {
-		d.logger.Debug("Failed to find manifest file", "manifest", manifestConfig.Manifest, "error", err)
-
-		return "", DeploymentResult{
-			Context:   stackInfo.Context,
-			StackName: stackInfo.Name,
-			Manifest:  manifestConfig.Manifest,
-			Response:  "",
-			Error:     fmt.Errorf("error finding manifest file: %w", err),
-			Timestamp: timestamp,
-		}
-	}
-
-	// Check if this is a template file and render it
-	if d.templateRenderer.IsTemplateFile(manifestPath) {
-		content, result := d.renderTemplate(manifestPath, stackInfo, manifestConfig, timestamp)
-		if result.Error != nil {
-			// If template rendering fails, fall back to treating it as a regular file
-			d.logger.Warn("Template rendering failed, treating as regular file", "template", manifestPath, "error", result.Error)
-
-			return manifestPath, DeploymentResult{}
-		}
-
-		return content, DeploymentResult{}
-	}
-
-	return manifestPath, DeploymentResult{}
+	return content
 }
 
 // renderTemplate renders a Jinja template to memory.
-func (d *Deployer) renderTemplate(manifestPath string, stackInfo *stack.StackInfo, manifestConfig *ManifestConfig, timestamp time.Time) ([]byte, DeploymentResult) {
-	d.logger.Debug("Rendering template", "template", manifestPath)
-
-	// Build template context
-	// Use manifest config version if specified, otherwise fall back to stack version
-	version := manifestConfig.Version
-	if version == "" {
-		version = stackInfo.Version
-	}
+func (d *Deployer) renderTemplate(s *project.Stack, timestamp time.Time) ([]byte, DeploymentResult) {
+	d.logger.Debug("Rendering template", "template", s.ManifestPath)
 
 	templateContext := d.templateRenderer.BuildTemplateContext(
-		stackInfo.Name,
-		stackInfo.Context,
-		stackInfo.ProjectCode,
-		stackInfo.Namespace,
-		stackInfo.App,
-		version,
-		manifestConfig.Vars,
+		s.Info.Name,
+		s.Info.Context,
+		s.Info.ProjectCode,
+		s.Info.Namespace,
+		s.Info.App,
+		s.Info.Version,
+		s.Vars,
 	)
 
 	// Render the template to memory
-	renderedContent, err := d.templateRenderer.RenderManifest(manifestPath, templateContext)
+	renderedContent, err := d.templateRenderer.RenderManifest(s.ManifestPath, templateContext)
 	if err != nil {
-		d.logger.Debug("Failed to render template", "template", manifestPath, "error", err)
+		d.logger.Debug("Failed to render template", "template", s.ManifestPath, "error", err)
 
 		return nil, DeploymentResult{
-			Context:   stackInfo.Context,
-			StackName: stackInfo.Name,
-			Manifest:  manifestConfig.Manifest,
+			Context:   s.Info.Context,
+			StackName: s.Info.Name,
+			Manifest:  s.Config.Manifest,
 			Response:  "",
 			Error:     fmt.Errorf("error rendering template: %w", err),
 			Timestamp: timestamp,
 		}
 	}
 
-	d.logger.Debug("Template rendered successfully", "template", manifestPath, "size", len(renderedContent))
+	d.logger.Debug("Template rendered successfully", "template", s.ManifestPath, "size", len(renderedContent))
 
 	return renderedContent, DeploymentResult{}
 }
 
-// validateAndApplyManifest validates namespace and applies the manifest.
-func (d *Deployer) validateAndApplyManifest(manifestData any, manifestConfig *ManifestConfig, stackInfo *stack.StackInfo, timestamp time.Time) DeploymentResult {
+// validateAndApplyManifest validates namespace and applies the manifest. Manifests from a saved plan
+// come with their stack plan, so objects are only written and pruned as they were planned.
+func (d *Deployer) validateAndApplyManifest(manifestData any, manifestConfig *project.ManifestConfig, stackInfo *stack.StackInfo, planned *plan.StackPlan, timestamp time.Time) DeploymentResult {
 	// Set default timeout if not specified
 	timeout := manifestConfig.Timeout
 	if timeout == 0 {
@@ -447,26 +269,21 @@ func (d *Deployer) validateAndApplyManifest(manifestData any, manifestConfig *Ma
 		}
 	}
 
-	// Apply the manifest using the real Kubernetes deployer
-	var result *kubernetes.DeployResult
-
-	if manifestPath, ok := manifestData.(string); ok {
-		// It's a file path
-		result, err = d.k8sDeployer.DeployManifest(manifestPath, stackInfo.Name, stackInfo.Namespace, timeout)
-	} else if manifestContent, ok := manifestData.([]byte); ok {
-		// It's content in memory
-		result, err = d.k8sDeployer.DeployManifestContent(manifestContent, stackInfo.Name, stackInfo.Namespace, timeout)
-	} else {
+	// Get the Kubernetes deployer for the stack's context
+	k8sDeployer, err := d.deployers.Get(stackInfo.Context)
+	if err != nil {
 		return DeploymentResult{
 			Context:   stackInfo.Context,
 			StackName: stackInfo.Name,
 			Manifest:  manifestConfig.Manifest,
 			Response:  "",
-			Error:     fmt.Errorf("invalid manifest data type: %T", manifestData),
+			Error:     err,
 			Timestamp: timestamp,
 		}
 	}
 
+	// Apply the manifest using the real Kubernetes deployer
+	results, err := d.applyManifest(k8sDeployer, manifestData, planned, stackInfo, timeout)
 	if err != nil {
 		d.logger.Debug("Failed to apply manifest", "manifest", manifestConfig.Manifest, "error", err)
 
@@ -480,176 +297,224 @@ func (d *Deployer) validateAndApplyManifest(manifestData any, manifestConfig *Ma
 		}
 	}
 
-	// Check if the deployment result contains an error
-	if result.Error != nil {
-		d.logger.Debug("Deployment failed", "manifest", manifestConfig.Manifest, "error", result.Error)
+	// Format response based on the results
+	response := d.formatResponses(results)
+
+	// Undo the changes of a failed stack when it should be rolled back
+	if d.rollbackOnFailure(manifestConfig) && slices.ContainsFunc(results, kubernetes.DeployResult.Failed) {
+		rolledBack, err := rollbackStack(k8sDeployer, results, stackInfo.Name)
+		d.logger.Debug("Deployment rolled back", "manifest", manifestConfig.Manifest, "resources", len(rolledBack), "error", err)
+
+		return DeploymentResult{
+			Context:    stackInfo.Context,
+			StackName:  stackInfo.Name,
+			Manifest:   manifestConfig.Manifest,
+			Response:   response,
+			Resources:  results,
+			RolledBack: rolledBack,
+			Error:      err,
+			Timestamp:  timestamp,
+		}
+	}
+
+	// A resource that failed to apply or become ready fails the stack, so that it isn't pruned
+	// and its dependents don't run
+	resultErr := resourceFailures(results)
+	if resultErr != nil {
+		d.logger.Debug("Deployment failed", "manifest", manifestConfig.Manifest, "error", resultErr)
 
 		return DeploymentResult{
 			Context:   stackInfo.Context,
 			StackName: stackInfo.Name,
 			Manifest:  manifestConfig.Manifest,
-			Response:  d.formatResponse(result),
-			Error:     fmt.Errorf("deployment failed: %w", result.Error),
+			Response:  response,
+			Resources: results,
+			Error:     fmt.Errorf("deployment failed: %w", resultErr),
 			Timestamp: timestamp,
 		}
 	}
 
-	// Format response based on the result
-	response := d.formatResponse(result)
+	d.logger.Debug("Apply completed", "manifest", manifestConfig.Manifest, "resources", len(results), "response", response)
 
-	d.logger.Debug("Apply completed", "manifest", manifestConfig.Manifest, "response", response)
+	// Remove objects that were dropped from the stack's manifests
+	pruned, err := d.pruneStack(k8sDeployer, manifestData, stackInfo, planned)
 
 	return DeploymentResult{
 		Context:   stackInfo.Context,
 		StackName: stackInfo.Name,
 		Manifest:  manifestConfig.Manifest,
 		Response:  response,
-		Error:     nil,
+		Resources: results,
+		Pruned:    pruned,
+		Error:     err,
 		Timestamp: timestamp,
 	}
 }
 
-// formatResponse formats the response string based on the deployment result.
-func (d *Deployer) formatResponse(result *kubernetes.DeployResult) string {
-	if result.Error != nil {
-		return fmt.Sprintf("Apply failed: %v", result.Error)
+// applyManifest applies manifest data from a file or from memory. Content from a saved plan is only
+// applied to objects that are still in the state the plan observed.
+func (d *Deployer) applyManifest(k8sDeployer *kubernetes.Deployer, manifestData any, planned *plan.StackPlan, stackInfo *stack.StackInfo, timeout time.Duration) ([]kubernetes.DeployResult, error) {
+	switch data := manifestData.(type) {
+	case string:
+		// It's a file path
+		return k8sDeployer.DeployManifest(data, stackInfo.Name, stackInfo.Namespace, timeout)
+	case []byte:
+		// It's content in memory
+		if planned != nil {
+			return k8sDeployer.DeployPlannedContent(data, stackInfo.Name, stackInfo.Namespace, timeout, observedVersions(*planned))
+		}
+
+		return k8sDeployer.DeployManifestContent(data, stackInfo.Name, stackInfo.Namespace, timeout)
+	default:
+		return nil, fmt.Errorf("invalid manifest data type: %T", manifestData)
 	}
+}
 
-	return fmt.Sprintf("Applied %s/%s: %s in namespace %s (operation: %s, status: %s)",
-		result.Resource.GetAPIVersion(),
-		result.Resource.GetKind(),
-		result.Resource.GetName(),
-		result.Resource.GetNamespace(),
-		result.Operation,
-		result.Status)
+// rollbackOnFailure checks if a stack is rolled back when it fails, because it sets on_failure: rollback
+// or apply runs with --atomic.
+func (d *Deployer) rollbackOnFailure(manifestConfig *project.ManifestConfig) bool {
+	return d.options.Atomic || manifestConfig.OnFailure == project.OnFailureRollback
 }
 
-// readManifestConfig reads a manifest config file.
-func (d *Deployer) readManifestConfig(configPath string) (*ManifestConfig, error) {
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, err
-	}
+// rollbackStack rolls back the changes of a failed stack and returns the error that failed it.
+func rollbackStack(rollbacker stackRollbacker, results []kubernetes.DeployResult, stackName string) ([]kubernetes.RollbackResult, error) {
+	failure := resourceFailures(results)
+	rolledBack := rollbacker.Rollback(results, stackName)
 
-	var config ManifestConfig
+	var errs []error
 
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
-		return nil, err
+	for _, rollback := range rolledBack {
+		if rollback.Error != nil {
+			resource := rollback.Resource
+			errs = append(errs, fmt.Errorf("%s %s/%s: %w", resource.GetKind(), resource.GetNamespace(), resource.GetName(), rollback.Error))
+		}
 	}
 
-	if config.Manifest == "" {
-		return nil, fmt.Errorf("manifest not specified in config file %s", configPath)
+	if len(errs) > 0 {
+		return rolledBack, fmt.Errorf("deployment failed: %w; rollback failed: %w", failure, errors.Join(errs...))
 	}
 
-	return &config, nil
+	return rolledBack, fmt.Errorf("deployment failed and was rolled back: %w", failure)
 }
 
-// createKubernetesConfig creates a Kubernetes REST config.
-func createKubernetesConfig() (*rest.Config, error) {
-	// Load kubeconfig from default location
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
+// resourceFailures describes every resource of a stack that failed to apply or become ready.
+func resourceFailures(results []kubernetes.DeployResult) error {
+	var errs []error
 
-	// Build config from kubeconfig
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides).ClientConfig()
-	if err != nil {
-		return nil, err
+	for _, result := range results {
+		if result.Error != nil {
+			errs = append(errs, result.Error)
+		} else if result.Failed() {
+			resource := result.Resource
+			errs = append(errs, fmt.Errorf("%s %s/%s did not become ready: %s", resource.GetKind(), resource.GetNamespace(), resource.GetName(), result.Status))
+		}
 	}
 
-	return config, nil
+	return errors.Join(errs...)
 }
 
-// findManifestFile searches for a manifest file in the manifests directory and its subdirectories.
-func (d *Deployer) findManifestFile(manifestName string) (string, error) {
-	// Find the project root (where manifests directory is located)
-	projectRoot := filepath.Dir(d.configDir)
-	manifestsDir := filepath.Join(projectRoot, "manifests")
-
-	// First check if the manifest exists directly in the manifests directory
-	manifestPath := filepath.Join(manifestsDir, manifestName)
-
-	var err error
+// pruneStack deletes objects of the stack that are no longer rendered, when pruning is enabled.
+// A stack from a saved plan only deletes the objects the plan listed for deletion.
+func (d *Deployer) pruneStack(k8sDeployer *kubernetes.Deployer, manifestData any, stackInfo *stack.StackInfo, planned *plan.StackPlan) ([]kubernetes.DeleteResult, error) {
+	if !d.options.Prune {
+		return nil, nil
+	}
 
-	_, err = os.Stat(manifestPath)
-	if err == nil {
-		return manifestPath, nil
+	pruned, err := d.pruneResources(k8sDeployer, manifestData, stackInfo, planned)
+	if err != nil {
+		return nil, fmt.Errorf("prune failed: %w", err)
 	}
 
-	// Check for Jinja template versions
-	jinjaExtensions := []string{".jinja", ".j2"}
-	for _, ext := range jinjaExtensions {
-		jinjaPath := strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + ext
+	var errs []error
 
-		_, err = os.Stat(jinjaPath)
-		if err == nil {
-			return jinjaPath, nil
+	for _, result := range pruned {
+		if result.Error != nil {
+			errs = append(errs, fmt.Errorf("%s %s/%s: %w", result.ResourceType, result.Namespace, result.ResourceName, result.Error))
 		}
 	}
 
-	// If not found, search in subdirectories
-	return d.findManifestInSubdirectories(manifestsDir, manifestName)
+	if len(errs) > 0 {
+		return pruned, fmt.Errorf("prune failed: %w", errors.Join(errs...))
+	}
+
+	d.logger.Debug("Prune completed", "stack", stackInfo.Name, "pruned", len(pruned))
+
+	return pruned, nil
 }
 
-// findManifestInSubdirectories recursively searches for a manifest file in subdirectories.
-func (d *Deployer) findManifestInSubdirectories(dir, manifestName string) (string, error) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return "", err
+// pruneResources deletes the objects a saved plan listed for deletion, or otherwise the live objects of
+// the stack that are no longer rendered.
+func (d *Deployer) pruneResources(k8sDeployer *kubernetes.Deployer, manifestData any, stackInfo *stack.StackInfo, planned *plan.StackPlan) ([]kubernetes.DeleteResult, error) {
+	if planned != nil {
+		return k8sDeployer.PrunePlanned(plannedDeletions(*planned), stackInfo.Name), nil
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			subdirPath := filepath.Join(dir, entry.Name())
-			if found := d.searchInSubdirectory(subdirPath, manifestName); found != "" {
-				return found, nil
-			}
-		}
+	manifestContent, err := d.manifestContent(manifestData)
+	if err != nil {
+		return nil, err
 	}
 
-	return "", fmt.Errorf("manifest file not found: %s (searched in manifests directory and subdirectories)", manifestName)
+	return k8sDeployer.PruneManifestContent(manifestContent, stackInfo.Name, stackInfo.Namespace)
 }
 
-// searchInSubdirectory searches for a manifest in a specific subdirectory.
-func (d *Deployer) searchInSubdirectory(subdirPath, manifestName string) string {
-	// Check for regular manifest file
-	manifestPath := filepath.Join(subdirPath, manifestName)
-	if d.fileExists(manifestPath) {
-		return manifestPath
-	}
+// manifestContent returns the manifest content for a manifest file path or rendered template content.
+func (d *Deployer) manifestContent(manifestData any) ([]byte, error) {
+	switch data := manifestData.(type) {
+	case string:
+		content, err := os.ReadFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("error reading manifest file: %w", err)
+		}
 
-	// Check for Jinja template versions
-	if jinjaPath := d.findJinjaTemplate(manifestPath); jinjaPath != "" {
-		return jinjaPath
+		return content, nil
+	case []byte:
+		return data, nil
+	default:
+		return nil, fmt.Errorf("invalid manifest data type: %T", manifestData)
 	}
+}
+
+// formatResponses formats the response strings of every resource in a stack.
+func (d *Deployer) formatResponses(results []kubernetes.DeployResult) string {
+	responses := make([]string, 0, len(results))
 
-	// Recursively search in deeper subdirectories
-	found, err := d.findManifestInSubdirectories(subdirPath, manifestName)
-	if err == nil {
-		return found
+	for i := range results {
+		responses = append(responses, d.formatResponse(&results[i]))
 	}
 
-	return ""
+	return strings.Join(responses, "; ")
 }
 
-// fileExists checks if a file exists.
-func (d *Deployer) fileExists(path string) bool {
-	_, err := os.Stat(path)
+// formatResponse formats the response string based on the deployment result.
+func (d *Deployer) formatResponse(result *kubernetes.DeployResult) string {
+	if len(result.Conflicts) > 0 {
+		return "Apply failed: " + d.formatConflicts(result.Conflicts)
+	}
+
+	if result.Error != nil {
+		return fmt.Sprintf("Apply failed: %v", result.Error)
+	}
 
-	return err == nil
+	return fmt.Sprintf("Applied %s/%s: %s in namespace %s (operation: %s, status: %s)",
+		result.Resource.GetAPIVersion(),
+		result.Resource.GetKind(),
+		result.Resource.GetName(),
+		result.Resource.GetNamespace(),
+		result.Operation,
+		result.Status)
 }
 
-// findJinjaTemplate looks for Jinja template versions of a manifest file.
-func (d *Deployer) findJinjaTemplate(manifestPath string) string {
-	jinjaExtensions := []string{".jinja", ".j2"}
-	for _, ext := range jinjaExtensions {
-		jinjaPath := strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + ext
-		if d.fileExists(jinjaPath) {
-			return jinjaPath
-		}
+// formatConflicts formats server-side apply field conflicts as a single line.
+func (d *Deployer) formatConflicts(conflicts []kubernetes.FieldConflict) string {
+	fields := make([]string, 0, len(conflicts))
+
+	for _, conflict := range conflicts {
+		fields = append(fields, fmt.Sprintf("%s (managed by %q)", conflict.Field, conflict.Manager))
 	}
 
-	return ""
+	return fmt.Sprintf("%d field conflict(s): %s; use --force-conflicts to take ownership",
+		len(conflicts),
+		strings.Join(fields, ", "))
 }
 
 // validateNamespaceConfiguration checks for namespace conflicts between config and manifest.
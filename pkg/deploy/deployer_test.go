@@ -2,225 +2,19 @@ package deploy
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-func TestFilterConfigFilesByStack(t *testing.T) {
-	// Create a temporary directory structure
-	tempDir := t.TempDir()
-
-	// Create config files
-	createTestConfigFiles(t, tempDir)
-
-	deployer := &Deployer{
-		configDir: tempDir,
-		logger:    slog.Default(),
-	}
-
-	tests := createFilterTestCases(tempDir)
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := deployer.filterConfigFilesByStack(tt.configFiles, tt.stackFilter)
-			validateFilterResult(t, result, tt.expected)
-		})
-	}
-}
-
-// createTestConfigFiles creates the test directory structure and config files.
-func createTestConfigFiles(t *testing.T, tempDir string) []string {
-	configFiles := []string{
-		filepath.Join(tempDir, "app.yaml"),
-		filepath.Join(tempDir, "api.yaml"),
-		filepath.Join(tempDir, "web.yaml"),
-		filepath.Join(tempDir, "dev", "app.yaml"),
-		filepath.Join(tempDir, "dev", "api.yaml"),
-		filepath.Join(tempDir, "prod", "web.yaml"),
-		filepath.Join(tempDir, "prod", "api.yaml"),
-	}
-
-	// Create the directory structure
-	for _, file := range configFiles {
-		dir := filepath.Dir(file)
-
-		err := os.MkdirAll(dir, 0o755)
-		if err != nil {
-			t.Fatalf("Failed to create directory %s: %v", dir, err)
-		}
-
-		err = os.WriteFile(file, []byte("manifest: test.yaml"), 0o600)
-		if err != nil {
-			t.Fatalf("Failed to create file %s: %v", file, err)
-		}
-	}
-
-	return configFiles
-}
-
-// createFilterTestCases creates test cases for filtering.
-func createFilterTestCases(tempDir string) []struct {
-	name        string
-	configFiles []string
-	stackFilter string
-	expected    []string
-} {
-	// Create the config files list for all tests
-	configFiles := []string{
-		filepath.Join(tempDir, "app.yaml"),
-		filepath.Join(tempDir, "api.yaml"),
-		filepath.Join(tempDir, "web.yaml"),
-		filepath.Join(tempDir, "dev", "app.yaml"),
-		filepath.Join(tempDir, "dev", "api.yaml"),
-		filepath.Join(tempDir, "prod", "web.yaml"),
-		filepath.Join(tempDir, "prod", "api.yaml"),
-	}
-
-	return []struct {
-		name        string
-		configFiles []string
-		stackFilter string
-		expected    []string
-	}{
-		{
-			name:        "no filter should return no files",
-			configFiles: configFiles,
-			stackFilter: "",
-			expected:    []string{},
-		},
-		{
-			name:        "exact match should return single file",
-			configFiles: configFiles,
-			stackFilter: "app",
-			expected: []string{
-				filepath.Join(tempDir, "app.yaml"),
-			},
-		},
-		{
-			name:        "partial match should return multiple files",
-			configFiles: configFiles,
-			stackFilter: "api",
-			expected: []string{
-				filepath.Join(tempDir, "api.yaml"),
-			},
-		},
-		{
-			name:        "directory filter should return files in directory",
-			configFiles: configFiles,
-			stackFilter: "dev",
-			expected: []string{
-				filepath.Join(tempDir, "dev", "app.yaml"),
-				filepath.Join(tempDir, "dev", "api.yaml"),
-			},
-		},
-		{
-			name:        "path filter should return files matching path",
-			configFiles: configFiles,
-			stackFilter: "dev/app",
-			expected: []string{
-				filepath.Join(tempDir, "dev", "app.yaml"),
-			},
-		},
-		{
-			name:        "non-matching filter should return empty",
-			configFiles: configFiles,
-			stackFilter: "nonexistent",
-			expected:    []string{},
-		},
-	}
-}
-
-// validateFilterResult validates the result of filtering.
-func validateFilterResult(t *testing.T, result, expected []string) {
-	if len(result) != len(expected) {
-		t.Errorf("filterConfigFilesByStack() returned %d files, want %d", len(result), len(expected))
-
-		return
-	}
-
-	// Convert to map for easier comparison
-	resultMap := make(map[string]bool)
-	for _, file := range result {
-		resultMap[file] = true
-	}
-
-	for _, expectedFile := range expected {
-		if !resultMap[expectedFile] {
-			t.Errorf("Expected file %s not found in result", expectedFile)
-		}
-	}
-}
-
-func TestMatchesStackFilter(t *testing.T) {
-	deployer := &Deployer{
-		logger: slog.Default(),
-	}
-
-	tests := []struct {
-		name      string
-		stackName string
-		filter    string
-		expected  bool
-	}{
-		{
-			name:      "exact match",
-			stackName: "frank-dev-app",
-			filter:    "frank-dev-app",
-			expected:  true,
-		},
-		{
-			name:      "prefix match with dash",
-			stackName: "frank-dev-app",
-			filter:    "frank-dev",
-			expected:  true,
-		},
-		{
-			name:      "prefix match without dash",
-			stackName: "frank-dev-app",
-			filter:    "frank",
-			expected:  true,
-		},
-		{
-			name:      "path pattern match",
-			stackName: "dev-app",
-			filter:    "dev/app",
-			expected:  true,
-		},
-		{
-			name:      "no match",
-			stackName: "frank-dev-app",
-			filter:    "prod",
-			expected:  false,
-		},
-		{
-			name:      "partial match in middle",
-			stackName: "frank-dev-app",
-			filter:    "dev-app",
-			expected:  false,
-		},
-		{
-			name:      "empty filter should not match",
-			stackName: "frank-dev-app",
-			filter:    "",
-			expected:  false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := deployer.matchesStackFilter(tt.stackName, tt.filter)
-			if result != tt.expected {
-				t.Errorf("matchesStackFilter(%s, %s) = %v, want %v", tt.stackName, tt.filter, result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestValidateNamespaceConfiguration(t *testing.T) {
 	// Create a temporary directory for test files
 	tempDir := t.TempDir()
@@ -485,3 +279,333 @@ func TestFormatResponseError(t *testing.T) {
 		t.Errorf("Expected response %q, got %q", expected, response)
 	}
 }
+
+// TestFormatResponses tests that every resource in a stack is reported.
+func TestFormatResponses(t *testing.T) {
+	deployer := &Deployer{
+		logger: slog.Default(),
+	}
+
+	results := []kubernetes.DeployResult{
+		{
+			Resource: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]any{
+						"name":      "web",
+						"namespace": "dev",
+					},
+				},
+			},
+			Operation: "created",
+			Status:    "Available",
+		},
+		{
+			Resource: &unstructured.Unstructured{
+				Object: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Service",
+					"metadata": map[string]any{
+						"name":      "web",
+						"namespace": "dev",
+					},
+				},
+			},
+			Operation: "failed",
+			Status:    "failed",
+			Error:     errors.New("port already allocated"),
+		},
+	}
+
+	response := deployer.formatResponses(results)
+
+	expected := "Applied apps/v1/Deployment: web in namespace dev (operation: created, status: Available); Apply failed: port already allocated"
+	if response != expected {
+		t.Errorf("Expected response %q, got %q", expected, response)
+	}
+
+	err := resourceFailures(results)
+	if err == nil || !contains(err.Error(), "port already allocated") {
+		t.Errorf("Expected joined error containing the Service failure, got %v", err)
+	}
+}
+
+// TestFormatResponseConflicts tests that server-side apply conflicts are reported per field.
+func TestFormatResponseConflicts(t *testing.T) {
+	deployer := &Deployer{
+		logger: slog.Default(),
+	}
+
+	result := &kubernetes.DeployResult{
+		Resource: &unstructured.Unstructured{
+			Object: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]any{
+					"name":      "web",
+					"namespace": "dev",
+				},
+			},
+		},
+		Operation: "failed",
+		Status:    "failed",
+		Error:     errors.New("apply failed with 2 conflicts"),
+		Conflicts: []kubernetes.FieldConflict{
+			{Field: ".spec.replicas", Manager: "hpa"},
+			{Field: ".spec.template.spec.containers[name=\"web\"].image", Manager: "kubectl"},
+		},
+	}
+
+	response := deployer.formatResponse(result)
+
+	expected := `Apply failed: 2 field conflict(s): .spec.replicas (managed by "hpa"), .spec.template.spec.containers[name="web"].image (managed by "kubectl"); use --force-conflicts to take ownership`
+	if response != expected {
+		t.Errorf("Expected response %q, got %q", expected, response)
+	}
+}
+
+// mockHealthChecker reports the stacks of a kubeconfig context as unhealthy when they have an error,
+// and fails stacks that aren't checked against their own manifest.
+type mockHealthChecker struct {
+	unhealthy map[string]error
+}
+
+func (m *mockHealthChecker) CheckStackHealth(stackName string, manifestContent []byte) error {
+	if string(manifestContent) != "manifest of "+stackName {
+		return fmt.Errorf("checked against %q", manifestContent)
+	}
+
+	return m.unhealthy[stackName]
+}
+
+// dependencyStacks returns stacks with names and contexts as dependencies of a stack.
+func dependencyStacks(infos ...*stack.StackInfo) []*project.Stack {
+	stacks := make([]*project.Stack, 0, len(infos))
+	for _, info := range infos {
+		stacks = append(stacks, &project.Stack{Info: info, ManifestPath: info.Name + ".yaml"})
+	}
+
+	return stacks
+}
+
+// testDependencyManifest returns "manifest of <stack>" as the manifest content of a dependency,
+// failing for the stack dev-broken.
+func testDependencyManifest(s *project.Stack) ([]byte, error) {
+	if s.Info.Name == "dev-broken" {
+		return nil, errors.New("error rendering template: unexpected end of template")
+	}
+
+	return []byte("manifest of " + strings.TrimSuffix(s.ManifestPath, ".yaml")), nil
+}
+
+func TestVerifyDependencies(t *testing.T) {
+	checkers := map[string]*mockHealthChecker{
+		"dev":  {unhealthy: map[string]error{"dev-queue": errors.New("stack dev-queue has no resources in context dev")}},
+		"prod": {},
+	}
+
+	checkerFor := func(kubeContext string) (stackHealthChecker, error) {
+		checker, ok := checkers[kubeContext]
+		if !ok {
+			return nil, errors.New("context " + kubeContext + " does not exist")
+		}
+
+		return checker, nil
+	}
+
+	tests := []struct {
+		name          string
+		dependencies  []*project.Stack
+		expectedError string
+	}{
+		{name: "no dependencies"},
+		{name: "healthy dependencies", dependencies: dependencyStacks(&stack.StackInfo{Name: "dev-db", Context: "dev"}, &stack.StackInfo{Name: "prod-db", Context: "prod"})},
+		{
+			name:          "unhealthy dependency",
+			dependencies:  dependencyStacks(&stack.StackInfo{Name: "dev-db", Context: "dev"}, &stack.StackInfo{Name: "dev-queue", Context: "dev"}),
+			expectedError: "dependency dev-queue is not selected and not ready: stack dev-queue has no resources in context dev",
+		},
+		{
+			name:          "unknown context",
+			dependencies:  dependencyStacks(&stack.StackInfo{Name: "test-db", Context: "test"}),
+			expectedError: "dependency test-db is not selected and not ready: context test does not exist",
+		},
+		{
+			name:          "manifest can't be rendered",
+			dependencies:  dependencyStacks(&stack.StackInfo{Name: "dev-broken", Context: "dev"}),
+			expectedError: "dependency dev-broken is not selected and not ready: error rendering template: unexpected end of template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyDependencies(tt.dependencies, testDependencyManifest, checkerFor)
+
+			errorMessage := ""
+			if err != nil {
+				errorMessage = err.Error()
+			}
+
+			if errorMessage != tt.expectedError {
+				t.Errorf("verifyDependencies() error = %q, want %q", errorMessage, tt.expectedError)
+			}
+		})
+	}
+}
+
+// newTestResource creates an object with an API version, kind, namespace and name.
+func newTestResource(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+
+	return obj
+}
+
+// mockRollbacker records the stack it rolled back and returns fixed rollback results.
+type mockRollbacker struct {
+	stackName string
+	results   []kubernetes.RollbackResult
+}
+
+func (m *mockRollbacker) Rollback(_ []kubernetes.DeployResult, stackName string) []kubernetes.RollbackResult {
+	m.stackName = stackName
+
+	return m.results
+}
+
+func TestRollbackStack(t *testing.T) {
+	deployment := newTestResource("apps/v1", "Deployment", "dev", "web")
+	configMap := newTestResource("v1", "ConfigMap", "dev", "settings")
+
+	results := []kubernetes.DeployResult{
+		{Resource: configMap, Operation: "applied", Status: "Ready"},
+		{Resource: deployment, Operation: "applied", Status: "timeout"},
+	}
+
+	tests := []struct {
+		name          string
+		rollbacks     []kubernetes.RollbackResult
+		expectedError string
+	}{
+		{
+			name: "rolled back",
+			rollbacks: []kubernetes.RollbackResult{
+				{Resource: deployment, Operation: "restored"},
+				{Resource: configMap, Operation: "restored"},
+			},
+			expectedError: "deployment failed and was rolled back: Deployment dev/web did not become ready: timeout",
+		},
+		{
+			name: "rollback failed",
+			rollbacks: []kubernetes.RollbackResult{
+				{Resource: deployment, Operation: "restored", Error: errors.New("conflict")},
+				{Resource: configMap, Operation: "restored"},
+			},
+			expectedError: "deployment failed: Deployment dev/web did not become ready: timeout; rollback failed: Deployment dev/web: conflict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollbacker := &mockRollbacker{results: tt.rollbacks}
+
+			rolledBack, err := rollbackStack(rollbacker, results, "dev-web")
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("rollbackStack() error = %v, want %q", err, tt.expectedError)
+			}
+
+			if rollbacker.stackName != "dev-web" || len(rolledBack) != len(tt.rollbacks) {
+				t.Errorf("rollbackStack() = %+v for %q, want the rollback of dev-web", rolledBack, rollbacker.stackName)
+			}
+		})
+	}
+}
+
+func TestResourceFailures(t *testing.T) {
+	deployment := newTestResource("apps/v1", "Deployment", "dev", "web")
+	configMap := newTestResource("v1", "ConfigMap", "dev", "settings")
+
+	tests := []struct {
+		name          string
+		results       []kubernetes.DeployResult
+		expectedError string
+	}{
+		{
+			name: "every resource ready",
+			results: []kubernetes.DeployResult{
+				{Resource: configMap, Operation: "no-change", Status: "ready"},
+				{Resource: deployment, Operation: "applied", Status: "Available"},
+			},
+		},
+		{
+			name: "readiness timeout",
+			results: []kubernetes.DeployResult{
+				{Resource: configMap, Operation: "applied", Status: "Ready"},
+				{Resource: deployment, Operation: "applied", Status: "timeout"},
+			},
+			expectedError: "Deployment dev/web did not become ready: timeout",
+		},
+		{
+			name: "progress deadline exceeded",
+			results: []kubernetes.DeployResult{
+				{Resource: deployment, Operation: "created", Status: "Failed"},
+			},
+			expectedError: "Deployment dev/web did not become ready: Failed",
+		},
+		{
+			name: "replica failure",
+			results: []kubernetes.DeployResult{
+				{Resource: deployment, Operation: "applied", Status: "ReplicaFailure"},
+			},
+			expectedError: "Deployment dev/web did not become ready: ReplicaFailure",
+		},
+		{
+			name: "apply error",
+			results: []kubernetes.DeployResult{
+				{Resource: configMap, Operation: "failed", Status: "failed", Error: errors.New("forbidden")},
+			},
+			expectedError: "forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var message string
+			if err := resourceFailures(tt.results); err != nil {
+				message = err.Error()
+			}
+
+			if message != tt.expectedError {
+				t.Errorf("resourceFailures() error = %q, want %q", message, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestRollbackOnFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		atomic    bool
+		onFailure string
+		expected  bool
+	}{
+		{name: "default", expected: false},
+		{name: "on_failure rollback", onFailure: project.OnFailureRollback, expected: true},
+		{name: "atomic", atomic: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer := &Deployer{logger: slog.Default(), options: Options{Atomic: tt.atomic}}
+
+			rollback := deployer.rollbackOnFailure(&project.ManifestConfig{OnFailure: tt.onFailure})
+			if rollback != tt.expected {
+				t.Errorf("rollbackOnFailure() = %v, want %v", rollback, tt.expected)
+			}
+		})
+	}
+}
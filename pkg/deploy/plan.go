@@ -0,0 +1,154 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/plan"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+)
+
+// resourceVersionReader reads the resourceVersion of live objects.
+type resourceVersionReader interface {
+	CurrentResourceVersion(apiVersion, kind, namespace, name string) (string, error)
+}
+
+// ApplyPlan applies the rendered manifests of a saved plan exactly as they were reviewed.
+// Nothing is applied when any object observed by the plan changed since the plan was taken, and
+// an object that changes while earlier stacks are applied fails its stack when it is written.
+func (d *Deployer) ApplyPlan(planFile *plan.File) ([]DeploymentResult, error) {
+	err := d.verifyPlan(planFile)
+	if err != nil {
+		return nil, err
+	}
+
+	stackPlans := make(map[string]plan.StackPlan, len(planFile.Stacks))
+	for _, stackPlan := range planFile.Stacks {
+		stackPlans[stackPlan.StackName] = stackPlan
+	}
+
+	graph := planFile.DependencyGraph()
+
+	d.logger.Debug("Applying saved plan", "stacks", len(graph.Stacks), "created_at", planFile.CreatedAt, "parallelism", d.options.Parallelism)
+
+	// Execute stacks as soon as their dependencies have finished
+	deploymentResults := runStacks(graph, d.options.Parallelism, d.options.FailFast, func(stackInfo *stack.StackInfo) DeploymentResult {
+		return d.applyStackPlan(stackPlans[stackInfo.Name], stackInfo)
+	})
+
+	d.logger.Debug("All applies completed", "total", len(deploymentResults))
+
+	return deploymentResults, nil
+}
+
+// applyStackPlan applies the saved manifest content of a single stack.
+func (d *Deployer) applyStackPlan(stackPlan plan.StackPlan, stackInfo *stack.StackInfo) DeploymentResult {
+	d.logger.Debug("Starting apply from plan", "stack", stackInfo.Name, "context", stackInfo.Context)
+
+	manifestConfig := &project.ManifestConfig{
+		Manifest:  stackPlan.Manifest,
+		Timeout:   stackPlan.Timeout,
+		OnFailure: stackPlan.OnFailure,
+	}
+
+	result := d.validateAndApplyManifest([]byte(stackPlan.Content), manifestConfig, stackInfo, &stackPlan, time.Now())
+
+	// Stacks that depend on a failed stack are skipped by the scheduler
+	if result.Error != nil {
+		d.logger.Error("Deployment failed", "stack", stackInfo.Name, "error", result.Error)
+	}
+
+	return result
+}
+
+// observedVersions returns the resourceVersion the plan observed for every object a stack applies.
+func observedVersions(stackPlan plan.StackPlan) kubernetes.ObservedVersions {
+	observed := make(kubernetes.ObservedVersions, len(stackPlan.Resources))
+
+	for _, resource := range stackPlan.Resources {
+		if resource.Operation != "delete" {
+			observed[kubernetes.NewObjectKey(resource.APIVersion, resource.Kind, resource.Namespace, resource.Name)] = resource.ResourceVersion
+		}
+	}
+
+	return observed
+}
+
+// plannedDeletions returns the objects the plan deletes from a stack, with the resourceVersion it observed.
+func plannedDeletions(stackPlan plan.StackPlan) []kubernetes.PlannedDeletion {
+	var deletions []kubernetes.PlannedDeletion
+
+	for _, resource := range stackPlan.Resources {
+		if resource.Operation == "delete" {
+			deletions = append(deletions, kubernetes.PlannedDeletion{
+				APIVersion:      resource.APIVersion,
+				Kind:            resource.Kind,
+				Name:            resource.Name,
+				Namespace:       resource.Namespace,
+				ResourceVersion: resource.ResourceVersion,
+			})
+		}
+	}
+
+	return deletions
+}
+
+// verifyPlan checks that every object observed by the plan is still in the state it was planned against.
+func (d *Deployer) verifyPlan(planFile *plan.File) error {
+	var errs []error
+
+	for _, stackPlan := range planFile.Stacks {
+		k8sDeployer, err := d.deployers.Get(stackPlan.Context)
+		if err != nil {
+			return err
+		}
+
+		errs = append(errs, staleResources(k8sDeployer, stackPlan)...)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("plan is out of date, run frank plan again: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// staleResources returns an error for every resource of a stack that changed since the plan was taken.
+func staleResources(reader resourceVersionReader, stackPlan plan.StackPlan) []error {
+	var errs []error
+
+	for _, resource := range stackPlan.Resources {
+		err := verifyResource(reader, resource)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", stackPlan.StackName, err))
+		}
+	}
+
+	return errs
+}
+
+// verifyResource checks that a live object still has the resourceVersion observed by the plan.
+func verifyResource(reader resourceVersionReader, resource plan.ObservedResource) error {
+	current, err := reader.CurrentResourceVersion(resource.APIVersion, resource.Kind, resource.Namespace, resource.Name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case current == resource.ResourceVersion:
+		return nil
+	case resource.ResourceVersion == "":
+		return fmt.Errorf("%s was created since the plan was taken", resource)
+	case current == "":
+		return fmt.Errorf("%s was deleted since the plan was taken", resource)
+	default:
+		return fmt.Errorf("%s changed since the plan was taken (resourceVersion %s, now %s)", resource, resource.ResourceVersion, current)
+	}
+}
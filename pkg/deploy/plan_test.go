@@ -0,0 +1,143 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package deploy
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/plan"
+)
+
+// mockVersionReader returns resourceVersions from a map keyed by object name.
+type mockVersionReader struct {
+	versions map[string]string
+	err      error
+}
+
+func (m *mockVersionReader) CurrentResourceVersion(apiVersion, kind, namespace, name string) (string, error) {
+	return m.versions[name], m.err
+}
+
+func TestVerifyResource(t *testing.T) {
+	reader := &mockVersionReader{versions: map[string]string{"web": "42", "api": "8"}}
+
+	tests := []struct {
+		name          string
+		resource      plan.ObservedResource
+		expectedError string
+	}{
+		{
+			name:     "unchanged object",
+			resource: plan.ObservedResource{Kind: "Service", Namespace: "dev", Name: "web", ResourceVersion: "42"},
+		},
+		{
+			name:     "still missing object",
+			resource: plan.ObservedResource{Kind: "Service", Namespace: "dev", Name: "worker"},
+		},
+		{
+			name:          "changed object",
+			resource:      plan.ObservedResource{Kind: "Service", Namespace: "dev", Name: "api", ResourceVersion: "7"},
+			expectedError: "Service/dev/api changed since the plan was taken (resourceVersion 7, now 8)",
+		},
+		{
+			name:          "created object",
+			resource:      plan.ObservedResource{Kind: "Service", Namespace: "dev", Name: "web"},
+			expectedError: "Service/dev/web was created since the plan was taken",
+		},
+		{
+			name:          "deleted object",
+			resource:      plan.ObservedResource{Kind: "Service", Namespace: "dev", Name: "worker", ResourceVersion: "3"},
+			expectedError: "Service/dev/worker was deleted since the plan was taken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyResource(reader, tt.resource)
+			validateVerifyResourceError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func validateVerifyResourceError(t *testing.T, err error, expectedError string) {
+	if expectedError == "" {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		return
+	}
+
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("Expected error %q, got %v", expectedError, err)
+	}
+}
+
+func TestStaleResources(t *testing.T) {
+	stackPlan := plan.StackPlan{
+		StackName: "dev-web",
+		Resources: []plan.ObservedResource{
+			{Kind: "Service", Namespace: "dev", Name: "web", ResourceVersion: "42"},
+			{Kind: "ConfigMap", Namespace: "dev", Name: "settings", ResourceVersion: "1"},
+		},
+	}
+
+	errs := staleResources(&mockVersionReader{versions: map[string]string{"web": "42", "settings": "2"}}, stackPlan)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "dev-web: ConfigMap/dev/settings changed") {
+		t.Errorf("Expected only the ConfigMap to be stale, got %v", errs)
+	}
+
+	errs = staleResources(&mockVersionReader{err: errors.New("connection refused")}, stackPlan)
+	if len(errs) != 2 {
+		t.Errorf("Expected read errors for every resource, got %v", errs)
+	}
+}
+
+func TestObservedVersions(t *testing.T) {
+	stackPlan := plan.StackPlan{
+		StackName: "dev-web",
+		Resources: []plan.ObservedResource{
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Namespace: "dev", Operation: "update", ResourceVersion: "42"},
+			{APIVersion: "v1", Kind: "Service", Name: "web", Namespace: "dev", Operation: "create"},
+			{APIVersion: "v1", Kind: "ConfigMap", Name: "old", Namespace: "dev", Operation: "delete", ResourceVersion: "7"},
+		},
+	}
+
+	observed := observedVersions(stackPlan)
+
+	expected := kubernetes.ObservedVersions{
+		kubernetes.NewObjectKey("apps/v1", "Deployment", "dev", "web"): "42",
+		kubernetes.NewObjectKey("v1", "Service", "dev", "web"):         "",
+	}
+
+	if !maps.Equal(observed, expected) {
+		t.Errorf("observedVersions() = %v, want %v", observed, expected)
+	}
+}
+
+func TestPlannedDeletions(t *testing.T) {
+	stackPlan := plan.StackPlan{
+		StackName: "dev-web",
+		Resources: []plan.ObservedResource{
+			{APIVersion: "v1", Kind: "Service", Name: "web", Namespace: "dev", Operation: "update", ResourceVersion: "42"},
+			{APIVersion: "v1", Kind: "ConfigMap", Name: "old", Namespace: "dev", Operation: "delete", ResourceVersion: "7"},
+		},
+	}
+
+	deletions := plannedDeletions(stackPlan)
+
+	expected := []kubernetes.PlannedDeletion{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "old", Namespace: "dev", ResourceVersion: "7"},
+	}
+
+	if !slices.Equal(deletions, expected) {
+		t.Errorf("plannedDeletions() = %v, want %v", deletions, expected)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+)
+
+// scheduler tracks which stacks of a dependency graph are ready to run.
+type scheduler struct {
+	stacks     []*stack.StackInfo
+	pending    []int
+	dependents [][]int
+	ready      []int
+	// skip holds why a stack won't run because one of its dependencies failed or was skipped.
+	skip []string
+	// failFast stops starting stacks once any stack failed, and stopped says which one.
+	failFast bool
+	stopped  string
+}
+
+// newScheduler builds a scheduler for a resolved dependency graph.
+func newScheduler(graph *stack.DependencyGraph, failFast bool) *scheduler {
+	index := make(map[string]int, len(graph.Stacks))
+	for i, stackInfo := range graph.Stacks {
+		index[stackInfo.Name] = i
+	}
+
+	s := &scheduler{
+		stacks:     graph.Stacks,
+		pending:    make([]int, len(graph.Stacks)),
+		dependents: make([][]int, len(graph.Stacks)),
+		skip:       make([]string, len(graph.Stacks)),
+		failFast:   failFast,
+	}
+
+	for i, stackInfo := range graph.Stacks {
+		for _, dep := range uniqueStrings(graph.DependsOn[stackInfo.Name]) {
+			depIndex, exists := index[dep]
+			if !exists {
+				continue
+			}
+
+			s.pending[i]++
+			s.dependents[depIndex] = append(s.dependents[depIndex], i)
+		}
+	}
+
+	for i := range graph.Stacks {
+		if s.pending[i] == 0 {
+			s.ready = append(s.ready, i)
+		}
+	}
+
+	return s
+}
+
+// next removes and returns the next stack that is ready to run.
+func (s *scheduler) next() (int, bool) {
+	if len(s.ready) == 0 {
+		return 0, false
+	}
+
+	i := s.ready[0]
+	s.ready = s.ready[1:]
+
+	return i, true
+}
+
+// complete marks a stack as finished and releases dependents whose dependencies are all finished.
+// Dependents of a stack that failed or was skipped are skipped as well.
+func (s *scheduler) complete(i int, result DeploymentResult) {
+	reason := s.failureReason(i, result)
+
+	for _, dependent := range s.dependents[i] {
+		if reason != "" && s.skip[dependent] == "" {
+			s.skip[dependent] = reason
+		}
+
+		s.pending[dependent]--
+		if s.pending[dependent] == 0 {
+			s.ready = append(s.ready, dependent)
+		}
+	}
+}
+
+// failureReason returns why the dependents of a finished stack are skipped, or "" when they can run.
+// With failFast, the first failure also stops every stack that hasn't started yet.
+func (s *scheduler) failureReason(i int, result DeploymentResult) string {
+	name := s.stacks[i].Name
+
+	switch {
+	case result.Skipped:
+		return fmt.Sprintf("dependency %s was skipped", name)
+	case result.Error != nil:
+		if s.failFast && s.stopped == "" {
+			s.stopped = fmt.Sprintf("apply stopped after %s failed", name)
+		}
+
+		return fmt.Sprintf("dependency %s failed", name)
+	default:
+		return ""
+	}
+}
+
+// skipReason returns why a ready stack won't run, or "" when it runs.
+func (s *scheduler) skipReason(i int) string {
+	if s.skip[i] != "" {
+		return s.skip[i]
+	}
+
+	return s.stopped
+}
+
+// runStacks runs every stack in the graph with at most parallelism stacks in flight.
+// A stack starts as soon as all of its dependencies have finished, and is skipped when one of them
+// failed or was skipped. With failFast, no stack starts after the first failure. Results are returned
+// in the graph's execution order regardless of the order in which stacks complete.
+func runStacks(graph *stack.DependencyGraph, parallelism int, failFast bool, run func(*stack.StackInfo) DeploymentResult) []DeploymentResult {
+	parallelism = max(parallelism, 1)
+
+	s := newScheduler(graph, failFast)
+	results := make([]DeploymentResult, len(graph.Stacks))
+	done := make(chan int)
+	running := 0
+	remaining := len(graph.Stacks)
+
+	for {
+		started, skipped := s.startReady(parallelism-running, results, done, run)
+		running += started
+		remaining -= skipped
+
+		if remaining == 0 {
+			return results
+		}
+
+		i := <-done
+		running--
+		remaining--
+
+		s.complete(i, results[i])
+	}
+}
+
+// startReady starts up to limit ready stacks and returns how many were started. Stacks that are
+// skipped don't count against the limit, and are completed right away.
+func (s *scheduler) startReady(limit int, results []DeploymentResult, done chan<- int, run func(*stack.StackInfo) DeploymentResult) (int, int) {
+	started, skipped := 0, 0
+
+	for started < limit {
+		i, ok := s.next()
+		if !ok {
+			break
+		}
+
+		if reason := s.skipReason(i); reason != "" {
+			results[i] = skippedResult(s.stacks[i], reason)
+			s.complete(i, results[i])
+			skipped++
+
+			continue
+		}
+
+		go func() {
+			results[i] = run(s.stacks[i])
+			done <- i
+		}()
+
+		started++
+	}
+
+	return started, skipped
+}
+
+// skippedResult records a stack that wasn't applied and why.
+func skippedResult(stackInfo *stack.StackInfo, reason string) DeploymentResult {
+	return DeploymentResult{
+		Context:    stackInfo.Context,
+		StackName:  stackInfo.Name,
+		Skipped:    true,
+		SkipReason: reason,
+		Timestamp:  time.Now(),
+	}
+}
+
+// uniqueStrings returns the distinct values of a slice, preserving their order.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,181 @@
+package deploy
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+)
+
+// recordingRunner records the order stacks finish in and the peak number of concurrent runs.
+type recordingRunner struct {
+	mu       sync.Mutex
+	running  int
+	peak     int
+	finished []string
+}
+
+func (r *recordingRunner) run(stackInfo *stack.StackInfo) DeploymentResult {
+	r.mu.Lock()
+	r.running++
+	r.peak = max(r.peak, r.running)
+	r.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	r.mu.Lock()
+	r.running--
+	r.finished = append(r.finished, stackInfo.Name)
+	r.mu.Unlock()
+
+	return DeploymentResult{StackName: stackInfo.Name}
+}
+
+// finishedBefore reports whether stack a finished before stack b.
+func (r *recordingRunner) finishedBefore(a, b string) bool {
+	positions := make(map[string]int, len(r.finished))
+	for i, name := range r.finished {
+		positions[name] = i
+	}
+
+	return positions[a] < positions[b]
+}
+
+func TestRunStacks(t *testing.T) {
+	graph, err := stack.ResolveDependencyGraph([]stack.StackWithDependencies{
+		{StackInfo: &stack.StackInfo{Name: "database"}},
+		{StackInfo: &stack.StackInfo{Name: "redis"}},
+		{StackInfo: &stack.StackInfo{Name: "api"}, DependsOn: []string{"database", "redis"}},
+		{StackInfo: &stack.StackInfo{Name: "worker"}, DependsOn: []string{"database"}},
+		{StackInfo: &stack.StackInfo{Name: "web"}, DependsOn: []string{"api"}},
+	})
+	if err != nil {
+		t.Fatalf("ResolveDependencyGraph() unexpected error: %v", err)
+	}
+
+	runner := &recordingRunner{}
+	results := runStacks(graph, 2, false, runner.run)
+
+	// Results follow the graph's execution order
+	for i, stackInfo := range graph.Stacks {
+		if results[i].StackName != stackInfo.Name {
+			t.Errorf("results[%d] = %s, want %s", i, results[i].StackName, stackInfo.Name)
+		}
+	}
+
+	if runner.peak > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", runner.peak)
+	}
+
+	if runner.peak < 2 {
+		t.Errorf("peak concurrency = %d, expected independent stacks to run in parallel", runner.peak)
+	}
+
+	for _, edge := range [][2]string{{"database", "api"}, {"redis", "api"}, {"database", "worker"}, {"api", "web"}} {
+		if !runner.finishedBefore(edge[0], edge[1]) {
+			t.Errorf("%s finished after its dependent %s", edge[0], edge[1])
+		}
+	}
+}
+
+func TestRunStacksSequential(t *testing.T) {
+	graph, err := stack.ResolveDependencyGraph([]stack.StackWithDependencies{
+		{StackInfo: &stack.StackInfo{Name: "one"}},
+		{StackInfo: &stack.StackInfo{Name: "two"}},
+		{StackInfo: &stack.StackInfo{Name: "three"}},
+	})
+	if err != nil {
+		t.Fatalf("ResolveDependencyGraph() unexpected error: %v", err)
+	}
+
+	runner := &recordingRunner{}
+	results := runStacks(graph, 0, false, runner.run)
+
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+
+	if runner.peak != 1 {
+		t.Errorf("peak concurrency = %d, want 1 when parallelism is below 1", runner.peak)
+	}
+}
+
+// failingRun returns a runner that fails the named stacks and records every stack it ran.
+func failingRun(ran *sync.Map, failing ...string) func(*stack.StackInfo) DeploymentResult {
+	return func(stackInfo *stack.StackInfo) DeploymentResult {
+		ran.Store(stackInfo.Name, true)
+
+		if slices.Contains(failing, stackInfo.Name) {
+			return DeploymentResult{StackName: stackInfo.Name, Error: errors.New("apply failed")}
+		}
+
+		return DeploymentResult{StackName: stackInfo.Name}
+	}
+}
+
+func TestRunStacksSkipsDependentsOfFailedStacks(t *testing.T) {
+	graph, err := stack.ResolveDependencyGraph([]stack.StackWithDependencies{
+		{StackInfo: &stack.StackInfo{Name: "database"}},
+		{StackInfo: &stack.StackInfo{Name: "redis"}},
+		{StackInfo: &stack.StackInfo{Name: "api"}, DependsOn: []string{"database", "redis"}},
+		{StackInfo: &stack.StackInfo{Name: "web"}, DependsOn: []string{"api"}},
+		{StackInfo: &stack.StackInfo{Name: "cache"}, DependsOn: []string{"redis"}},
+	})
+	if err != nil {
+		t.Fatalf("ResolveDependencyGraph() unexpected error: %v", err)
+	}
+
+	var ran sync.Map
+
+	results := runStacks(graph, 2, false, failingRun(&ran, "database"))
+
+	expected := map[string]string{
+		"api": "dependency database failed",
+		"web": "dependency api was skipped",
+	}
+
+	for _, result := range results {
+		if result.SkipReason != expected[result.StackName] || result.Skipped != (expected[result.StackName] != "") {
+			t.Errorf("%s skipped = %v (%q), want %q", result.StackName, result.Skipped, result.SkipReason, expected[result.StackName])
+		}
+
+		if _, ok := ran.Load(result.StackName); ok == result.Skipped {
+			t.Errorf("%s ran = %v, skipped = %v", result.StackName, ok, result.Skipped)
+		}
+	}
+}
+
+func TestRunStacksFailFast(t *testing.T) {
+	graph, err := stack.ResolveDependencyGraph([]stack.StackWithDependencies{
+		{StackInfo: &stack.StackInfo{Name: "database"}},
+		{StackInfo: &stack.StackInfo{Name: "web"}, DependsOn: []string{"database"}},
+		{StackInfo: &stack.StackInfo{Name: "one"}},
+		{StackInfo: &stack.StackInfo{Name: "two"}},
+	})
+	if err != nil {
+		t.Fatalf("ResolveDependencyGraph() unexpected error: %v", err)
+	}
+
+	var ran sync.Map
+
+	// Stacks run one at a time, so nothing starts after the first stack failed
+	results := runStacks(graph, 1, true, failingRun(&ran, graph.Stacks[0].Name))
+	first := graph.Stacks[0].Name
+
+	for _, result := range results[1:] {
+		if !result.Skipped || result.SkipReason == "" {
+			t.Errorf("%s was not skipped after %s failed", result.StackName, first)
+		}
+
+		if _, ok := ran.Load(result.StackName); ok {
+			t.Errorf("%s ran after %s failed", result.StackName, first)
+		}
+	}
+
+	if results[0].Error == nil {
+		t.Errorf("%s result has no error", first)
+	}
+}
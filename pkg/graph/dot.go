@@ -0,0 +1,56 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dot renders the graph in the Graphviz DOT language. Each wave is a cluster and edges point from a
+// dependency to the stacks that depend on it, in the order the stacks run.
+func (v *view) dot() string {
+	var builder strings.Builder
+
+	builder.WriteString("digraph stacks {\n")
+	builder.WriteString("  rankdir=LR;\n")
+	builder.WriteString("  node [shape=box];\n")
+
+	groups, unranked := v.waveGroups()
+
+	for i, group := range groups {
+		fmt.Fprintf(&builder, "\n  subgraph cluster_wave_%d {\n", i+1)
+		fmt.Fprintf(&builder, "    label=\"wave %d\";\n", i+1)
+
+		for _, name := range group {
+			fmt.Fprintf(&builder, "    %q;\n", name)
+		}
+
+		builder.WriteString("  }\n")
+	}
+
+	if len(unranked) > 0 {
+		builder.WriteString("\n")
+	}
+
+	for _, name := range unranked {
+		fmt.Fprintf(&builder, "  %q [color=red, label=%q];\n", name, v.label(name))
+	}
+
+	builder.WriteString("\n")
+
+	for _, e := range v.edges() {
+		attributes := ""
+		if v.cycle[e] {
+			attributes = " [color=red, penwidth=2]"
+		}
+
+		fmt.Fprintf(&builder, "  %q -> %q%s;\n", e.to, e.from, attributes)
+	}
+
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
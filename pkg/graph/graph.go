@@ -0,0 +1,149 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+)
+
+// Output formats supported by Render.
+const (
+	FormatTree    = "tree"
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+// Formats lists every output format supported by Render.
+var Formats = []string{FormatTree, FormatDOT, FormatMermaid}
+
+// edge is a dependency of one stack on another.
+type edge struct {
+	from string // The dependent stack
+	to   string // The stack it depends on
+}
+
+// view is a dependency graph prepared for rendering.
+type view struct {
+	graph *stack.DependencyGraph
+	// names lists the stacks in lexical order.
+	names []string
+	// waves maps each stack to its 1-based execution wave. Stacks on or behind a cycle have no wave.
+	waves map[string]int
+	// cycle holds the dependencies along the first circular dependency of the graph.
+	cycle map[edge]bool
+	// onCycle holds the stacks along the first circular dependency of the graph.
+	onCycle map[string]bool
+}
+
+// Render formats a dependency graph as a plain-text tree, a Graphviz DOT graph or a Mermaid flowchart.
+// Every stack is labeled with the execution wave it runs in, stacks of the same wave can run concurrently.
+// When the graph has a circular dependency, the stacks and dependencies along it are highlighted.
+func Render(graph *stack.DependencyGraph, format string) (string, error) {
+	v := newView(graph)
+
+	switch format {
+	case FormatTree:
+		return v.tree(), nil
+	case FormatDOT:
+		return v.dot(), nil
+	case FormatMermaid:
+		return v.mermaid(), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format %q, use %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// newView computes the waves and the cycle of a graph.
+func newView(graph *stack.DependencyGraph) *view {
+	v := &view{
+		graph:   graph,
+		waves:   make(map[string]int),
+		cycle:   make(map[edge]bool),
+		onCycle: make(map[string]bool),
+	}
+
+	for _, stackInfo := range graph.Stacks {
+		v.names = append(v.names, stackInfo.Name)
+	}
+
+	sort.Strings(v.names)
+
+	for i, wave := range graph.Waves() {
+		for _, stackInfo := range wave {
+			v.waves[stackInfo.Name] = i + 1
+		}
+	}
+
+	path := graph.Cycle()
+	for i := 0; i+1 < len(path); i++ {
+		v.cycle[edge{from: path[i], to: path[i+1]}] = true
+		v.onCycle[path[i]] = true
+	}
+
+	return v
+}
+
+// dependencies returns the dependencies of a stack in lexical order.
+func (v *view) dependencies(name string) []string {
+	deps := slices.Clone(v.graph.DependsOn[name])
+	sort.Strings(deps)
+
+	return deps
+}
+
+// edges returns every dependency of the graph, ordered by dependent and then by dependency.
+func (v *view) edges() []edge {
+	var edges []edge
+
+	for _, name := range v.names {
+		for _, dep := range v.dependencies(name) {
+			edges = append(edges, edge{from: name, to: dep})
+		}
+	}
+
+	return edges
+}
+
+// label describes a stack with its wave, or why it has none.
+func (v *view) label(name string) string {
+	switch {
+	case v.waves[name] > 0:
+		return fmt.Sprintf("%s (wave %d)", name, v.waves[name])
+	case v.onCycle[name]:
+		return name + " (cycle)"
+	default:
+		return name + " (blocked by cycle)"
+	}
+}
+
+// waveGroups returns the stacks of every wave in order, followed by the stacks without a wave.
+func (v *view) waveGroups() ([][]string, []string) {
+	var (
+		groups   [][]string
+		unranked []string
+	)
+
+	for _, name := range v.names {
+		wave := v.waves[name]
+		if wave == 0 {
+			unranked = append(unranked, name)
+
+			continue
+		}
+
+		for len(groups) < wave {
+			groups = append(groups, nil)
+		}
+
+		groups[wave-1] = append(groups[wave-1], name)
+	}
+
+	return groups, unranked
+}
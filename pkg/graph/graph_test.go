@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+)
+
+// newTestGraph creates a dependency graph from stack names and their dependencies.
+func newTestGraph(dependsOn map[string][]string) *stack.DependencyGraph {
+	graph := &stack.DependencyGraph{DependsOn: dependsOn}
+	for name := range dependsOn {
+		graph.Stacks = append(graph.Stacks, &stack.StackInfo{Name: name})
+	}
+
+	return graph
+}
+
+func TestRenderTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		dependsOn map[string][]string
+		expected  string
+	}{
+		{
+			name: "waves",
+			dependsOn: map[string][]string{
+				"db": nil, "redis": nil, "api": {"redis", "db"}, "worker": {"db"}, "web": {"api"}, "admin": {"api"},
+			},
+			expected: `admin (wave 3)
+└── api (wave 2)
+    ├── db (wave 1)
+    └── redis (wave 1)
+web (wave 3)
+└── api (wave 2) (see above)
+worker (wave 2)
+└── db (wave 1)
+`,
+		},
+		{
+			name:      "cycle",
+			dependsOn: map[string][]string{"web": {"api"}, "api": {"queue"}, "queue": {"worker"}, "worker": {"api"}},
+			expected: `web (blocked by cycle)
+└── api (cycle)
+    └── queue (cycle)
+        └── worker (cycle)
+            └── api (cycle: api -> queue -> worker -> api)
+`,
+		},
+		{
+			name:      "cycle without roots",
+			dependsOn: map[string][]string{"a": {"b"}, "b": {"a"}},
+			expected: `a (cycle)
+└── b (cycle)
+    └── a (cycle: a -> b -> a)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := Render(newTestGraph(tt.dependsOn), FormatTree)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			if output != tt.expected {
+				t.Errorf("Render() =\n%s\nwant\n%s", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderDOT(t *testing.T) {
+	output, err := Render(newTestGraph(map[string][]string{"db": nil, "api": {"db", "cache"}, "cache": {"api"}}), FormatDOT)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	for _, expected := range []string{
+		"digraph stacks {",
+		"subgraph cluster_wave_1 {\n    label=\"wave 1\";\n    \"db\";\n  }",
+		`"api" [color=red, label="api (cycle)"];`,
+		`"db" -> "api";`,
+		`"cache" -> "api" [color=red, penwidth=2];`,
+		`"api" -> "cache" [color=red, penwidth=2];`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Render() =\n%s\nwant it to contain %q", output, expected)
+		}
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	output, err := Render(newTestGraph(map[string][]string{"db": nil, "api": {"db", "cache"}, "cache": {"api"}}), FormatMermaid)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	// Nodes are numbered in lexical order: api, cache, db
+	for _, expected := range []string{
+		"flowchart LR\n",
+		"  subgraph wave1 [\"wave 1\"]\n    s2[\"db\"]\n  end\n",
+		"  s0[\"api (cycle)\"]:::cycle\n",
+		"  s1 --> s0\n  s2 --> s0\n  s0 --> s1\n",
+		"  linkStyle 0,2 stroke:#d00,stroke-width:2px\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Render() =\n%s\nwant it to contain %q", output, expected)
+		}
+	}
+}
+
+func TestRenderUnsupportedFormat(t *testing.T) {
+	_, err := Render(newTestGraph(map[string][]string{"db": nil}), "svg")
+	if err == nil || !strings.Contains(err.Error(), `unsupported graph format "svg"`) {
+		t.Errorf("Render() error = %v, want unsupported graph format", err)
+	}
+}
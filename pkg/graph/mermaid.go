@@ -0,0 +1,62 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// mermaid renders the graph as a Mermaid flowchart. Each wave is a subgraph and links point from a
+// dependency to the stacks that depend on it, in the order the stacks run.
+func (v *view) mermaid() string {
+	var builder strings.Builder
+
+	builder.WriteString("flowchart LR\n")
+
+	// Stack names aren't valid Mermaid node IDs, so nodes are numbered in lexical order
+	ids := make(map[string]string, len(v.names))
+	for i, name := range v.names {
+		ids[name] = "s" + strconv.Itoa(i)
+	}
+
+	groups, unranked := v.waveGroups()
+
+	for i, group := range groups {
+		fmt.Fprintf(&builder, "  subgraph wave%d [\"wave %d\"]\n", i+1, i+1)
+
+		for _, name := range group {
+			fmt.Fprintf(&builder, "    %s[\"%s\"]\n", ids[name], name)
+		}
+
+		builder.WriteString("  end\n")
+	}
+
+	for _, name := range unranked {
+		fmt.Fprintf(&builder, "  %s[\"%s\"]:::cycle\n", ids[name], v.label(name))
+	}
+
+	// Links are styled by their index in the order they are declared
+	var cycleLinks []string
+
+	for i, e := range v.edges() {
+		if v.cycle[e] {
+			cycleLinks = append(cycleLinks, strconv.Itoa(i))
+		}
+
+		fmt.Fprintf(&builder, "  %s --> %s\n", ids[e.to], ids[e.from])
+	}
+
+	if len(unranked) > 0 {
+		builder.WriteString("  classDef cycle stroke:#d00,stroke-width:2px\n")
+	}
+
+	if len(cycleLinks) > 0 {
+		fmt.Fprintf(&builder, "  linkStyle %s stroke:#d00,stroke-width:2px\n", strings.Join(cycleLinks, ","))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package graph
+
+import (
+	"slices"
+	"strings"
+)
+
+// tree renders every stack that no other stack depends on with its dependencies below it. A stack
+// whose dependencies were already shown is marked "see above" instead of repeating them.
+func (v *view) tree() string {
+	var builder strings.Builder
+
+	printed := make(map[string]bool)
+
+	for _, root := range v.roots() {
+		builder.WriteString(v.label(root) + "\n")
+		printed[root] = true
+		v.writeDependencies(&builder, root, "", []string{root}, printed)
+	}
+
+	return builder.String()
+}
+
+// roots returns the stacks no other stack depends on, followed by stacks that are only reachable
+// through a cycle, in lexical order.
+func (v *view) roots() []string {
+	dependedOn := make(map[string]bool)
+	for _, deps := range v.graph.DependsOn {
+		for _, dep := range deps {
+			dependedOn[dep] = true
+		}
+	}
+
+	var roots []string
+
+	reached := make(map[string]bool)
+
+	for _, name := range v.names {
+		if !dependedOn[name] {
+			roots = append(roots, name)
+			v.reach(name, reached)
+		}
+	}
+
+	for _, name := range v.names {
+		if !reached[name] {
+			roots = append(roots, name)
+			v.reach(name, reached)
+		}
+	}
+
+	return roots
+}
+
+// reach marks a stack and everything it depends on as reached.
+func (v *view) reach(name string, reached map[string]bool) {
+	if reached[name] {
+		return
+	}
+
+	reached[name] = true
+
+	for _, dep := range v.graph.DependsOn[name] {
+		v.reach(dep, reached)
+	}
+}
+
+// writeDependencies writes the dependencies of a stack as tree branches. The path holds the stacks from
+// the root down to the stack, a dependency on one of them closes a cycle and isn't followed.
+func (v *view) writeDependencies(builder *strings.Builder, name, indent string, path []string, printed map[string]bool) {
+	deps := v.dependencies(name)
+
+	for i, dep := range deps {
+		branch, childIndent := "├── ", indent+"│   "
+		if i == len(deps)-1 {
+			branch, childIndent = "└── ", indent+"    "
+		}
+
+		switch start := slices.Index(path, dep); {
+		case start >= 0:
+			cycle := append(slices.Clone(path[start:]), dep)
+			builder.WriteString(indent + branch + dep + " (cycle: " + strings.Join(cycle, " -> ") + ")\n")
+		case printed[dep] && len(v.graph.DependsOn[dep]) > 0:
+			builder.WriteString(indent + branch + v.label(dep) + " (see above)\n")
+		default:
+			builder.WriteString(indent + branch + v.label(dep) + "\n")
+			printed[dep] = true
+			v.writeDependencies(builder, dep, childIndent, append(path, dep), printed)
+		}
+	}
+}
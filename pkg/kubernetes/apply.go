@@ -0,0 +1,146 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// FieldManager is the field manager name frank uses for server-side apply.
+const FieldManager = "frank"
+
+// serverSideApply applies the resource with server-side apply under the frank field manager.
+// Like applyResource, it also returns the live object from before the apply.
+func (d *Deployer) serverSideApply(resource preparedResource, stackName string) (string, *unstructured.Unstructured, *unstructured.Unstructured, error) {
+	obj, gvr := resource.obj, resource.gvr
+	namespace := obj.GetNamespace()
+	name := obj.GetName()
+	client := d.dynamicClient.Resource(gvr).Namespace(namespace)
+
+	// Look up the live object so the operation can be reported accurately
+	existing, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return "failed", nil, nil, fmt.Errorf("error getting existing resource: %w", err)
+	}
+
+	err = resource.checkPlanned(existing)
+	if err != nil {
+		return "failed", existing, nil, err
+	}
+
+	// Server-side apply rejects managed fields in the applied configuration, and only sends a
+	// resourceVersion for planned objects so the apply fails with a conflict if they changed since
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+
+	if resource.planned && existing != nil {
+		obj.SetResourceVersion(existing.GetResourceVersion())
+	}
+
+	d.logger.Debug("Server-side applying resource",
+		"stack", stackName,
+		"name", name,
+		"namespace", namespace,
+		"force", d.applyOptions.ForceConflicts)
+
+	result, err := client.Apply(context.TODO(), name, obj, metav1.ApplyOptions{
+		FieldManager: FieldManager,
+		Force:        d.applyOptions.ForceConflicts,
+	})
+	if err != nil {
+		return "failed", existing, nil, err
+	}
+
+	operation := d.serverSideApplyOperation(existing, result)
+	if operation != "no-change" {
+		d.logger.Warn("Server-side applied resource", "stack", stackName, "name", name, "namespace", namespace, "operation", operation)
+	}
+
+	return operation, existing, result, nil
+}
+
+// DryRunApply server-side applies the resource in dry-run mode and returns the object
+// as it would look in the cluster, including server-side defaults.
+func (d *Deployer) DryRunApply(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	applied := obj.DeepCopy()
+	applied.SetResourceVersion("")
+	applied.SetManagedFields(nil)
+
+	result, err := d.dynamicClient.Resource(gvr).Namespace(applied.GetNamespace()).Apply(context.TODO(), applied.GetName(), applied, metav1.ApplyOptions{
+		FieldManager: FieldManager,
+		Force:        true,
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dry-run apply failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// serverSideApplyOperation derives the operation from the live object before and after the apply.
+func (d *Deployer) serverSideApplyOperation(existing, result *unstructured.Unstructured) string {
+	if existing == nil {
+		return "created"
+	}
+
+	// The API server only bumps the resource version when the apply changed something
+	if result.GetResourceVersion() != existing.GetResourceVersion() {
+		return "applied"
+	}
+
+	return "no-change"
+}
+
+// fieldConflicts extracts the per-field conflicts from a server-side apply error.
+func fieldConflicts(err error) []FieldConflict {
+	var statusErr *apierrors.StatusError
+	if !errors.As(err, &statusErr) || !apierrors.IsConflict(err) {
+		return nil
+	}
+
+	details := statusErr.ErrStatus.Details
+	if details == nil {
+		return nil
+	}
+
+	var conflicts []FieldConflict
+
+	for _, cause := range details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+
+		conflicts = append(conflicts, FieldConflict{
+			Field:   cause.Field,
+			Manager: conflictManager(cause.Message),
+			Message: cause.Message,
+		})
+	}
+
+	return conflicts
+}
+
+// conflictManager extracts the field manager name from a conflict message
+// such as `conflict with "kubectl-edit" using apps/v1`.
+func conflictManager(message string) string {
+	_, rest, found := strings.Cut(message, `conflict with "`)
+	if !found {
+		return ""
+	}
+
+	manager, _, found := strings.Cut(rest, `"`)
+	if !found {
+		return ""
+	}
+
+	return manager
+}
@@ -0,0 +1,209 @@
+package kubernetes
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestConfigMap() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":      "settings",
+				"namespace": "dev",
+			},
+			"data": map[string]any{
+				"mode": "fast",
+			},
+		},
+	}
+}
+
+func TestServerSideApplyOperation(t *testing.T) {
+	tests := []struct {
+		name           string
+		existing       []runtime.Object
+		appliedVersion string
+		expected       string
+	}{
+		{
+			name:           "missing resource is created",
+			appliedVersion: "1",
+			expected:       "created",
+		},
+		{
+			name:           "changed resource is applied",
+			existing:       []runtime.Object{newTestConfigMapWithVersion("1")},
+			appliedVersion: "2",
+			expected:       "applied",
+		},
+		{
+			name:           "unchanged resource keeps its resource version",
+			existing:       []runtime.Object{newTestConfigMapWithVersion("1")},
+			appliedVersion: "1",
+			expected:       "no-change",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tt.existing...)
+			client.PrependReactor("patch", "configmaps", applyReactor(t, tt.appliedVersion))
+
+			deployer := &Deployer{
+				dynamicClient: client,
+				logger:        slog.Default(),
+				applyOptions:  ApplyOptions{ServerSide: true},
+			}
+
+			gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+			operation, _, _, err := deployer.applyResource(preparedResource{obj: newTestConfigMap(), gvr: gvr}, "dev-settings")
+			if err != nil {
+				t.Fatalf("applyResource() error = %v", err)
+			}
+
+			if operation != tt.expected {
+				t.Errorf("applyResource() operation = %q, want %q", operation, tt.expected)
+			}
+		})
+	}
+}
+
+// applyReactor answers server-side apply patches with the applied object at the given resource version.
+func applyReactor(t *testing.T, resourceVersion string) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(k8stesting.PatchAction)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType {
+			t.Errorf("Expected a server-side apply patch, got %v", action)
+
+			return true, nil, errors.New("unexpected action")
+		}
+
+		obj := &unstructured.Unstructured{}
+
+		err := obj.UnmarshalJSON(patch.GetPatch())
+		if err != nil {
+			return true, nil, err
+		}
+
+		obj.SetResourceVersion(resourceVersion)
+
+		return true, obj, nil
+	}
+}
+
+func newTestConfigMapWithVersion(resourceVersion string) *unstructured.Unstructured {
+	obj := newTestConfigMap()
+	obj.SetResourceVersion(resourceVersion)
+
+	return obj
+}
+
+func TestServerSideApplyReportsConflicts(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	client.PrependReactor("patch", "configmaps", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewApplyConflict([]metav1.StatusCause{
+			{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: `conflict with "kubectl-edit" using v1`,
+				Field:   ".data.mode",
+			},
+		}, `Apply failed with 1 conflict: conflict with "kubectl-edit" using v1: .data.mode`)
+	})
+
+	deployer := &Deployer{
+		dynamicClient: client,
+		logger:        slog.Default(),
+		applyOptions:  ApplyOptions{ServerSide: true},
+	}
+
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	resource := preparedResource{obj: newTestConfigMap(), gvr: gvr}
+
+	result := deployer.applyPreparedResource(resource, "dev-settings")
+	if result.Error == nil {
+		t.Fatal("Expected conflict error, got nil")
+	}
+
+	expected := []FieldConflict{
+		{Field: ".data.mode", Manager: "kubectl-edit", Message: `conflict with "kubectl-edit" using v1`},
+	}
+
+	if len(result.Conflicts) != len(expected) || result.Conflicts[0] != expected[0] {
+		t.Errorf("Expected conflicts %v, got %v", expected, result.Conflicts)
+	}
+}
+
+func TestFieldConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: 0,
+		},
+		{
+			name:     "non-API error",
+			err:      errors.New("connection refused"),
+			expected: 0,
+		},
+		{
+			name:     "not found error",
+			err:      apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "settings"),
+			expected: 0,
+		},
+		{
+			name: "apply conflict with two fields",
+			err: apierrors.NewApplyConflict([]metav1.StatusCause{
+				{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "hpa" using apps/v1`, Field: ".spec.replicas"},
+				{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "kubectl" using apps/v1`, Field: ".spec.paused"},
+			}, "Apply failed with 2 conflicts"),
+			expected: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := fieldConflicts(tt.err)
+			if len(conflicts) != tt.expected {
+				t.Errorf("fieldConflicts() returned %d conflicts, want %d", len(conflicts), tt.expected)
+			}
+		})
+	}
+}
+
+func TestConflictManager(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{message: `conflict with "kubectl-client-side-apply" using apps/v1`, expected: "kubectl-client-side-apply"},
+		{message: `conflict with "hpa"`, expected: "hpa"},
+		{message: "unexpected message", expected: ""},
+		{message: `conflict with "unterminated`, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if manager := conflictManager(tt.message); manager != tt.expected {
+				t.Errorf("conflictManager() = %q, want %q", manager, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package kubernetes
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// DeployerPool lazily creates and caches one Deployer per kubeconfig context.
+type DeployerPool struct {
+	logger    *slog.Logger
+	options   ApplyOptions
+	mu        sync.Mutex
+	deployers map[string]*Deployer
+}
+
+// NewDeployerPool creates an empty pool of per-context deployers that apply resources with the given options.
+func NewDeployerPool(logger *slog.Logger, options ApplyOptions) *DeployerPool {
+	return &DeployerPool{
+		logger:    logger,
+		options:   options,
+		deployers: make(map[string]*Deployer),
+	}
+}
+
+// Get returns the deployer for a kubeconfig context, creating it on first use.
+// An empty context uses the kubeconfig's current-context.
+func (p *DeployerPool) Get(kubeContext string) (*Deployer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if deployer, exists := p.deployers[kubeContext]; exists {
+		return deployer, nil
+	}
+
+	config, err := CreateConfigForContext(kubeContext)
+	if err != nil {
+		return nil, err
+	}
+
+	deployer, err := NewDeployer(config, p.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes deployer for context %q: %w", kubeContext, err)
+	}
+
+	deployer.kubeContext = kubeContext
+	deployer.applyOptions = p.options
+
+	p.logger.Debug("Created Kubernetes client", "context", kubeContext, "host", config.Host)
+	p.deployers[kubeContext] = deployer
+
+	return deployer, nil
+}
+
+// CreateConfigForContext creates a Kubernetes REST config for a kubeconfig context.
+// An empty context uses the kubeconfig's current-context.
+func CreateConfigForContext(kubeContext string) (*rest.Config, error) {
+	// Load kubeconfig from default location
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+
+	// Build config from kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes config for context %q: %w", kubeContext, err)
+	}
+
+	return config, nil
+}
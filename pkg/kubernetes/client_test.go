@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: prod-user
+users:
+- name: dev-user
+  user:
+    token: dev-token
+- name: prod-user
+  user:
+    token: prod-token
+`
+
+// writeTestKubeconfig writes a kubeconfig with dev and prod contexts and points KUBECONFIG at it.
+func writeTestKubeconfig(t *testing.T) {
+	kubeconfigPath := filepath.Join(t.TempDir(), "kubeconfig")
+
+	err := os.WriteFile(kubeconfigPath, []byte(testKubeconfig), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+
+	t.Setenv("KUBECONFIG", kubeconfigPath)
+}
+
+func TestCreateConfigForContext(t *testing.T) {
+	writeTestKubeconfig(t)
+
+	tests := []struct {
+		name         string
+		kubeContext  string
+		expectedHost string
+		expectError  bool
+	}{
+		{
+			name:         "empty context uses current-context",
+			kubeContext:  "",
+			expectedHost: "https://dev.example.com",
+		},
+		{
+			name:         "explicit context overrides current-context",
+			kubeContext:  "prod",
+			expectedHost: "https://prod.example.com",
+		},
+		{
+			name:        "unknown context is an error",
+			kubeContext: "staging",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validateConfigForContext(t, tt.kubeContext, tt.expectedHost, tt.expectError)
+		})
+	}
+}
+
+// validateConfigForContext checks the REST config created for a kubeconfig context.
+func validateConfigForContext(t *testing.T, kubeContext, expectedHost string, expectError bool) {
+	config, err := CreateConfigForContext(kubeContext)
+	if expectError {
+		if err == nil {
+			t.Errorf("CreateConfigForContext(%q) expected error but got none", kubeContext)
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Fatalf("CreateConfigForContext(%q) unexpected error: %v", kubeContext, err)
+	}
+
+	if config.Host != expectedHost {
+		t.Errorf("Host = %s, want %s", config.Host, expectedHost)
+	}
+}
+
+func TestDeployerPoolCachesPerContext(t *testing.T) {
+	writeTestKubeconfig(t)
+
+	pool := NewDeployerPool(slog.Default(), ApplyOptions{})
+
+	dev, err := pool.Get("dev")
+	if err != nil {
+		t.Fatalf("Get(dev) unexpected error: %v", err)
+	}
+
+	prod, err := pool.Get("prod")
+	if err != nil {
+		t.Fatalf("Get(prod) unexpected error: %v", err)
+	}
+
+	if dev == prod {
+		t.Error("Expected a separate deployer per context")
+	}
+
+	again, _ := pool.Get("dev")
+	if again != dev {
+		t.Error("Expected the dev deployer to be cached")
+	}
+
+	if prod.kubeContext != "prod" {
+		t.Errorf("kubeContext = %q, want prod", prod.kubeContext)
+	}
+}
@@ -1,337 +1,309 @@
 package kubernetes
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+// lastAppliedAnnotation holds the manifest frank last applied to an object with a client-side update,
+// so fields removed from the manifest since can be told apart from fields frank never set.
+const lastAppliedAnnotation = "frankthetank.cloud/last-applied-configuration"
+
+// needsUpdate checks if the live object differs from the desired object in any field the manifest sets,
+// or still has a field that the last applied manifest set and the desired one no longer does. Fields
+// frank never set, such as server defaults, status and fields written by controllers, are ignored.
 func (d *Deployer) needsUpdate(existing, desired *unstructured.Unstructured) bool {
-	// Compare only the spec fields that actually matter for deployments
-	existingSpec, _, _ := unstructured.NestedMap(existing.Object, "spec")
-	desiredSpec, _, _ := unstructured.NestedMap(desired.Object, "spec")
+	normalized := normalizeDesired(desired).Object
 
-	if existingSpec == nil || desiredSpec == nil {
-		return (existingSpec == nil) != (desiredSpec == nil)
+	field, differs := removedDrift(existing.Object, lastApplied(existing), normalized, "")
+	if !differs {
+		field, differs = drift(existing.Object, normalized, "")
 	}
 
-	// For deployments, compare key fields that matter
-	kind := desired.GetKind()
-
-	switch kind {
-	case "Deployment":
-		return d.deploymentNeedsUpdate(existingSpec, desiredSpec)
-	case "StatefulSet":
-		return d.statefulSetNeedsUpdate(existingSpec, desiredSpec)
-	case "DaemonSet":
-		return d.daemonSetNeedsUpdate(existingSpec, desiredSpec)
-	case "Job":
-		return d.jobNeedsUpdate(existingSpec, desiredSpec)
-	default:
-		// For other resources, do a simple spec comparison
-		return !d.mapsEqual(existingSpec, desiredSpec)
+	if differs {
+		d.logger.Debug("Resource needs update", "kind", desired.GetKind(), "name", desired.GetName(), "field", field)
 	}
+
+	return differs
 }
 
-func (d *Deployer) deploymentNeedsUpdate(existing, desired map[string]any) bool {
-	// Compare replicas
-	if !d.compareField(existing, desired, "replicas") {
-		d.logger.Debug("Deployment needs update: replicas differ", "existing", existing["replicas"], "desired", desired["replicas"])
+// setLastApplied records the object in its last applied annotation, leaving out the annotation itself.
+func setLastApplied(obj *unstructured.Unstructured) error {
+	applied := obj.DeepCopy()
+	unstructured.RemoveNestedField(applied.Object, "metadata", "annotations", lastAppliedAnnotation)
 
-		return true
+	data, err := json.Marshal(applied.Object)
+	if err != nil {
+		return fmt.Errorf("error recording last applied configuration: %w", err)
 	}
 
-	// Compare template (which contains the actual pod spec)
-	existingTemplate, _, _ := unstructured.NestedMap(existing, "template")
-	desiredTemplate, _, _ := unstructured.NestedMap(desired, "template")
-
-	if !d.templateEqual(existingTemplate, desiredTemplate) {
-		d.logger.Debug("Deployment needs update: template differs")
-
-		return true
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
 	}
 
-	d.logger.Debug("Deployment is up to date")
+	annotations[lastAppliedAnnotation] = string(data)
+	obj.SetAnnotations(annotations)
 
-	return false
+	return nil
 }
 
-func (d *Deployer) statefulSetNeedsUpdate(existing, desired map[string]any) bool {
-	// Compare replicas
-	if !d.compareField(existing, desired, "replicas") {
-		return true
+// lastApplied returns the manifest frank last applied to a live object in the form the API server
+// stores it, or nil when frank didn't record one.
+func lastApplied(live *unstructured.Unstructured) map[string]any {
+	data, ok := live.GetAnnotations()[lastAppliedAnnotation]
+	if !ok {
+		return nil
 	}
 
-	// Compare template
-	existingTemplate, _, _ := unstructured.NestedMap(existing, "template")
-	desiredTemplate, _, _ := unstructured.NestedMap(desired, "template")
-
-	return !d.templateEqual(existingTemplate, desiredTemplate)
-}
-
-func (d *Deployer) daemonSetNeedsUpdate(existing, desired map[string]any) bool {
-	// Compare template
-	existingTemplate, _, _ := unstructured.NestedMap(existing, "template")
-	desiredTemplate, _, _ := unstructured.NestedMap(desired, "template")
+	var applied map[string]any
 
-	return !d.templateEqual(existingTemplate, desiredTemplate)
-}
-
-func (d *Deployer) jobNeedsUpdate(existing, desired map[string]any) bool {
-	// Compare template
-	existingTemplate, _, _ := unstructured.NestedMap(existing, "template")
-	desiredTemplate, _, _ := unstructured.NestedMap(desired, "template")
+	err := json.Unmarshal([]byte(data), &applied)
+	if err != nil {
+		return nil
+	}
 
-	return !d.templateEqual(existingTemplate, desiredTemplate)
+	return normalizeDesired(&unstructured.Unstructured{Object: applied}).Object
 }
 
-func (d *Deployer) templateEqual(existing, desired map[string]any) bool {
-	if existing == nil || desired == nil {
-		return (existing == nil) != (desired == nil)
-	}
-
-	// Compare spec within template
-	existingSpec, _, _ := unstructured.NestedMap(existing, "spec")
-	desiredSpec, _, _ := unstructured.NestedMap(desired, "spec")
+// normalizeDesired returns a copy of the desired object in the form the API server stores it.
+func normalizeDesired(desired *unstructured.Unstructured) *unstructured.Unstructured {
+	normalized := desired.DeepCopy()
 
-	if existingSpec == nil || desiredSpec == nil {
-		return (existingSpec == nil) != (desiredSpec == nil)
-	}
+	// Status is written by the cluster, never by the manifest
+	unstructured.RemoveNestedField(normalized.Object, "status")
 
-	// Compare containers
-	existingContainers, _, _ := unstructured.NestedSlice(existingSpec, "containers")
-	desiredContainers, _, _ := unstructured.NestedSlice(desiredSpec, "containers")
+	// The API server merges stringData into data, base64 encoded
+	if normalized.GetKind() == "Secret" {
+		stringData, _, _ := unstructured.NestedStringMap(normalized.Object, "stringData")
+		if len(stringData) > 0 {
+			data, _, _ := unstructured.NestedMap(normalized.Object, "data")
+			if data == nil {
+				data = make(map[string]any)
+			}
 
-	if !d.containersEqual(existingContainers, desiredContainers) {
-		return false
-	}
+			for key, value := range stringData {
+				data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+			}
 
-	// Compare other important fields
-	fieldsToCompare := []string{"restartPolicy", "terminationGracePeriodSeconds", "dnsPolicy", "hostNetwork", "hostPID", "hostIPC"}
-	for _, field := range fieldsToCompare {
-		if !d.compareField(existingSpec, desiredSpec, field) {
-			return false
+			_ = unstructured.SetNestedMap(normalized.Object, data, "data")
 		}
+
+		unstructured.RemoveNestedField(normalized.Object, "stringData")
 	}
 
-	return true
+	return normalized
 }
 
-func (d *Deployer) containersEqual(existing, desired []any) bool {
-	if len(existing) != len(desired) {
-		return false
-	}
-
-	for i, existingContainer := range existing {
-		if !d.compareSingleContainer(existingContainer, desired[i]) {
-			return false
-		}
+// drift compares a desired value with the live value and returns the path of the first field that differs.
+// Maps only compare the keys set in the desired map, so fields the server adds are ignored. Lists must
+// have the same length and are compared element by element. A field set to null is not set, like an omitted one.
+func drift(live, desired any, path string) (string, bool) {
+	switch want := desired.(type) {
+	case nil:
+		return "", false
+	case map[string]any:
+		return mapDrift(live, want, path)
+	case []any:
+		return listDrift(live, want, path)
+	default:
+		return path, !scalarsEqual(live, desired)
 	}
-
-	return true
 }
 
-func (d *Deployer) compareSingleContainer(existing, desired any) bool {
-	existingMap, ok := existing.(map[string]any)
-	if !ok {
-		return false
+// mapDrift compares the keys of a desired map, in lexical order, with the live value.
+func mapDrift(live any, desired map[string]any, path string) (string, bool) {
+	have, ok := live.(map[string]any)
+	if !ok && live != nil {
+		return path, true
 	}
 
-	desiredMap, ok := desired.(map[string]any)
-	if !ok {
-		return false
-	}
+	quantities := isQuantityMap(path)
 
-	// Compare basic fields
-	if !d.compareContainerBasicFields(existingMap, desiredMap) {
-		return false
-	}
+	for _, key := range slices.Sorted(maps.Keys(desired)) {
+		if quantities && quantitiesEqual(have[key], desired[key]) {
+			continue
+		}
 
-	// Compare ports
-	if !d.compareContainerPorts(existingMap, desiredMap) {
-		return false
+		field, differs := drift(have[key], desired[key], joinFieldPath(path, key))
+		if differs {
+			return field, true
+		}
 	}
 
-	// Compare other important fields
-	return d.compareContainerOtherFields(existingMap, desiredMap)
+	return "", false
 }
 
-func (d *Deployer) compareContainerBasicFields(existing, desired map[string]any) bool {
-	// Compare container name
-	if !d.compareField(existing, desired, "name") {
-		return false
+// joinFieldPath appends a map key to a field path, quoting keys that contain dots or slashes
+// such as nvidia.com/gpu.
+func joinFieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%q]", path, key)
 	}
 
-	// Compare image
-	if !d.compareField(existing, desired, "image") {
-		return false
-	}
+	return path + "." + key
+}
 
-	return true
+// removedDrift returns the path of the first field that the previously applied manifest set, the desired
+// manifest no longer sets and the live object still has, such as a ConfigMap key or label removed from the
+// manifest. List items that were removed change the list's length, which drift reports.
+func removedDrift(live, previous, desired any, path string) (string, bool) {
+	switch applied := previous.(type) {
+	case map[string]any:
+		return removedMapDrift(live, applied, desired, path)
+	case []any:
+		return removedListDrift(live, applied, desired, path)
+	default:
+		return "", false
+	}
 }
 
-func (d *Deployer) compareContainerPorts(existing, desired map[string]any) bool {
-	existingPorts, _, _ := unstructured.NestedSlice(existing, "ports")
-	desiredPorts, _, _ := unstructured.NestedSlice(desired, "ports")
+// removedMapDrift checks the keys of a previously applied map, in lexical order, for keys that were
+// removed from the desired map but are still live.
+func removedMapDrift(live any, previous map[string]any, desired any, path string) (string, bool) {
+	want, _ := desired.(map[string]any)
+	have, _ := live.(map[string]any)
 
-	return d.comparePorts(existingPorts, desiredPorts)
-}
+	for _, key := range slices.Sorted(maps.Keys(previous)) {
+		if want[key] == nil && have[key] != nil {
+			return joinFieldPath(path, key), true
+		}
 
-func (d *Deployer) compareContainerOtherFields(existing, desired map[string]any) bool {
-	fieldsToCompare := []string{"command", "args", "workingDir", "env", "resources", "volumeMounts"}
-	for _, field := range fieldsToCompare {
-		if !d.compareField(existing, desired, field) {
-			return false
+		field, differs := removedDrift(have[key], previous[key], want[key], joinFieldPath(path, key))
+		if differs {
+			return field, true
 		}
 	}
 
-	return true
+	return "", false
 }
 
-// comparePorts compares port slices, handling default TCP protocol.
-func (d *Deployer) comparePorts(existing, desired []any) bool {
-	if len(existing) != len(desired) {
-		return false
-	}
+// removedListDrift checks the elements a previously applied list shares with the desired and live lists.
+func removedListDrift(live any, previous []any, desired any, path string) (string, bool) {
+	want, _ := desired.([]any)
+	have, _ := live.([]any)
 
-	for i, existingPort := range existing {
-		if !d.compareSinglePort(existingPort, desired[i]) {
-			return false
+	for i := range min(len(previous), len(want), len(have)) {
+		field, differs := removedDrift(have[i], previous[i], want[i], path+"["+strconv.Itoa(i)+"]")
+		if differs {
+			return field, true
 		}
 	}
 
-	return true
+	return "", false
 }
 
-func (d *Deployer) compareSinglePort(existing, desired any) bool {
-	existingMap, ok := existing.(map[string]any)
-	if !ok {
-		return false
+// listDrift compares a desired list with the live value element by element.
+func listDrift(live any, desired []any, path string) (string, bool) {
+	have, ok := live.([]any)
+	if !ok && live != nil {
+		return path, true
 	}
 
-	desiredMap, ok := desired.(map[string]any)
-	if !ok {
-		return false
+	// The server drops empty lists, and an empty desired list clears the live one
+	if len(have) != len(desired) {
+		return path, true
 	}
 
-	// Compare protocol (with default handling)
-	if !d.comparePortProtocol(existingMap, desiredMap) {
-		return false
+	for i := range desired {
+		field, differs := drift(have[i], desired[i], path+"["+strconv.Itoa(i)+"]")
+		if differs {
+			return field, true
+		}
 	}
 
-	// Compare other port fields
-	return d.comparePortOtherFields(existingMap, desiredMap)
+	return "", false
 }
 
-func (d *Deployer) comparePortProtocol(existing, desired map[string]any) bool {
-	existingProtocol := d.getPortProtocol(existing)
-	desiredProtocol := d.getPortProtocol(desired)
-
-	return existingProtocol == desiredProtocol
-}
+// scalarsEqual compares a desired scalar with the live value. Unset zero values match missing fields,
+// numbers are compared by value and a number matches a string holding the same quantity, such as 10
+// and "10", because the API server stores quantities as strings.
+func scalarsEqual(live, desired any) bool {
+	if isZero(desired) && (live == nil || isZero(live)) {
+		return true
+	}
 
-func (d *Deployer) getPortProtocol(portMap map[string]any) string {
-	protocol := portMap["protocol"]
-	if protocol == nil {
-		return "TCP"
+	if live == nil || desired == nil {
+		return false
 	}
 
-	return protocol.(string)
-}
+	liveNumber, liveIsNumber := toFloat(live)
+	desiredNumber, desiredIsNumber := toFloat(desired)
 
-func (d *Deployer) comparePortOtherFields(existing, desired map[string]any) bool {
-	fieldsToCompare := []string{"containerPort", "name", "hostPort"}
-	for _, field := range fieldsToCompare {
-		if !d.compareField(existing, desired, field) {
-			return false
-		}
+	if liveIsNumber && desiredIsNumber {
+		return liveNumber == desiredNumber
 	}
 
-	return true
-}
+	if liveIsNumber || desiredIsNumber {
+		return quantitiesEqual(live, desired)
+	}
 
-func (d *Deployer) compareField(existing, desired map[string]any, field string) bool {
-	existingVal := existing[field]
-	desiredVal := desired[field]
+	return reflect.DeepEqual(live, desired)
+}
 
-	// Handle nil values
-	if existingVal == nil && desiredVal == nil {
+// isZero checks if a scalar is nil or the zero value the API server leaves out of objects.
+func isZero(value any) bool {
+	if value == nil {
 		return true
 	}
 
-	if existingVal == nil || desiredVal == nil {
-		return false
+	number, isNumber := toFloat(value)
+	if isNumber {
+		return number == 0
 	}
 
-	// For slices and maps, use deep comparison
-	switch existingTyped := existingVal.(type) {
-	case []any:
-		desiredSlice, ok := desiredVal.([]any)
-		if !ok {
-			return false
-		}
-
-		return d.slicesEqual(existingTyped, desiredSlice)
-	case map[string]any:
-		desiredMap, ok := desiredVal.(map[string]any)
-		if !ok {
-			return false
-		}
+	return value == "" || value == false
+}
 
-		return d.mapsEqual(existingTyped, desiredMap)
+// toFloat converts the number types found in decoded objects to float64.
+func toFloat(value any) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case float32:
+		return float64(number), true
+	case float64:
+		return number, true
 	default:
-		return existingVal == desiredVal
+		return 0, false
 	}
 }
 
-func (d *Deployer) mapsEqual(existing, desired map[string]any) bool {
-	if len(existing) != len(desired) {
-		return false
-	}
-
-	for key, existingVal := range existing {
-		desiredVal, exists := desired[key]
-		if !exists {
-			return false
-		}
-
-		if !d.valuesEqual(existingVal, desiredVal) {
-			return false
-		}
-	}
-
-	return true
+// isQuantityMap checks if a map holds resource quantities, such as .resources.limits,
+// .spec.resources.requests or a ResourceQuota's .spec.hard.
+func isQuantityMap(path string) bool {
+	return strings.HasSuffix(path, ".limits") || strings.HasSuffix(path, ".requests") || strings.HasSuffix(path, ".hard")
 }
 
-func (d *Deployer) slicesEqual(existing, desired []any) bool {
-	if len(existing) != len(desired) {
+// quantitiesEqual compares two scalars as resource quantities by amount, and reports false
+// when either isn't a quantity.
+func quantitiesEqual(live, desired any) bool {
+	if live == nil || desired == nil {
 		return false
 	}
 
-	for i, existingVal := range existing {
-		if !d.valuesEqual(existingVal, desired[i]) {
-			return false
-		}
+	liveQuantity, err := resource.ParseQuantity(fmt.Sprint(live))
+	if err != nil {
+		return false
 	}
 
-	return true
-}
-
-func (d *Deployer) valuesEqual(existing, desired any) bool {
-	switch existingVal := existing.(type) {
-	case map[string]any:
-		desiredMap, ok := desired.(map[string]any)
-		if !ok {
-			return false
-		}
-
-		return d.mapsEqual(existingVal, desiredMap)
-	case []any:
-		desiredSlice, ok := desired.([]any)
-		if !ok {
-			return false
-		}
-
-		return d.slicesEqual(existingVal, desiredSlice)
-	default:
-		return existingVal == desired
+	desiredQuantity, err := resource.ParseQuantity(fmt.Sprint(desired))
+	if err != nil {
+		return false
 	}
+
+	return liveQuantity.Cmp(desiredQuantity) == 0
 }
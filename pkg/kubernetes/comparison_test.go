@@ -294,197 +294,412 @@ func TestNeedsUpdate(t *testing.T) {
 	}
 }
 
-func TestComparePorts(t *testing.T) {
-	deployer := &Deployer{
-		logger: slog.Default(),
+// podSpecCase is a pod spec change that every workload kind must detect or ignore.
+type podSpecCase struct {
+	name         string
+	livePod      map[string]any
+	desiredPod   map[string]any
+	liveLabels   map[string]any
+	desiredLabel map[string]any
+	expected     bool
+}
+
+// liveContainer is a container as the API server returns it, with its defaults filled in.
+func liveContainer() map[string]any {
+	return map[string]any{
+		"name":                     "app",
+		"image":                    "app:1.0",
+		"imagePullPolicy":          "IfNotPresent",
+		"terminationMessagePath":   "/dev/termination-log",
+		"terminationMessagePolicy": "File",
+		"ports":                    []any{map[string]any{"containerPort": int64(8080), "protocol": "TCP"}},
+		"resources":                map[string]any{"limits": map[string]any{"cpu": "1", "memory": "1Gi"}},
+	}
+}
+
+// desiredContainer is the container of the manifest.
+func desiredContainer() map[string]any {
+	return map[string]any{
+		"name":      "app",
+		"image":     "app:1.0",
+		"ports":     []any{map[string]any{"containerPort": int64(8080)}},
+		"resources": map[string]any{"limits": map[string]any{"cpu": "1000m", "memory": "1024Mi"}},
+	}
+}
+
+// livePodSpec is a pod spec as the API server returns it, with extra fields merged in.
+func livePodSpec(fields map[string]any) map[string]any {
+	spec := map[string]any{
+		"containers":                    []any{liveContainer()},
+		"restartPolicy":                 "Always",
+		"dnsPolicy":                     "ClusterFirst",
+		"schedulerName":                 "default-scheduler",
+		"securityContext":               map[string]any{},
+		"terminationGracePeriodSeconds": int64(30),
+	}
+
+	for key, value := range fields {
+		spec[key] = value
+	}
+
+	return spec
+}
+
+// desiredPodSpec is a pod spec of the manifest with extra fields merged in.
+func desiredPodSpec(fields map[string]any) map[string]any {
+	spec := map[string]any{"containers": []any{desiredContainer()}}
+
+	for key, value := range fields {
+		spec[key] = value
 	}
 
+	return spec
+}
+
+// newWorkload creates a workload of a kind with a pod spec and pod template labels.
+func newWorkload(kind string, podSpec, labels map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{"kind": kind, "metadata": map[string]any{"name": "app"}}}
+
+	if kind == "Pod" {
+		_ = unstructured.SetNestedField(obj.Object, podSpec, "spec")
+		_ = unstructured.SetNestedField(obj.Object, labels, "metadata", "labels")
+
+		return obj
+	}
+
+	template := map[string]any{"metadata": map[string]any{"labels": labels}, "spec": podSpec}
+	if kind == "CronJob" {
+		_ = unstructured.SetNestedField(obj.Object, map[string]any{"template": template}, "spec", "jobTemplate", "spec")
+	} else {
+		_ = unstructured.SetNestedField(obj.Object, template, "spec", "template")
+	}
+
+	return obj
+}
+
+func TestNeedsUpdatePodTemplates(t *testing.T) {
+	deployer := &Deployer{logger: slog.Default()}
+
+	toleration := map[string]any{"key": "dedicated", "operator": "Equal", "value": "batch", "effect": "NoSchedule"}
+	volume := map[string]any{"name": "config", "configMap": map[string]any{"name": "app-config"}}
+
+	tests := []podSpecCase{
+		{name: "server defaults are ignored", livePod: livePodSpec(nil), desiredPod: desiredPodSpec(nil)},
+		{
+			name:       "volumes added",
+			livePod:    livePodSpec(nil),
+			desiredPod: desiredPodSpec(map[string]any{"volumes": []any{volume}}),
+			expected:   true,
+		},
+		{
+			name:       "volumes unchanged",
+			livePod:    livePodSpec(map[string]any{"volumes": []any{map[string]any{"name": "config", "configMap": map[string]any{"name": "app-config", "defaultMode": int64(420)}}}}),
+			desiredPod: desiredPodSpec(map[string]any{"volumes": []any{volume}}),
+		},
+		{
+			name:       "init containers added",
+			livePod:    livePodSpec(nil),
+			desiredPod: desiredPodSpec(map[string]any{"initContainers": []any{map[string]any{"name": "migrate", "image": "app:1.0"}}}),
+			expected:   true,
+		},
+		{
+			name:       "affinity changed",
+			livePod:    livePodSpec(map[string]any{"affinity": map[string]any{"nodeAffinity": map[string]any{"zone": "a"}}}),
+			desiredPod: desiredPodSpec(map[string]any{"affinity": map[string]any{"nodeAffinity": map[string]any{"zone": "b"}}}),
+			expected:   true,
+		},
+		{
+			name:       "toleration removed",
+			livePod:    livePodSpec(map[string]any{"tolerations": []any{toleration, toleration}}),
+			desiredPod: desiredPodSpec(map[string]any{"tolerations": []any{toleration}}),
+			expected:   true,
+		},
+		{
+			name:       "service account changed",
+			livePod:    livePodSpec(map[string]any{"serviceAccountName": "default", "serviceAccount": "default"}),
+			desiredPod: desiredPodSpec(map[string]any{"serviceAccountName": "app"}),
+			expected:   true,
+		},
+		{
+			name:       "security context changed",
+			livePod:    livePodSpec(nil),
+			desiredPod: desiredPodSpec(map[string]any{"securityContext": map[string]any{"runAsNonRoot": true}}),
+			expected:   true,
+		},
+		{
+			name:       "node selector changed",
+			livePod:    livePodSpec(map[string]any{"nodeSelector": map[string]any{"pool": "default"}}),
+			desiredPod: desiredPodSpec(map[string]any{"nodeSelector": map[string]any{"pool": "batch"}}),
+			expected:   true,
+		},
+		{
+			name:       "unset false and zero values match missing fields",
+			livePod:    livePodSpec(nil),
+			desiredPod: desiredPodSpec(map[string]any{"hostNetwork": false, "priority": int64(0), "hostname": ""}),
+		},
+		{
+			name:         "template labels changed",
+			livePod:      livePodSpec(nil),
+			desiredPod:   desiredPodSpec(nil),
+			liveLabels:   map[string]any{"app": "web"},
+			desiredLabel: map[string]any{"app": "web", "version": "2"},
+			expected:     true,
+		},
+	}
+
+	for _, kind := range []string{"Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob", "Pod"} {
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				live := newWorkload(kind, tt.livePod, tt.liveLabels)
+				desired := newWorkload(kind, tt.desiredPod, tt.desiredLabel)
+
+				result := deployer.needsUpdate(live, desired)
+				if result != tt.expected {
+					t.Errorf("needsUpdate() = %v, want %v", result, tt.expected)
+				}
+			})
+		}
+	}
+}
+
+func TestNeedsUpdateObjects(t *testing.T) {
+	deployer := &Deployer{logger: slog.Default()}
+
 	tests := []struct {
 		name     string
-		existing []any
-		desired  []any
+		live     map[string]any
+		desired  map[string]any
 		expected bool
 	}{
 		{
-			name: "identical ports should match",
-			existing: []any{
-				map[string]any{
-					"containerPort": int64(80),
-					"protocol":      "TCP",
+			name: "service defaults and status are ignored",
+			live: map[string]any{
+				"kind": "Service",
+				"spec": map[string]any{
+					"clusterIP": "10.0.0.12", "type": "ClusterIP", "sessionAffinity": "None",
+					"ports": []any{map[string]any{"port": int64(80), "targetPort": int64(8080), "protocol": "TCP"}},
 				},
+				"status": map[string]any{"loadBalancer": map[string]any{}},
 			},
-			desired: []any{
-				map[string]any{
-					"containerPort": int64(80),
-					"protocol":      "TCP",
-				},
+			desired: map[string]any{
+				"kind": "Service",
+				"spec": map[string]any{"ports": []any{map[string]any{"port": int64(80), "targetPort": int64(8080)}}},
 			},
+		},
+		{
+			name:     "service port changed",
+			live:     map[string]any{"kind": "Service", "spec": map[string]any{"ports": []any{map[string]any{"port": int64(80), "protocol": "TCP"}}}},
+			desired:  map[string]any{"kind": "Service", "spec": map[string]any{"ports": []any{map[string]any{"port": int64(80), "protocol": "UDP"}}}},
 			expected: true,
 		},
 		{
-			name: "different ports should not match",
-			existing: []any{
-				map[string]any{
-					"containerPort": int64(80),
-					"protocol":      "TCP",
-				},
-			},
-			desired: []any{
-				map[string]any{
-					"containerPort": int64(8080),
-					"protocol":      "TCP",
-				},
-			},
-			expected: false,
+			name:     "config map data changed",
+			live:     map[string]any{"kind": "ConfigMap", "data": map[string]any{"level": "info"}},
+			desired:  map[string]any{"kind": "ConfigMap", "data": map[string]any{"level": "debug"}},
+			expected: true,
 		},
 		{
-			name: "missing protocol should default to TCP",
-			existing: []any{
-				map[string]any{
-					"containerPort": int64(80),
-					"protocol":      "TCP",
-				},
+			name:    "secret string data matches encoded data",
+			live:    map[string]any{"kind": "Secret", "type": "Opaque", "data": map[string]any{"password": "aHVudGVyMg=="}},
+			desired: map[string]any{"kind": "Secret", "stringData": map[string]any{"password": "hunter2"}},
+		},
+		{
+			name:     "secret string data changed",
+			live:     map[string]any{"kind": "Secret", "data": map[string]any{"password": "aHVudGVyMg=="}},
+			desired:  map[string]any{"kind": "Secret", "stringData": map[string]any{"password": "hunter3"}},
+			expected: true,
+		},
+		{
+			name:     "annotation added",
+			live:     map[string]any{"kind": "ConfigMap", "metadata": map[string]any{"name": "app", "uid": "1234"}},
+			desired:  map[string]any{"kind": "ConfigMap", "metadata": map[string]any{"name": "app", "annotations": map[string]any{"team": "payments"}}},
+			expected: true,
+		},
+		{
+			name:    "empty annotations match live annotations",
+			live:    map[string]any{"kind": "ConfigMap", "metadata": map[string]any{"name": "app", "annotations": map[string]any{"team": "payments"}}},
+			desired: map[string]any{"kind": "ConfigMap", "metadata": map[string]any{"name": "app", "annotations": nil}},
+		},
+		{
+			name:     "replicas changed",
+			live:     map[string]any{"kind": "Deployment", "spec": map[string]any{"replicas": int64(3)}},
+			desired:  map[string]any{"kind": "Deployment", "spec": map[string]any{"replicas": int64(0)}},
+			expected: true,
+		},
+		{
+			name: "config map key removed from the manifest",
+			live: map[string]any{
+				"kind":     "ConfigMap",
+				"metadata": map[string]any{"annotations": map[string]any{lastAppliedAnnotation: `{"data":{"level":"info","mode":"fast"},"kind":"ConfigMap"}`}},
+				"data":     map[string]any{"level": "info", "mode": "fast"},
 			},
-			desired: []any{
-				map[string]any{
-					"containerPort": int64(80),
-					// protocol missing, should default to TCP
+			desired:  map[string]any{"kind": "ConfigMap", "data": map[string]any{"level": "info"}},
+			expected: true,
+		},
+		{
+			name: "label removed from the manifest",
+			live: map[string]any{
+				"kind": "ConfigMap",
+				"metadata": map[string]any{
+					"labels":      map[string]any{"app": "web", "tier": "frontend"},
+					"annotations": map[string]any{lastAppliedAnnotation: `{"kind":"ConfigMap","metadata":{"labels":{"app":"web","tier":"frontend"}}}`},
 				},
 			},
+			desired:  map[string]any{"kind": "ConfigMap", "metadata": map[string]any{"labels": map[string]any{"app": "web"}}},
 			expected: true,
 		},
 		{
-			name: "different protocols should not match",
-			existing: []any{
-				map[string]any{
-					"containerPort": int64(80),
-					"protocol":      "TCP",
-				},
+			name: "container args item removed from the manifest",
+			live: map[string]any{
+				"kind":     "Pod",
+				"metadata": map[string]any{"annotations": map[string]any{lastAppliedAnnotation: `{"kind":"Pod","spec":{"containers":[{"args":["--verbose","--trace"],"name":"web"}]}}`}},
+				"spec":     map[string]any{"containers": []any{map[string]any{"name": "web", "args": []any{"--verbose", "--trace"}}}},
 			},
-			desired: []any{
-				map[string]any{
-					"containerPort": int64(80),
-					"protocol":      "UDP",
-				},
+			desired:  map[string]any{"kind": "Pod", "spec": map[string]any{"containers": []any{map[string]any{"name": "web", "args": []any{"--verbose"}}}}},
+			expected: true,
+		},
+		{
+			name: "fields frank never applied are ignored",
+			live: map[string]any{
+				"kind":     "ConfigMap",
+				"metadata": map[string]any{"annotations": map[string]any{lastAppliedAnnotation: `{"data":{"level":"info"},"kind":"ConfigMap"}`}},
+				"data":     map[string]any{"level": "info", "added-by-controller": "true"},
 			},
-			expected: false,
+			desired: map[string]any{"kind": "ConfigMap", "data": map[string]any{"level": "info"}},
+		},
+		{
+			name:    "removed keys aren't known without a last applied manifest",
+			live:    map[string]any{"kind": "ConfigMap", "data": map[string]any{"level": "info", "mode": "fast"}},
+			desired: map[string]any{"kind": "ConfigMap", "data": map[string]any{"level": "info"}},
+		},
+		{
+			name:    "resource quota counts as numbers",
+			live:    map[string]any{"kind": "ResourceQuota", "spec": map[string]any{"hard": map[string]any{"pods": "10", "limits.cpu": "4"}}},
+			desired: map[string]any{"kind": "ResourceQuota", "spec": map[string]any{"hard": map[string]any{"pods": int64(10), "limits.cpu": int64(4)}}},
+		},
+		{
+			name:    "storage request in other units",
+			live:    map[string]any{"kind": "PersistentVolumeClaim", "spec": map[string]any{"resources": map[string]any{"requests": map[string]any{"storage": "1Gi"}}}},
+			desired: map[string]any{"kind": "PersistentVolumeClaim", "spec": map[string]any{"resources": map[string]any{"requests": map[string]any{"storage": "1024Mi"}}}},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := deployer.comparePorts(tt.existing, tt.desired)
+			result := deployer.needsUpdate(&unstructured.Unstructured{Object: tt.live}, &unstructured.Unstructured{Object: tt.desired})
 			if result != tt.expected {
-				t.Errorf("comparePorts() = %v, want %v", result, tt.expected)
+				t.Errorf("needsUpdate() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestMapsEqual(t *testing.T) {
-	deployer := &Deployer{
-		logger: slog.Default(),
+func TestDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		live     any
+		desired  any
+		field    string
+		expected bool
+	}{
+		{name: "identical ports", live: []any{map[string]any{"containerPort": int64(80), "protocol": "TCP"}}, desired: []any{map[string]any{"containerPort": int64(80), "protocol": "TCP"}}},
+		{name: "missing protocol defaults to TCP", live: []any{map[string]any{"containerPort": int64(80), "protocol": "TCP"}}, desired: []any{map[string]any{"containerPort": int64(80)}}},
+		{name: "different ports", live: []any{map[string]any{"containerPort": int64(80)}}, desired: []any{map[string]any{"containerPort": int64(8080)}}, field: "[0].containerPort", expected: true},
+		{name: "extra live port", live: []any{map[string]any{"containerPort": int64(80)}, map[string]any{"containerPort": int64(81)}}, desired: []any{map[string]any{"containerPort": int64(80)}}, expected: true},
+		{name: "nested maps", live: map[string]any{"a": map[string]any{"b": "c", "d": "e"}}, desired: map[string]any{"a": map[string]any{"b": "c"}}},
+		{name: "different nested values", live: map[string]any{"a": map[string]any{"b": "c"}}, desired: map[string]any{"a": map[string]any{"b": "x"}}, field: ".a.b", expected: true},
+		{name: "missing key", live: map[string]any{"a": "b"}, desired: map[string]any{"c": "d"}, field: ".c", expected: true},
+		{name: "numbers of different types", live: map[string]any{"a": int64(3)}, desired: map[string]any{"a": float64(3)}},
+		{name: "map replaced by a value", live: map[string]any{"a": "b"}, desired: map[string]any{"a": map[string]any{"b": "c"}}, field: ".a", expected: true},
+		{name: "cpu quantity", live: map[string]any{"limits": map[string]any{"cpu": "500m"}}, desired: map[string]any{"limits": map[string]any{"cpu": 0.5}}},
+		{name: "different cpu quantity", live: map[string]any{"limits": map[string]any{"cpu": "500m"}}, desired: map[string]any{"limits": map[string]any{"cpu": "1"}}, field: ".limits.cpu", expected: true},
+		{name: "null annotations are not set", live: map[string]any{"annotations": map[string]any{"team": "web"}}, desired: map[string]any{"annotations": nil}},
+		{name: "null resources are not set", live: map[string]any{"resources": map[string]any{}}, desired: map[string]any{"resources": nil}},
+		{name: "quantities only in resources", live: map[string]any{"version": "1"}, desired: map[string]any{"version": "1000m"}, field: ".version", expected: true},
+		{name: "quota count as a number", live: map[string]any{"hard": map[string]any{"pods": "10"}}, desired: map[string]any{"hard": map[string]any{"pods": int64(10)}}},
+		{name: "quota in other units", live: map[string]any{"hard": map[string]any{"requests.memory": "1Gi"}}, desired: map[string]any{"hard": map[string]any{"requests.memory": "1024Mi"}}},
+		{name: "extended resource as a number", live: map[string]any{"limits": map[string]any{"nvidia.com/gpu": "1"}}, desired: map[string]any{"limits": map[string]any{"nvidia.com/gpu": int64(1)}}},
+		{name: "different extended resource", live: map[string]any{"limits": map[string]any{"nvidia.com/gpu": "1"}}, desired: map[string]any{"limits": map[string]any{"nvidia.com/gpu": int64(2)}}, field: `.limits["nvidia.com/gpu"]`, expected: true},
+		{name: "dotted keys don't look like quantity maps", live: map[string]any{"labels": map[string]any{"example.com/limits": map[string]any{"a": "1"}}}, desired: map[string]any{"labels": map[string]any{"example.com/limits": map[string]any{"a": "1000m"}}}, field: `.labels["example.com/limits"].a`, expected: true},
+		{name: "number and string outside resources", live: map[string]any{"count": "10"}, desired: map[string]any{"count": int64(10)}},
+		{name: "number and other string", live: map[string]any{"count": "ten"}, desired: map[string]any{"count": int64(10)}, field: ".count", expected: true},
 	}
 
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, differs := drift(tt.live, tt.desired, "")
+			if differs != tt.expected || (tt.field != "" && field != tt.field) {
+				t.Errorf("drift() = %q, %v, want %q, %v", field, differs, tt.field, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRemovedDrift(t *testing.T) {
 	tests := []struct {
 		name     string
-		map1     map[string]any
-		map2     map[string]any
+		live     any
+		previous any
+		desired  any
+		field    string
 		expected bool
 	}{
 		{
-			name: "identical maps should be equal",
-			map1: map[string]any{
-				"key1": "value1",
-				"key2": 42,
-				"key3": true,
-			},
-			map2: map[string]any{
-				"key1": "value1",
-				"key2": 42,
-				"key3": true,
-			},
+			name:     "data key removed",
+			live:     map[string]any{"data": map[string]any{"a": "1", "b": "2"}},
+			previous: map[string]any{"data": map[string]any{"a": "1", "b": "2"}},
+			desired:  map[string]any{"data": map[string]any{"a": "1"}},
+			field:    ".data.b",
 			expected: true,
 		},
 		{
-			name: "different values should not be equal",
-			map1: map[string]any{
-				"key1": "value1",
-				"key2": 42,
-			},
-			map2: map[string]any{
-				"key1": "value1",
-				"key2": 43,
-			},
-			expected: false,
+			name:     "annotation with a dotted key removed",
+			live:     map[string]any{"annotations": map[string]any{"example.com/team": "web"}},
+			previous: map[string]any{"annotations": map[string]any{"example.com/team": "web"}},
+			desired:  map[string]any{"annotations": map[string]any{}},
+			field:    `.annotations["example.com/team"]`,
+			expected: true,
 		},
 		{
-			name: "different keys should not be equal",
-			map1: map[string]any{
-				"key1": "value1",
-				"key2": 42,
-			},
-			map2: map[string]any{
-				"key1": "value1",
-				"key3": 42,
-			},
-			expected: false,
+			name:     "node selector entry removed inside a list item",
+			live:     []any{map[string]any{"nodeSelector": map[string]any{"disk": "ssd", "zone": "a"}}},
+			previous: []any{map[string]any{"nodeSelector": map[string]any{"disk": "ssd", "zone": "a"}}},
+			desired:  []any{map[string]any{"nodeSelector": map[string]any{"disk": "ssd"}}},
+			field:    "[0].nodeSelector.zone",
+			expected: true,
 		},
 		{
-			name: "nested maps should be compared",
-			map1: map[string]any{
-				"nested": map[string]any{
-					"inner": "value",
-				},
-			},
-			map2: map[string]any{
-				"nested": map[string]any{
-					"inner": "value",
-				},
-			},
+			name:     "whole map removed",
+			live:     map[string]any{"data": map[string]any{"a": "1"}},
+			previous: map[string]any{"data": map[string]any{"a": "1"}},
+			desired:  map[string]any{"data": nil},
+			field:    ".data",
 			expected: true,
 		},
 		{
-			name: "different nested maps should not be equal",
-			map1: map[string]any{
-				"nested": map[string]any{
-					"inner": "value1",
-				},
-			},
-			map2: map[string]any{
-				"nested": map[string]any{
-					"inner": "value2",
-				},
-			},
-			expected: false,
+			name:     "removed key already gone from the live object",
+			live:     map[string]any{"data": map[string]any{"a": "1"}},
+			previous: map[string]any{"data": map[string]any{"a": "1", "b": "2"}},
+			desired:  map[string]any{"data": map[string]any{"a": "1"}},
 		},
 		{
-			name: "slices should be compared",
-			map1: map[string]any{
-				"slice": []any{"a", "b", "c"},
-			},
-			map2: map[string]any{
-				"slice": []any{"a", "b", "c"},
-			},
-			expected: true,
+			name:     "nothing removed",
+			live:     map[string]any{"data": map[string]any{"a": "1", "b": "2"}},
+			previous: map[string]any{"data": map[string]any{"a": "1"}},
+			desired:  map[string]any{"data": map[string]any{"a": "1"}},
 		},
 		{
-			name: "different slices should not be equal",
-			map1: map[string]any{
-				"slice": []any{"a", "b", "c"},
-			},
-			map2: map[string]any{
-				"slice": []any{"a", "b", "d"},
-			},
-			expected: false,
+			name:    "no previous manifest",
+			live:    map[string]any{"data": map[string]any{"a": "1", "b": "2"}},
+			desired: map[string]any{"data": map[string]any{"a": "1"}},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := deployer.mapsEqual(tt.map1, tt.map2)
-			if result != tt.expected {
-				t.Errorf("mapsEqual() = %v, want %v", result, tt.expected)
+			field, differs := removedDrift(tt.live, tt.previous, tt.desired, "")
+			if differs != tt.expected || field != tt.field {
+				t.Errorf("removedDrift() = %q, %v, want %q, %v", field, differs, tt.field, tt.expected)
 			}
 		})
 	}
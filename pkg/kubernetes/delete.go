@@ -2,35 +2,22 @@ package kubernetes
 
 import (
 	"context"
-	"fmt"
-	"log/slog"
-	"strings"
+	"slices"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
-// NewDeployerForDelete creates a new Kubernetes deployer specifically for delete operations.
-func NewDeployerForDelete(logger *slog.Logger) (*Deployer, error) {
-	config, err := createKubernetesConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kubernetes config: %w", err)
-	}
-
-	return NewDeployer(config, logger)
-}
-
 // DeleteAllManagedResources finds and deletes all resources with frankthetank.cloud/stack-name annotation.
-func (d *Deployer) DeleteAllManagedResources(stackFilter string) ([]DeleteResult, error) {
+// When stackNames is not empty, only resources of those stacks are deleted.
+func (d *Deployer) DeleteAllManagedResources(stackNames []string) ([]DeleteResult, error) {
 	var results []DeleteResult
 
 	resourceTypes := d.getResourceTypesToDelete()
 
 	for _, rt := range resourceTypes {
-		resourceResults := d.deleteResourcesOfType(rt, stackFilter)
+		resourceResults := d.deleteResourcesOfType(rt, stackNames)
 		results = append(results, resourceResults...)
 	}
 
@@ -69,7 +56,7 @@ func (d *Deployer) deleteResourcesOfType(rt struct {
 	Version  string
 	Resource string
 	Kind     string
-}, stackFilter string,
+}, stackNames []string,
 ) []DeleteResult {
 	var results []DeleteResult
 
@@ -89,7 +76,7 @@ func (d *Deployer) deleteResourcesOfType(rt struct {
 
 	// Check each resource for frank annotation and delete if matches
 	for _, item := range resourceList.Items {
-		if d.shouldDeleteResource(item, stackFilter) {
+		if d.shouldDeleteResource(item, stackNames) {
 			result := d.deleteResource(item, rt, gvr)
 			results = append(results, result)
 		}
@@ -98,8 +85,8 @@ func (d *Deployer) deleteResourcesOfType(rt struct {
 	return results
 }
 
-// shouldDeleteResource checks if a resource should be deleted based on annotations and filter.
-func (d *Deployer) shouldDeleteResource(item unstructured.Unstructured, stackFilter string) bool {
+// shouldDeleteResource checks if a resource should be deleted based on its annotations and the selected stacks.
+func (d *Deployer) shouldDeleteResource(item unstructured.Unstructured, stackNames []string) bool {
 	annotations := item.GetAnnotations()
 	if annotations == nil {
 		return false
@@ -110,12 +97,8 @@ func (d *Deployer) shouldDeleteResource(item unstructured.Unstructured, stackFil
 		return false
 	}
 
-	// Apply stack filter if provided
-	if stackFilter != "" && !d.matchesStackFilter(stackName, stackFilter) {
-		return false
-	}
-
-	return true
+	// Only delete resources of the selected stacks, if any
+	return len(stackNames) == 0 || slices.Contains(stackNames, stackName)
 }
 
 // deleteResource deletes a single resource and returns the result.
@@ -140,6 +123,7 @@ func (d *Deployer) deleteResource(item unstructured.Unstructured, rt struct {
 	err := d.dynamicClient.Resource(gvr).Namespace(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
 
 	result := DeleteResult{
+		Context:      d.kubeContext,
 		StackName:    stackName,
 		ResourceType: rt.Kind,
 		ResourceName: name,
@@ -154,45 +138,3 @@ func (d *Deployer) deleteResource(item unstructured.Unstructured, rt struct {
 
 	return result
 }
-
-// createKubernetesConfig creates a Kubernetes REST config.
-func createKubernetesConfig() (*rest.Config, error) {
-	// Load kubeconfig from default location
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
-
-	// Build config from kubeconfig
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides).ClientConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	return config, nil
-}
-
-// matchesStackFilter checks if a stack name matches the given filter.
-func (d *Deployer) matchesStackFilter(stackName, filter string) bool {
-	// Exact match
-	if stackName == filter {
-		return true
-	}
-
-	// Check if stack name starts with filter (for partial matching)
-	if strings.HasPrefix(stackName, filter) {
-		return true
-	}
-
-	// Check if filter is a directory pattern that matches
-	// e.g., "dev" should match "dev-app", "dev-web", etc.
-	if strings.HasPrefix(stackName, filter+"-") {
-		return true
-	}
-
-	// Check if filter is a path pattern that matches
-	// e.g., "dev/app" should match "dev-app", "dev-app-1", etc.
-	if strings.HasPrefix(stackName, strings.ReplaceAll(filter, "/", "-")) {
-		return true
-	}
-
-	return false
-}
@@ -7,11 +7,15 @@ package kubernetes
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -38,145 +42,221 @@ func NewDeployer(config *rest.Config, logger *slog.Logger) (*Deployer, error) {
 	return &Deployer{
 		dynamicClient: dynamicClient,
 		clientset:     clientset,
+		mapper:        newDiscoveryRESTMapper(clientset.Discovery()),
 		logger:        logger,
 	}, nil
 }
 
-// DeployManifest applies a single manifest file to Kubernetes.
-func (d *Deployer) DeployManifest(manifestPath, stackName, configNamespace string, timeout time.Duration) (*DeployResult, error) {
-	// Parse and prepare the manifest
-	obj, gvr, err := d.parseAndPrepareManifest(manifestPath, stackName, configNamespace)
+// DeployManifest applies every document in a manifest file to Kubernetes.
+func (d *Deployer) DeployManifest(manifestPath, stackName, configNamespace string, timeout time.Duration) ([]DeployResult, error) {
+	// Read the manifest file
+	manifestData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest file: %w", err)
+	}
+
+	return d.DeployManifestContent(manifestData, stackName, configNamespace, timeout)
+}
+
+// DeployManifestContent applies every document in manifest content from memory to Kubernetes.
+func (d *Deployer) DeployManifestContent(manifestContent []byte, stackName, configNamespace string, timeout time.Duration) ([]DeployResult, error) {
+	return d.deployContent(manifestContent, stackName, configNamespace, timeout, nil)
+}
+
+// deployContent applies every document in manifest content. When observed is set, every object must still
+// have the resourceVersion a saved plan observed for it.
+func (d *Deployer) deployContent(manifestContent []byte, stackName, configNamespace string, timeout time.Duration, observed ObservedVersions) ([]DeployResult, error) {
+	objects, err := decodeStackManifest(manifestContent)
 	if err != nil {
 		return nil, err
 	}
 
-	// Apply the resource to Kubernetes
-	operation, result, err := d.applyResource(obj, gvr, stackName)
+	deadline := time.Now().Add(timeout)
+
+	// CustomResourceDefinitions are applied and established first, so the kinds they define
+	// resolve when the rest of the stack is prepared
+	crds, rest := splitCustomResourceDefinitions(objects)
+
+	results, err := d.deployObjects(crds, stackName, configNamespace, deadline, observed)
 	if err != nil {
-		//nolint:nilerr
-		return &DeployResult{
-			Resource:  obj,
-			Operation: operation,
-			Status:    "failed",
-			Error:     err,
-			Timestamp: time.Now(),
-		}, nil
+		return nil, err
 	}
 
-	// Poll for completion and return result
-	status := d.determineStatus(operation, gvr, result, stackName, timeout)
+	more, err := d.deployObjects(rest, stackName, configNamespace, deadline, observed)
+	if err != nil {
+		return nil, err
+	}
 
-	return &DeployResult{
-		Resource:  result,
-		Operation: operation,
-		Status:    status,
-		Error:     err,
-		Timestamp: time.Now(),
-	}, nil
+	return append(results, more...), nil
 }
 
-// DeployManifestContent applies manifest content from memory to Kubernetes.
-func (d *Deployer) DeployManifestContent(manifestContent []byte, stackName, configNamespace string, timeout time.Duration) (*DeployResult, error) {
-	// Parse and prepare the manifest content
-	obj, gvr, err := d.parseAndPrepareManifestContent(manifestContent, stackName, configNamespace)
+// deployObjects prepares and applies objects, then waits until the deadline for them to become ready.
+func (d *Deployer) deployObjects(objects []*unstructured.Unstructured, stackName, configNamespace string, deadline time.Time, observed ObservedVersions) ([]DeployResult, error) {
+	resources, err := d.prepareObjects(objects, stackName, configNamespace)
 	if err != nil {
 		return nil, err
 	}
 
-	// Apply the resource to Kubernetes
-	operation, result, err := d.applyResource(obj, gvr, stackName)
+	if observed != nil {
+		for i := range resources {
+			resources[i].planned = true
+			resources[i].resourceVersion = observed[objectKeyFor(resources[i].obj)]
+		}
+	}
+
+	// Apply all resources first so that readiness of one document can depend on another
+	results := make([]DeployResult, len(resources))
+	for i, resource := range resources {
+		results[i] = d.applyPreparedResource(resource, stackName)
+	}
+
+	// Poll for completion of everything that was created or updated
+	for i, resource := range resources {
+		if results[i].Error != nil {
+			continue
+		}
+
+		results[i].Status = d.determineStatus(results[i].Operation, resource.gvr, results[i].Resource, stackName, time.Until(deadline))
+		results[i].Timestamp = time.Now()
+	}
+
+	return results, nil
+}
+
+// splitCustomResourceDefinitions separates the CustomResourceDefinitions of a stack from its other objects,
+// keeping the order of both.
+func splitCustomResourceDefinitions(objects []*unstructured.Unstructured) ([]*unstructured.Unstructured, []*unstructured.Unstructured) {
+	var crds, rest []*unstructured.Unstructured
+
+	for _, obj := range objects {
+		if obj.GroupVersionKind().GroupKind() == customResourceDefinitionKind {
+			crds = append(crds, obj)
+		} else {
+			rest = append(rest, obj)
+		}
+	}
+
+	return crds, rest
+}
+
+// applyPreparedResource applies a single prepared resource and records the outcome.
+func (d *Deployer) applyPreparedResource(resource preparedResource, stackName string) DeployResult {
+	operation, previous, result, err := d.applyResource(resource, stackName)
 	if err != nil {
-		//nolint:nilerr
-		return &DeployResult{
-			Resource:  obj,
+		return DeployResult{
+			Resource:  resource.obj,
+			Previous:  previous,
 			Operation: operation,
 			Status:    "failed",
 			Error:     err,
+			Conflicts: fieldConflicts(err),
 			Timestamp: time.Now(),
-		}, nil
+			gvr:       resource.gvr,
+		}
 	}
 
-	// Poll for completion and return result
-	status := d.determineStatus(operation, gvr, result, stackName, timeout)
-
-	return &DeployResult{
+	return DeployResult{
 		Resource:  result,
+		Previous:  previous,
 		Operation: operation,
-		Status:    status,
-		Error:     nil,
 		Timestamp: time.Now(),
-	}, nil
+		gvr:       resource.gvr,
+	}
 }
 
-// parseAndPrepareManifest reads, parses, and prepares a manifest file.
-func (d *Deployer) parseAndPrepareManifest(manifestPath, stackName, configNamespace string) (*unstructured.Unstructured, schema.GroupVersionResource, error) {
-	// Read the manifest file
-	manifestData, err := os.ReadFile(manifestPath)
+// parseAndPrepareManifestContent parses and prepares every document in manifest content from memory.
+func (d *Deployer) parseAndPrepareManifestContent(manifestContent []byte, stackName, configNamespace string) ([]preparedResource, error) {
+	objects, err := decodeStackManifest(manifestContent)
 	if err != nil {
-		return nil, schema.GroupVersionResource{}, fmt.Errorf("error reading manifest file: %w", err)
+		return nil, err
 	}
 
-	// Parse the YAML content
-	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifestData), 4096)
-
-	var obj unstructured.Unstructured
+	return d.prepareObjects(objects, stackName, configNamespace)
+}
 
-	err = decoder.Decode(&obj)
+// decodeStackManifest decodes the documents of a stack's manifest content, which must contain at least one.
+func decodeStackManifest(manifestContent []byte) ([]*unstructured.Unstructured, error) {
+	objects, err := DecodeManifest(manifestContent)
 	if err != nil {
-		return nil, schema.GroupVersionResource{}, fmt.Errorf("error parsing YAML: %w", err)
+		return nil, err
 	}
 
-	// Set namespace
-	namespace := d.determineNamespace(obj.GetNamespace(), configNamespace)
-	obj.SetNamespace(namespace)
+	if len(objects) == 0 {
+		return nil, errors.New("no resources found in manifest")
+	}
 
-	// Add stack name annotation and managed-by label
-	d.addStackAnnotation(&obj, stackName)
-	d.addManagedByLabel(&obj)
+	return objects, nil
+}
 
-	// Get the GVR (GroupVersionResource) for the resource
-	gvr, err := d.GetGVR(obj.GetAPIVersion(), obj.GetKind())
-	if err != nil {
-		return nil, schema.GroupVersionResource{}, fmt.Errorf("failed to get GVR for %s/%s: %w", obj.GetAPIVersion(), obj.GetKind(), err)
-	}
+// prepareObjects prepares decoded manifest documents to be applied.
+func (d *Deployer) prepareObjects(objects []*unstructured.Unstructured, stackName, configNamespace string) ([]preparedResource, error) {
+	resources := make([]preparedResource, 0, len(objects))
 
-	d.logger.Debug("Starting apply operation",
-		"stack", stackName,
-		"apiVersion", obj.GetAPIVersion(),
-		"kind", obj.GetKind(),
-		"name", obj.GetName(),
-		"namespace", namespace)
+	for _, obj := range objects {
+		resource, err := d.prepareObject(obj, stackName, configNamespace)
+		if err != nil {
+			return nil, err
+		}
 
-	return &obj, gvr, nil
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
 }
 
-// parseAndPrepareManifestContent parses and prepares manifest content from memory.
-func (d *Deployer) parseAndPrepareManifestContent(manifestContent []byte, stackName, configNamespace string) (*unstructured.Unstructured, schema.GroupVersionResource, error) {
-	// Parse the YAML content
+// DecodeManifest decodes all non-empty YAML or JSON documents in the manifest content.
+func DecodeManifest(manifestContent []byte) ([]*unstructured.Unstructured, error) {
 	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifestContent), 4096)
 
-	var obj unstructured.Unstructured
+	var objects []*unstructured.Unstructured
+
+	for {
+		var raw json.RawMessage
 
-	var err error
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
 
-	err = decoder.Decode(&obj)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing YAML: %w", err)
+		}
+
+		// Skip empty documents (e.g. a trailing "---")
+		trimmed := bytes.TrimSpace(raw)
+		if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+
+		err = obj.UnmarshalJSON(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing YAML document %d: %w", len(objects)+1, err)
+		}
+
+		objects = append(objects, obj)
+	}
+}
+
+// prepareObject sets the namespace and frank metadata on an object and resolves its GVR.
+func (d *Deployer) prepareObject(obj *unstructured.Unstructured, stackName, configNamespace string) (preparedResource, error) {
+	// Get the GVR (GroupVersionResource) for the resource and whether it is namespaced
+	gvr, namespaced, err := d.ResolveResource(obj.GetAPIVersion(), obj.GetKind())
 	if err != nil {
-		return nil, schema.GroupVersionResource{}, fmt.Errorf("error parsing YAML: %w", err)
+		return preparedResource{}, fmt.Errorf("failed to get GVR for %s/%s: %w", obj.GetAPIVersion(), obj.GetKind(), err)
+	}
+
+	// Set namespace; cluster-scoped resources never get one
+	namespace := ""
+	if namespaced {
+		namespace = d.determineNamespace(obj.GetNamespace(), configNamespace)
 	}
 
-	// Set namespace
-	namespace := d.determineNamespace(obj.GetNamespace(), configNamespace)
 	obj.SetNamespace(namespace)
 
 	// Add stack name annotation and managed-by label
-	d.addStackAnnotation(&obj, stackName)
-	d.addManagedByLabel(&obj)
-
-	// Get the GVR (GroupVersionResource) for the resource
-	gvr, err := d.GetGVR(obj.GetAPIVersion(), obj.GetKind())
-	if err != nil {
-		return nil, schema.GroupVersionResource{}, fmt.Errorf("failed to get GVR for %s/%s: %w", obj.GetAPIVersion(), obj.GetKind(), err)
-	}
+	SetStackMetadata(obj, stackName)
 
 	d.logger.Debug("Starting apply operation",
 		"stack", stackName,
@@ -185,7 +265,7 @@ func (d *Deployer) parseAndPrepareManifestContent(manifestContent []byte, stackN
 		"name", obj.GetName(),
 		"namespace", namespace)
 
-	return &obj, gvr, nil
+	return preparedResource{obj: obj, gvr: gvr}, nil
 }
 
 // determineNamespace determines the namespace to use.
@@ -201,8 +281,14 @@ func (d *Deployer) determineNamespace(manifestNamespace, configNamespace string)
 	return "default"
 }
 
+// SetStackMetadata adds the stack name annotation and managed-by label frank sets on every resource.
+func SetStackMetadata(obj *unstructured.Unstructured, stackName string) {
+	addStackAnnotation(obj, stackName)
+	addManagedByLabel(obj)
+}
+
 // addStackAnnotation adds the stack name annotation to the resource.
-func (d *Deployer) addStackAnnotation(obj *unstructured.Unstructured, stackName string) {
+func addStackAnnotation(obj *unstructured.Unstructured, stackName string) {
 	annotations := obj.GetAnnotations()
 	if annotations == nil {
 		annotations = make(map[string]string)
@@ -213,7 +299,7 @@ func (d *Deployer) addStackAnnotation(obj *unstructured.Unstructured, stackName
 }
 
 // addManagedByLabel adds the app.kubernetes.io/managed-by label to the resource.
-func (d *Deployer) addManagedByLabel(obj *unstructured.Unstructured) {
+func addManagedByLabel(obj *unstructured.Unstructured) {
 	labels := obj.GetLabels()
 	if labels == nil {
 		labels = make(map[string]string)
@@ -223,40 +309,68 @@ func (d *Deployer) addManagedByLabel(obj *unstructured.Unstructured) {
 	obj.SetLabels(labels)
 }
 
-// applyResource applies the resource to Kubernetes.
-func (d *Deployer) applyResource(obj *unstructured.Unstructured, gvr schema.GroupVersionResource, stackName string) (string, *unstructured.Unstructured, error) {
+// applyResource creates or updates a single resource. Besides the operation and the resulting object,
+// it returns a snapshot of the live object from before the apply, or nil when the resource was created,
+// so a failed stack can be rolled back.
+func (d *Deployer) applyResource(resource preparedResource, stackName string) (string, *unstructured.Unstructured, *unstructured.Unstructured, error) {
+	if d.applyOptions.ServerSide {
+		return d.serverSideApply(resource, stackName)
+	}
+
+	obj, gvr := resource.obj, resource.gvr
 	namespace := obj.GetNamespace()
 	name := obj.GetName()
 
 	// Check if resource already exists
 	existing, err := d.dynamicClient.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
+		err = resource.checkPlanned(nil)
+		if err != nil {
+			return "failed", nil, nil, err
+		}
+
+		// Record the manifest so that the next apply notices fields removed from it
+		err = setLastApplied(obj)
+		if err != nil {
+			return "failed", nil, nil, err
+		}
+
 		// Resource doesn't exist, create it
 		d.logger.Warn("Resource does not exist, creating", "stack", stackName, "name", name, "namespace", namespace)
 		result, err := d.dynamicClient.Resource(gvr).Namespace(namespace).Create(context.TODO(), obj, metav1.CreateOptions{})
 
-		return "created", result, err
+		return "created", nil, result, err
+	}
+
+	err = resource.checkPlanned(existing)
+	if err != nil {
+		return "failed", existing, nil, err
 	}
 
 	// Resource exists, check if it needs applying
 	if d.needsUpdate(existing, obj) {
+		err = setLastApplied(obj)
+		if err != nil {
+			return "failed", existing, nil, err
+		}
+
 		d.logger.Warn("Updating existing resource", "stack", stackName, "name", name, "namespace", namespace)
-		obj.SetResourceVersion(existing.GetResourceVersion()) // Set resource version for update
+		obj.SetResourceVersion(existing.GetResourceVersion()) // The update fails with a conflict if the object changed since it was read
 		result, err := d.dynamicClient.Resource(gvr).Namespace(namespace).Update(context.TODO(), obj, metav1.UpdateOptions{})
 
-		return "applied", result, err
+		return "applied", existing, result, err
 	}
 
 	// No changes needed
-	return "no-change", existing, nil
+	return "no-change", existing, existing, nil
 }
 
 // determineStatus determines the final status of the deployment.
 func (d *Deployer) determineStatus(operation string, gvr schema.GroupVersionResource, result *unstructured.Unstructured, stackName string, timeout time.Duration) string {
 	if operation == "created" || operation == "applied" {
-		status, err := d.pollForCompletion(gvr, result.GetNamespace(), result.GetName(), stackName, timeout)
+		status, err := d.waitForReady(gvr, result, stackName, timeout)
 		if err != nil {
-			d.logger.Warn("Error polling for completion", "stack", stackName, "error", err)
+			d.logger.Warn("Error waiting for resource to be ready", "stack", stackName, "error", err)
 		}
 
 		return status
@@ -270,43 +384,31 @@ func (d *Deployer) determineStatus(operation string, gvr schema.GroupVersionReso
 
 // GetGVR converts an API version and kind to a GroupVersionResource.
 func (d *Deployer) GetGVR(apiVersion, kind string) (schema.GroupVersionResource, error) {
-	// Parse the API version
-	gv, err := schema.ParseGroupVersion(apiVersion)
-	if err != nil {
-		return schema.GroupVersionResource{}, fmt.Errorf("invalid API version: %w", err)
-	}
-
-	// Map common kinds to their resource names
-	resourceMap := map[string]string{
-		"Deployment":            "deployments",
-		"StatefulSet":           "statefulsets",
-		"DaemonSet":             "daemonsets",
-		"Service":               "services",
-		"ConfigMap":             "configmaps",
-		"Secret":                "secrets",
-		"Pod":                   "pods",
-		"Job":                   "jobs",
-		"CronJob":               "cronjobs",
-		"Ingress":               "ingresses",
-		"PersistentVolume":      "persistentvolumes",
-		"PersistentVolumeClaim": "persistentvolumeclaims",
-	}
-
-	resource, exists := resourceMap[kind]
-	if !exists {
-		// For unknown resources, try to guess the resource name
-		// This is a simple heuristic and might not work for all resources
-		resource = kind + "s"
-	}
-
-	return schema.GroupVersionResource{
-		Group:    gv.Group,
-		Version:  gv.Version,
-		Resource: resource,
-	}, nil
+	gvr, _, err := d.ResolveResource(apiVersion, kind)
+
+	return gvr, err
 }
 
 // GetResource gets a resource from Kubernetes.
 func (d *Deployer) GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
 	return d.dynamicClient.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 }
+
+// CurrentResourceVersion returns the resourceVersion of a live object, or "" when it doesn't exist.
+func (d *Deployer) CurrentResourceVersion(apiVersion, kind, namespace, name string) (string, error) {
+	gvr, _, err := d.ResolveResource(apiVersion, kind)
+	if err != nil {
+		return "", fmt.Errorf("failed to get GVR: %w", err)
+	}
+
+	existing, err := d.GetResource(gvr, namespace, name)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error reading %s %s: %w", kind, name, err)
+	}
+
+	return existing.GetResourceVersion(), nil
+}
@@ -0,0 +1,224 @@
+package kubernetes
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestParseAndPrepareManifestContent(t *testing.T) {
+	deployer := &Deployer{
+		logger: slog.Default(),
+	}
+
+	tests := []struct {
+		name          string
+		content       string
+		expectedKinds []string
+		expectError   bool
+	}{
+		{
+			name: "single document",
+			content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings`,
+			expectedKinds: []string{"ConfigMap"},
+		},
+		{
+			name: "multiple documents",
+			content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web`,
+			expectedKinds: []string{"Deployment", "Service"},
+		},
+		{
+			name: "empty documents are skipped",
+			content: `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+---
+`,
+			expectedKinds: []string{"Service"},
+		},
+		{
+			name:        "no documents",
+			content:     "---\n",
+			expectError: true,
+		},
+		{
+			name: "document without kind",
+			content: `apiVersion: v1
+metadata:
+  name: web`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources, err := deployer.parseAndPrepareManifestContent([]byte(tt.content), "frank-dev-web", "dev")
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("parseAndPrepareManifestContent() expected error but got none")
+				}
+
+				return
+			}
+
+			validatePreparedResources(t, resources, err, tt.expectedKinds)
+		})
+	}
+}
+
+// validatePreparedResources checks kinds, namespace and stack annotation of prepared resources.
+func validatePreparedResources(t *testing.T, resources []preparedResource, err error, expectedKinds []string) {
+	if err != nil {
+		t.Fatalf("parseAndPrepareManifestContent() unexpected error: %v", err)
+	}
+
+	if len(resources) != len(expectedKinds) {
+		t.Fatalf("got %d resources, want %d", len(resources), len(expectedKinds))
+	}
+
+	for i, resource := range resources {
+		if resource.obj.GetKind() != expectedKinds[i] {
+			t.Errorf("resource %d kind = %s, want %s", i, resource.obj.GetKind(), expectedKinds[i])
+		}
+
+		if resource.obj.GetNamespace() != "dev" {
+			t.Errorf("resource %d namespace = %s, want dev", i, resource.obj.GetNamespace())
+		}
+
+		if resource.obj.GetAnnotations()["frankthetank.cloud/stack-name"] != "frank-dev-web" {
+			t.Errorf("resource %d is missing the stack annotation", i)
+		}
+	}
+}
+
+var customResourceDefinitionsGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// crdMapper knows CustomResourceDefinitions and learns the Cactus kind when reset once its definition
+// exists, like a discovery cache.
+type crdMapper struct {
+	*meta.DefaultRESTMapper
+	client dynamic.Interface
+}
+
+func newCRDMapper(client dynamic.Interface) *crdMapper {
+	mapper := &crdMapper{DefaultRESTMapper: meta.NewDefaultRESTMapper(nil), client: client}
+	mapper.AddSpecific(
+		schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+		customResourceDefinitionsGVR,
+		schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinition"},
+		meta.RESTScopeRoot)
+
+	return mapper
+}
+
+func (m *crdMapper) Reset() {
+	_, err := m.client.Resource(customResourceDefinitionsGVR).Get(context.Background(), "cacti.garden.example.com", metav1.GetOptions{})
+	if err == nil {
+		m.DefaultRESTMapper = newTestRESTMapper()
+	}
+}
+
+func TestDeployManifestContentWithCustomResourceDefinition(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	deployer := &Deployer{
+		dynamicClient: client,
+		mapper:        newCRDMapper(client),
+		logger:        slog.Default(),
+	}
+
+	// The custom resource comes first, its definition is only known once it is applied
+	manifest := `apiVersion: garden.example.com/v1
+kind: Cactus
+metadata:
+  name: prickly
+status:
+  conditions:
+  - type: Complete
+    status: "True"
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: cacti.garden.example.com
+status:
+  conditions:
+  - type: NamesAccepted
+    status: "True"
+  - type: Established
+    status: "True"`
+
+	results, err := deployer.DeployManifestContent([]byte(manifest), "dev-garden", "dev", time.Second)
+	if err != nil {
+		t.Fatalf("DeployManifestContent() error = %v", err)
+	}
+
+	if len(results) != 2 || results[0].Resource.GetKind() != "CustomResourceDefinition" || results[1].Resource.GetKind() != "Cactus" {
+		t.Fatalf("DeployManifestContent() = %+v, want the CustomResourceDefinition applied before the Cactus", results)
+	}
+
+	for _, result := range results {
+		if result.Error != nil || result.Operation != "created" {
+			t.Errorf("%s = %q, %v, want created", result.Resource.GetKind(), result.Operation, result.Error)
+		}
+	}
+
+	if results[0].Status != "Ready" {
+		t.Errorf("CustomResourceDefinition status = %q, want Ready", results[0].Status)
+	}
+}
+
+func TestApplyResourceRemovesDroppedKeys(t *testing.T) {
+	deployer, client := newPruneTestDeployer()
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	original := newTestConfigMap()
+	original.Object["data"] = map[string]any{"mode": "fast", "level": "debug"}
+
+	result := deployer.applyPreparedResource(preparedResource{obj: original, gvr: gvr}, "dev-settings")
+	if result.Error != nil || result.Operation != "created" {
+		t.Fatalf("applyPreparedResource() = %q, %v, want created", result.Operation, result.Error)
+	}
+
+	// Dropping a key from the manifest must update the object even though every remaining key matches
+	result = deployer.applyPreparedResource(preparedResource{obj: newTestConfigMap(), gvr: gvr}, "dev-settings")
+	if result.Error != nil || result.Operation != "applied" {
+		t.Fatalf("applyPreparedResource() = %q, %v, want applied", result.Operation, result.Error)
+	}
+
+	live, err := client.Resource(gvr).Namespace("dev").Get(context.TODO(), "settings", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get config map: %v", err)
+	}
+
+	if _, found, _ := unstructured.NestedString(live.Object, "data", "level"); found {
+		t.Errorf("data.level is still set after it was removed from the manifest: %v", live.Object["data"])
+	}
+
+	result = deployer.applyPreparedResource(preparedResource{obj: newTestConfigMap(), gvr: gvr}, "dev-settings")
+	if result.Operation != "no-change" {
+		t.Errorf("applyPreparedResource() = %q after the removal was applied, want no-change", result.Operation)
+	}
+}
@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// workloadKinds are the kinds whose status decides if a stack is healthy. Other objects only have to exist.
+var workloadKinds = []string{"Deployment", "StatefulSet", "DaemonSet", "Job", "Pod"}
+
+// CheckStackHealth checks that a stack is deployed and healthy: at least one of its objects exists
+// and every workload of the stack is ready. Objects are searched among the types frank deletes by
+// default and every type rendered by the stack's manifest content, so stacks that only hold
+// namespaces, RBAC objects or custom resources are found too.
+func (d *Deployer) CheckStackHealth(stackName string, manifestContent []byte) error {
+	gvrs, err := d.healthResourceTypes(manifestContent)
+	if err != nil {
+		return fmt.Errorf("error reading the manifest of stack %s: %w", stackName, err)
+	}
+
+	var (
+		found     int
+		unhealthy []error
+	)
+
+	for _, gvr := range gvrs {
+		items, err := d.listStackObjects(gvr, stackName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("error listing %s of stack %s: %w", gvr.Resource, stackName, err)
+		}
+
+		found += len(items)
+		unhealthy = append(unhealthy, d.unreadyWorkloads(items)...)
+	}
+
+	if found == 0 {
+		return fmt.Errorf("stack %s has no resources in context %s", stackName, d.kubeContext)
+	}
+
+	if len(unhealthy) > 0 {
+		return fmt.Errorf("stack %s is not healthy: %w", stackName, errors.Join(unhealthy...))
+	}
+
+	return nil
+}
+
+// healthResourceTypes returns the resource types to search for the objects of a stack: the types
+// frank deletes by default plus every type in the manifest content, without duplicates.
+func (d *Deployer) healthResourceTypes(manifestContent []byte) ([]schema.GroupVersionResource, error) {
+	objects, err := DecodeManifest(manifestContent)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]preparedResource, 0, len(objects))
+
+	for _, obj := range objects {
+		gvr, _, err := d.ResolveResource(obj.GetAPIVersion(), obj.GetKind())
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, preparedResource{obj: obj, gvr: gvr})
+	}
+
+	return d.pruneResourceTypes(resources), nil
+}
+
+// unreadyWorkloads describes the workloads among the objects that aren't ready.
+func (d *Deployer) unreadyWorkloads(items []unstructured.Unstructured) []error {
+	var errs []error
+
+	for _, item := range items {
+		if !slices.Contains(workloadKinds, item.GetKind()) {
+			continue
+		}
+
+		status := d.getResourceStatus(&item)
+		if !d.isResourceReady(status) {
+			resource := ManagedResource{Kind: item.GetKind(), Name: item.GetName(), Namespace: item.GetNamespace()}
+			errs = append(errs, fmt.Errorf("%s is %s", resource, status))
+		}
+	}
+
+	return errs
+}
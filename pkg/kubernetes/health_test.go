@@ -0,0 +1,67 @@
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// withStatus sets the generation and status of a managed object.
+func withStatus(obj *unstructured.Unstructured, status map[string]any) *unstructured.Unstructured {
+	obj.SetGeneration(1)
+	_ = unstructured.SetNestedMap(obj.Object, status, "status")
+
+	return obj
+}
+
+// assertErrorContains fails the test if the error message doesn't contain the expected text.
+func assertErrorContains(t *testing.T, err error, expected string) {
+	t.Helper()
+
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("error = %v, want %q", err, expected)
+	}
+}
+
+func TestCheckStackHealth(t *testing.T) {
+	deployer, _ := newPruneTestDeployer(
+		withStatus(newManagedObject("apps/v1", "Deployment", "dev", "db", "dev-db"), rolledOutStatus),
+		newManagedObject("v1", "Service", "dev", "db", "dev-db"),
+		newManagedObject("v1", "ConfigMap", "dev", "cache-settings", "dev-cache"),
+		withStatus(newManagedObject("apps/v1", "Deployment", "dev", "queue", "dev-queue"), deadlineExceededStatus),
+		newManagedObject("apps/v1", "Deployment", "dev", "worker", "dev-queue"),
+		newManagedObject("v1", "Namespace", "", "payments", "dev-namespace"),
+		newManagedObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "reader", "dev-rbac"),
+	)
+	deployer.kubeContext = "kind-dev"
+
+	deployment := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n"
+
+	tests := []struct {
+		stackName string
+		manifest  string
+		expected  []string
+	}{
+		{stackName: "dev-db", manifest: deployment},
+		{stackName: "dev-cache", manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cache-settings\n"},
+		{stackName: "dev-queue", manifest: deployment, expected: []string{"stack dev-queue is not healthy", "Deployment/dev/queue is Failed", "Deployment/dev/worker is Progressing"}},
+		{stackName: "dev-search", manifest: deployment, expected: []string{"stack dev-search has no resources in context kind-dev"}},
+		{stackName: "dev-namespace", manifest: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: payments\n"},
+		{stackName: "dev-rbac", manifest: "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: reader\n"},
+		{stackName: "dev-broken", manifest: "kind: [", expected: []string{"error reading the manifest of stack dev-broken"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stackName, func(t *testing.T) {
+			err := deployer.CheckStackHealth(tt.stackName, []byte(tt.manifest))
+			if (err != nil) != (len(tt.expected) > 0) {
+				t.Fatalf("CheckStackHealth() error = %v, want %v", err, tt.expected)
+			}
+
+			for _, expected := range tt.expected {
+				assertErrorContains(t, err, expected)
+			}
+		})
+	}
+}
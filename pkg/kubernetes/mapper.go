@@ -0,0 +1,73 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+)
+
+// customResourceDefinitionKind is the kind of the objects that define custom resources.
+var customResourceDefinitionKind = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+
+// newDiscoveryRESTMapper creates a RESTMapper backed by the API server's discovery
+// information, cached in memory for the lifetime of the client.
+func newDiscoveryRESTMapper(discoveryClient discovery.DiscoveryInterface) meta.RESTMapper {
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+}
+
+// ResolveResource resolves an API version and kind to its GroupVersionResource and
+// reports whether the resource is namespaced.
+func (d *Deployer) ResolveResource(apiVersion, kind string) (schema.GroupVersionResource, bool, error) {
+	// Parse the API version
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, fmt.Errorf("invalid API version: %w", err)
+	}
+
+	gvk := gv.WithKind(kind)
+
+	if d.mapper == nil {
+		return d.fallbackResource(gvk)
+	}
+
+	mapping, err := d.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The kind may have been registered after discovery was cached (e.g. a CRD applied earlier in the stack)
+		d.logger.Debug("Kind not found in cached discovery, refreshing", "apiVersion", apiVersion, "kind", kind)
+		meta.MaybeResetRESTMapper(d.mapper)
+		mapping, err = d.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+
+	if err != nil {
+		return schema.GroupVersionResource{}, false, fmt.Errorf("failed to map %s/%s to a resource: %w", apiVersion, kind, err)
+	}
+
+	return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// fallbackResource guesses the resource for a kind when no discovery information is available.
+func (d *Deployer) fallbackResource(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+	// Use the same pluralization rules as the API machinery (e.g. NetworkPolicy -> networkpolicies)
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+
+	return gvr, !clusterScopedKinds[gvk.Kind], nil
+}
+
+// clusterScopedKinds lists common cluster-scoped kinds used when no discovery information is available.
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"CustomResourceDefinition":       true,
+	"StorageClass":                   true,
+	"PriorityClass":                  true,
+	"IngressClass":                   true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+}
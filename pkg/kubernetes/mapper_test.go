@@ -0,0 +1,193 @@
+package kubernetes
+
+import (
+	"log/slog"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// newTestRESTMapper creates a static RESTMapper with a few namespaced and cluster-scoped kinds.
+func newTestRESTMapper() *meta.DefaultRESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+
+	mapper.AddSpecific(
+		schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+		schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+		schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicy"},
+		meta.RESTScopeNamespace)
+	mapper.AddSpecific(
+		schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+		schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+		schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrole"},
+		meta.RESTScopeRoot)
+	mapper.AddSpecific(
+		schema.GroupVersionKind{Group: "garden.example.com", Version: "v1", Kind: "Cactus"},
+		schema.GroupVersionResource{Group: "garden.example.com", Version: "v1", Resource: "cacti"},
+		schema.GroupVersionResource{Group: "garden.example.com", Version: "v1", Resource: "cactus"},
+		meta.RESTScopeNamespace)
+
+	return mapper
+}
+
+func TestResolveResource(t *testing.T) {
+	tests := []struct {
+		name               string
+		mapper             meta.RESTMapper
+		apiVersion         string
+		kind               string
+		expectedResource   string
+		expectedNamespaced bool
+		expectError        bool
+	}{
+		{
+			name:               "namespaced kind from discovery",
+			mapper:             newTestRESTMapper(),
+			apiVersion:         "networking.k8s.io/v1",
+			kind:               "NetworkPolicy",
+			expectedResource:   "networkpolicies",
+			expectedNamespaced: true,
+		},
+		{
+			name:               "cluster-scoped kind from discovery",
+			mapper:             newTestRESTMapper(),
+			apiVersion:         "rbac.authorization.k8s.io/v1",
+			kind:               "ClusterRole",
+			expectedResource:   "clusterroles",
+			expectedNamespaced: false,
+		},
+		{
+			name:               "custom resource with irregular plural",
+			mapper:             newTestRESTMapper(),
+			apiVersion:         "garden.example.com/v1",
+			kind:               "Cactus",
+			expectedResource:   "cacti",
+			expectedNamespaced: true,
+		},
+		{
+			name:        "unknown kind in discovery",
+			mapper:      newTestRESTMapper(),
+			apiVersion:  "example.com/v1",
+			kind:        "Widget",
+			expectError: true,
+		},
+		{
+			name:               "fallback pluralizes without discovery",
+			apiVersion:         "networking.k8s.io/v1",
+			kind:               "NetworkPolicy",
+			expectedResource:   "networkpolicies",
+			expectedNamespaced: true,
+		},
+		{
+			name:               "fallback knows common cluster-scoped kinds",
+			apiVersion:         "v1",
+			kind:               "Namespace",
+			expectedResource:   "namespaces",
+			expectedNamespaced: false,
+		},
+		{
+			name:        "invalid API version",
+			apiVersion:  "a/b/c",
+			kind:        "Deployment",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer := &Deployer{
+				mapper: tt.mapper,
+				logger: slog.Default(),
+			}
+
+			gvr, namespaced, err := deployer.ResolveResource(tt.apiVersion, tt.kind)
+			validateResolvedResource(t, gvr, namespaced, err, tt.expectedResource, tt.expectedNamespaced, tt.expectError)
+		})
+	}
+}
+
+func validateResolvedResource(t *testing.T, gvr schema.GroupVersionResource, namespaced bool, err error, expectedResource string, expectedNamespaced, expectError bool) {
+	if expectError {
+		if err == nil {
+			t.Errorf("Expected error, got %v", gvr)
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Fatalf("ResolveResource() error = %v", err)
+	}
+
+	if gvr.Resource != expectedResource {
+		t.Errorf("ResolveResource() resource = %q, want %q", gvr.Resource, expectedResource)
+	}
+
+	if namespaced != expectedNamespaced {
+		t.Errorf("ResolveResource() namespaced = %v, want %v", namespaced, expectedNamespaced)
+	}
+}
+
+// resettableMapper starts without the Cactus kind and learns it when reset, like a discovery cache after a CRD is created.
+type resettableMapper struct {
+	*meta.DefaultRESTMapper
+	resets int
+}
+
+func (m *resettableMapper) Reset() {
+	m.resets++
+	m.DefaultRESTMapper = newTestRESTMapper()
+}
+
+func TestResolveResourceRefreshesDiscovery(t *testing.T) {
+	mapper := &resettableMapper{DefaultRESTMapper: meta.NewDefaultRESTMapper(nil)}
+	deployer := &Deployer{
+		mapper: mapper,
+		logger: slog.Default(),
+	}
+
+	gvr, _, err := deployer.ResolveResource("garden.example.com/v1", "Cactus")
+	if err != nil {
+		t.Fatalf("ResolveResource() error = %v", err)
+	}
+
+	if gvr.Resource != "cacti" {
+		t.Errorf("Expected resource 'cacti', got %q", gvr.Resource)
+	}
+
+	if mapper.resets != 1 {
+		t.Errorf("Expected discovery to be refreshed once, got %d", mapper.resets)
+	}
+}
+
+func TestPrepareObjectClusterScoped(t *testing.T) {
+	deployer := &Deployer{
+		mapper: newTestRESTMapper(),
+		logger: slog.Default(),
+	}
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRole",
+			"metadata": map[string]any{
+				"name": "reader",
+			},
+		},
+	}
+
+	resource, err := deployer.prepareObject(obj, "dev-reader", "dev")
+	if err != nil {
+		t.Fatalf("prepareObject() error = %v", err)
+	}
+
+	if namespace := resource.obj.GetNamespace(); namespace != "" {
+		t.Errorf("Expected cluster-scoped resource without namespace, got %q", namespace)
+	}
+
+	if resource.gvr.Resource != "clusterroles" {
+		t.Errorf("Expected resource 'clusterroles', got %q", resource.gvr.Resource)
+	}
+}
@@ -0,0 +1,114 @@
+package kubernetes
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ObjectKey identifies an object by API group, kind, namespace and name, independently of its API version.
+type ObjectKey struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// NewObjectKey creates the key of an object from its API version, kind, namespace and name.
+func NewObjectKey(apiVersion, kind, namespace, name string) ObjectKey {
+	gv, _ := schema.ParseGroupVersion(apiVersion)
+
+	return ObjectKey{Group: gv.Group, Kind: kind, Namespace: namespace, Name: name}
+}
+
+// objectKeyFor returns the key of an object.
+func objectKeyFor(obj *unstructured.Unstructured) ObjectKey {
+	return NewObjectKey(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// ObservedVersions holds the resourceVersion a saved plan observed for every object, "" for objects that
+// didn't exist.
+type ObservedVersions map[ObjectKey]string
+
+// DeployPlannedContent applies manifest content from a saved plan like DeployManifestContent. An object that
+// changed, appeared or disappeared since the plan observed it fails to apply, and an object that changes
+// between reading and writing it fails with a conflict.
+func (d *Deployer) DeployPlannedContent(manifestContent []byte, stackName, configNamespace string, timeout time.Duration, observed ObservedVersions) ([]DeployResult, error) {
+	if observed == nil {
+		observed = ObservedVersions{}
+	}
+
+	return d.deployContent(manifestContent, stackName, configNamespace, timeout, observed)
+}
+
+// PlannedDeletion is an object a saved plan deletes from a stack, with the resourceVersion the plan observed.
+type PlannedDeletion struct {
+	APIVersion      string
+	Kind            string
+	Name            string
+	Namespace       string
+	ResourceVersion string
+}
+
+// PrunePlanned deletes exactly the objects a saved plan listed for deletion. An object is only deleted if it
+// still has the resourceVersion the plan observed, and objects that are already gone are left out.
+func (d *Deployer) PrunePlanned(deletions []PlannedDeletion, stackName string) []DeleteResult {
+	results := make([]DeleteResult, 0, len(deletions))
+
+	for _, deletion := range deletions {
+		resource := ManagedResource{
+			Kind:            deletion.Kind,
+			Name:            deletion.Name,
+			Namespace:       deletion.Namespace,
+			ResourceVersion: deletion.ResourceVersion,
+		}
+
+		gvr, _, err := d.ResolveResource(deletion.APIVersion, deletion.Kind)
+		if err != nil {
+			results = append(results, d.deleteResult(resource, stackName, fmt.Errorf("failed to get GVR: %w", err)))
+
+			continue
+		}
+
+		resource.GVR = gvr
+
+		result := d.pruneResource(resource, stackName, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{ResourceVersion: &resource.ResourceVersion},
+		})
+		if !apierrors.IsNotFound(result.Error) {
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// checkPlanned checks that the live object, nil when it doesn't exist, is still the version a saved plan
+// observed. Resources that don't come from a saved plan always pass.
+func (r preparedResource) checkPlanned(existing *unstructured.Unstructured) error {
+	if !r.planned {
+		return nil
+	}
+
+	current := ""
+	if existing != nil {
+		current = existing.GetResourceVersion()
+	}
+
+	resource := managedResourceFor(r)
+
+	switch {
+	case current == r.resourceVersion:
+		return nil
+	case r.resourceVersion == "":
+		return fmt.Errorf("%s was created since the plan was taken", resource)
+	case current == "":
+		return fmt.Errorf("%s was deleted since the plan was taken", resource)
+	default:
+		return fmt.Errorf("%s changed since the plan was taken (resourceVersion %s, now %s)", resource, r.resourceVersion, current)
+	}
+}
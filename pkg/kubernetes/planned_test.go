@@ -0,0 +1,139 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestApplyPlannedResource(t *testing.T) {
+	tests := []struct {
+		name            string
+		existing        []runtime.Object
+		serverSide      bool
+		resourceVersion string
+		expected        string
+		expectedError   string
+	}{
+		{
+			name:            "object the plan observed is applied",
+			existing:        []runtime.Object{newTestConfigMapWithVersion("1")},
+			resourceVersion: "1",
+			expected:        "no-change",
+		},
+		{
+			name:     "missing object is created",
+			expected: "created",
+		},
+		{
+			name:            "changed object fails",
+			existing:        []runtime.Object{newTestConfigMapWithVersion("2")},
+			resourceVersion: "1",
+			expectedError:   "ConfigMap/dev/settings changed since the plan was taken (resourceVersion 1, now 2)",
+		},
+		{
+			name:            "changed object fails with server-side apply",
+			existing:        []runtime.Object{newTestConfigMapWithVersion("2")},
+			serverSide:      true,
+			resourceVersion: "1",
+			expectedError:   "changed since the plan was taken",
+		},
+		{
+			name:          "created object fails",
+			existing:      []runtime.Object{newTestConfigMapWithVersion("1")},
+			expectedError: "ConfigMap/dev/settings was created since the plan was taken",
+		},
+		{
+			name:            "deleted object fails",
+			resourceVersion: "1",
+			expectedError:   "ConfigMap/dev/settings was deleted since the plan was taken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer, _ := newPruneTestDeployer(tt.existing...)
+			deployer.applyOptions.ServerSide = tt.serverSide
+
+			resource := preparedResource{
+				obj:             newTestConfigMap(),
+				gvr:             schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+				planned:         true,
+				resourceVersion: tt.resourceVersion,
+			}
+
+			validateApplyResult(t, deployer.applyPreparedResource(resource, "dev-settings"), tt.expected, tt.expectedError)
+		})
+	}
+}
+
+// validateApplyResult checks the operation of an apply, or its error when one is expected.
+func validateApplyResult(t *testing.T, result DeployResult, expected, expectedError string) {
+	t.Helper()
+
+	if expectedError != "" {
+		if result.Error == nil || !strings.Contains(result.Error.Error(), expectedError) {
+			t.Errorf("applyPreparedResource() error = %v, want %q", result.Error, expectedError)
+		}
+
+		return
+	}
+
+	if result.Error != nil || result.Operation != expected {
+		t.Errorf("applyPreparedResource() = %q, %v, want %q", result.Operation, result.Error, expected)
+	}
+}
+
+func TestNewObjectKey(t *testing.T) {
+	key := NewObjectKey("apps/v1", "Deployment", "dev", "web")
+	if key != NewObjectKey("apps/v1beta1", "Deployment", "dev", "web") {
+		t.Errorf("keys of one object differ between API versions")
+	}
+
+	if key.Group != "apps" || NewObjectKey("v1", "Service", "dev", "web").Group != "" {
+		t.Errorf("NewObjectKey() = %+v, want group apps", key)
+	}
+}
+
+func TestPrunePlanned(t *testing.T) {
+	planned := newManagedObject("v1", "ConfigMap", "dev", "legacy", "dev-web")
+	planned.SetResourceVersion("7")
+
+	// Other orphans of the stack weren't in the plan and stay
+	deployer, client := newPruneTestDeployer(planned, newManagedObject("v1", "ConfigMap", "dev", "unplanned", "dev-web"))
+
+	var preconditions []string
+
+	client.PrependReactor("delete", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if precondition := action.(k8stesting.DeleteAction).GetDeleteOptions().Preconditions; precondition != nil {
+			preconditions = append(preconditions, *precondition.ResourceVersion)
+		}
+
+		return false, nil, nil
+	})
+
+	results := deployer.PrunePlanned([]PlannedDeletion{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "legacy", Namespace: "dev", ResourceVersion: "7"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "gone", Namespace: "dev", ResourceVersion: "3"},
+	}, "dev-web")
+
+	if len(results) != 1 || results[0].ResourceName != "legacy" || results[0].Error != nil {
+		t.Fatalf("PrunePlanned() = %+v, want only legacy deleted", results)
+	}
+
+	if len(preconditions) != 2 || preconditions[0] != "7" {
+		t.Errorf("delete preconditions = %v, want the observed resourceVersions", preconditions)
+	}
+
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	_, err := client.Resource(configMaps).Namespace("dev").Get(context.Background(), "unplanned", metav1.GetOptions{})
+	if err != nil {
+		t.Errorf("unplanned ConfigMap was deleted, Get() error = %v", err)
+	}
+}
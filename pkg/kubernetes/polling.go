@@ -11,12 +11,15 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// pollInterval is how often pollForCompletion reads a resource that can't be watched.
+var pollInterval = 2 * time.Second
+
 // pollForCompletion polls the Kubernetes API until the resource is ready or timeout.
 func (d *Deployer) pollForCompletion(gvr schema.GroupVersionResource, namespace, name, stackName string, timeout time.Duration) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	ticker := time.NewTicker(2 * time.Second)
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
 	d.logger.Warn("Waiting for resource to be ready", "stack", stackName, "name", name, "namespace", namespace)
@@ -119,104 +122,25 @@ func (d *Deployer) getResourceStatus(resource *unstructured.Unstructured) string
 		return "Ready" // Secrets are immediately ready
 	case "Pod":
 		return d.getPodStatus(resource)
+	case "CustomResourceDefinition":
+		return d.getCustomResourceDefinitionStatus(resource)
 	default:
 		// For unknown resources, check if they have a ready condition
 		return d.getGenericStatus(resource)
 	}
 }
 
-// getDeploymentStatus checks the status of a Deployment.
-func (d *Deployer) getDeploymentStatus(resource *unstructured.Unstructured) string {
+// getCustomResourceDefinitionStatus checks if a CustomResourceDefinition is established, so the API server
+// serves the kind it defines. It fails when its names conflict with another definition.
+func (d *Deployer) getCustomResourceDefinitionStatus(resource *unstructured.Unstructured) string {
 	status, _, _ := unstructured.NestedMap(resource.Object, "status")
-	if status == nil {
-		return "Progressing"
-	}
-
-	return d.checkDeploymentConditions(status)
-}
-
-// checkDeploymentConditions checks deployment-specific conditions.
-func (d *Deployer) checkDeploymentConditions(status map[string]any) string {
-	conditions, _, _ := unstructured.NestedSlice(status, "conditions")
-
-	for _, cond := range conditions {
-		condMap, ok := cond.(map[string]any)
-		if !ok {
-			continue
-		}
-
-		condType, _, _ := unstructured.NestedString(condMap, "type")
-		condStatus, _, _ := unstructured.NestedString(condMap, "status")
 
-		if d.isDeploymentAvailable(condType, condStatus) {
-			return "Available"
-		}
-
-		if d.isDeploymentFailed(condType, condStatus) {
-			return "Failed"
-		}
-	}
-
-	return "Progressing"
-}
-
-// isDeploymentAvailable checks if deployment is available.
-func (d *Deployer) isDeploymentAvailable(condType, condStatus string) bool {
-	return condType == "Available" && condStatus == "True"
-}
-
-// isDeploymentFailed checks if deployment failed.
-func (d *Deployer) isDeploymentFailed(condType, condStatus string) bool {
-	return condType == "Progressing" && condStatus == "False"
-}
-
-// getStatefulSetStatus checks the status of a StatefulSet.
-func (d *Deployer) getStatefulSetStatus(resource *unstructured.Unstructured) string {
-	status, _, _ := unstructured.NestedMap(resource.Object, "status")
-	if status == nil {
-		return "Progressing"
-	}
-
-	// Check conditions
-	conditions, _, _ := unstructured.NestedSlice(status, "conditions")
-	for _, cond := range conditions {
-		condMap, ok := cond.(map[string]any)
-		if !ok {
-			continue
-		}
-
-		condType, _, _ := unstructured.NestedString(condMap, "type")
-		condStatus, _, _ := unstructured.NestedString(condMap, "status")
-
-		if condType == "Ready" && condStatus == "True" {
-			return "Ready"
-		}
-	}
-
-	return "Progressing"
-}
-
-// getDaemonSetStatus checks the status of a DaemonSet.
-func (d *Deployer) getDaemonSetStatus(resource *unstructured.Unstructured) string {
-	status, _, _ := unstructured.NestedMap(resource.Object, "status")
-	if status == nil {
-		return "Progressing"
+	if namesAccepted := findCondition(status, "NamesAccepted"); namesAccepted["status"] == "False" {
+		return "Failed"
 	}
 
-	// Check conditions
-	conditions, _, _ := unstructured.NestedSlice(status, "conditions")
-	for _, cond := range conditions {
-		condMap, ok := cond.(map[string]any)
-		if !ok {
-			continue
-		}
-
-		condType, _, _ := unstructured.NestedString(condMap, "type")
-		condStatus, _, _ := unstructured.NestedString(condMap, "status")
-
-		if condType == "Ready" && condStatus == "True" {
-			return "Ready"
-		}
+	if established := findCondition(status, "Established"); established["status"] == "True" {
+		return "Ready"
 	}
 
 	return "Progressing"
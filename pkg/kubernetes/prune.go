@@ -0,0 +1,198 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ManagedResource identifies a live object that belongs to a stack.
+type ManagedResource struct {
+	GVR             schema.GroupVersionResource
+	Kind            string
+	Name            string
+	Namespace       string
+	ResourceVersion string
+}
+
+// String formats the resource as Kind/namespace/name.
+func (r ManagedResource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// key identifies the resource independently of the API version it was read with.
+func (r ManagedResource) key() string {
+	return fmt.Sprintf("%s|%s|%s", r.GVR.GroupResource(), r.Namespace, r.Name)
+}
+
+// FindOrphanedResources lists live objects annotated with the stack name that are
+// no longer part of the stack's rendered manifest content. Only the types frank deletes by
+// default and the types the stack still renders are searched. It fails when a type can't be
+// listed, so a prune is never reported complete when objects may have been missed.
+func (d *Deployer) FindOrphanedResources(manifestContent []byte, stackName, configNamespace string) ([]ManagedResource, error) {
+	resources, err := d.parseAndPrepareManifestContent(manifestContent, stackName, configNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything that is still rendered must be kept
+	desired := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		desired[managedResourceFor(resource).key()] = true
+	}
+
+	var orphans []ManagedResource
+
+	for _, gvr := range d.pruneResourceTypes(resources) {
+		live, err := d.listStackResources(gvr, stackName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range live {
+			if !desired[resource.key()] {
+				orphans = append(orphans, resource)
+			}
+		}
+	}
+
+	return orphans, nil
+}
+
+// PruneManifestContent deletes live objects of the stack that are no longer part of its rendered manifest content.
+func (d *Deployer) PruneManifestContent(manifestContent []byte, stackName, configNamespace string) ([]DeleteResult, error) {
+	orphans, err := d.FindOrphanedResources(manifestContent, stackName, configNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("error finding resources to prune: %w", err)
+	}
+
+	results := make([]DeleteResult, 0, len(orphans))
+
+	for _, orphan := range orphans {
+		results = append(results, d.pruneResource(orphan, stackName, metav1.DeleteOptions{}))
+	}
+
+	return results, nil
+}
+
+// managedResourceFor describes a prepared manifest document as a managed resource.
+func managedResourceFor(resource preparedResource) ManagedResource {
+	return ManagedResource{
+		GVR:       resource.gvr,
+		Kind:      resource.obj.GetKind(),
+		Name:      resource.obj.GetName(),
+		Namespace: resource.obj.GetNamespace(),
+	}
+}
+
+// pruneResourceTypes returns the resource types to search for orphans: the types frank
+// deletes by default plus every type rendered by the stack, without duplicates.
+func (d *Deployer) pruneResourceTypes(resources []preparedResource) []schema.GroupVersionResource {
+	seen := make(map[schema.GroupResource]bool)
+
+	var gvrs []schema.GroupVersionResource
+
+	add := func(gvr schema.GroupVersionResource) {
+		if !seen[gvr.GroupResource()] {
+			seen[gvr.GroupResource()] = true
+			gvrs = append(gvrs, gvr)
+		}
+	}
+
+	for _, rt := range d.getResourceTypesToDelete() {
+		add(schema.GroupVersionResource{Group: rt.Group, Version: rt.Version, Resource: rt.Resource})
+	}
+
+	for _, resource := range resources {
+		add(resource.gvr)
+	}
+
+	return gvrs
+}
+
+// listStackResources lists frank-managed objects of one type that carry the stack's annotation.
+// Types the cluster doesn't serve have no objects.
+func (d *Deployer) listStackResources(gvr schema.GroupVersionResource, stackName string) ([]ManagedResource, error) {
+	items, err := d.listStackObjects(gvr, stackName)
+	if apierrors.IsNotFound(err) {
+		d.logger.Debug("Resource type not served, nothing to prune", "resource", gvr.String())
+
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", gvr.GroupResource(), err)
+	}
+
+	resources := make([]ManagedResource, 0, len(items))
+
+	for _, item := range items {
+		resources = append(resources, ManagedResource{
+			GVR:             gvr,
+			Kind:            item.GetKind(),
+			Name:            item.GetName(),
+			Namespace:       item.GetNamespace(),
+			ResourceVersion: item.GetResourceVersion(),
+		})
+	}
+
+	return resources, nil
+}
+
+// listStackObjects lists live frank-managed objects of one type that carry the stack's annotation,
+// leaving out objects that are already going away.
+func (d *Deployer) listStackObjects(gvr schema.GroupVersionResource, stackName string) ([]unstructured.Unstructured, error) {
+	// List resources across all namespaces
+	resourceList, err := d.dynamicClient.Resource(gvr).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/managed-by=frank",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []unstructured.Unstructured
+
+	for _, item := range resourceList.Items {
+		if item.GetAnnotations()["frankthetank.cloud/stack-name"] == stackName && item.GetDeletionTimestamp() == nil {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// pruneResource deletes a single orphaned resource and returns the result.
+func (d *Deployer) pruneResource(resource ManagedResource, stackName string, options metav1.DeleteOptions) DeleteResult {
+	d.logger.Warn("Pruning resource removed from stack",
+		"stack", stackName,
+		"resource", resource.Kind,
+		"name", resource.Name,
+		"namespace", resource.Namespace)
+
+	err := d.dynamicClient.Resource(resource.GVR).Namespace(resource.Namespace).Delete(context.TODO(), resource.Name, options)
+	if err != nil {
+		d.logger.Error("Failed to prune resource", "stack", stackName, "resource", resource.String(), "error", err)
+	}
+
+	return d.deleteResult(resource, stackName, err)
+}
+
+// deleteResult records the outcome of deleting a resource of a stack.
+func (d *Deployer) deleteResult(resource ManagedResource, stackName string, err error) DeleteResult {
+	return DeleteResult{
+		Context:      d.kubeContext,
+		StackName:    stackName,
+		ResourceType: resource.Kind,
+		ResourceName: resource.Name,
+		Namespace:    resource.Namespace,
+		Error:        err,
+	}
+}
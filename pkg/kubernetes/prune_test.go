@@ -0,0 +1,156 @@
+package kubernetes
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newManagedObject creates a frank-managed object owned by the given stack.
+func newManagedObject(apiVersion, kind, namespace, name, stackName string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetAnnotations(map[string]string{"frankthetank.cloud/stack-name": stackName})
+	obj.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "frank"})
+
+	return obj
+}
+
+// newPruneTestDeployer creates a deployer backed by a fake dynamic client that can list every pruned type,
+// namespaces and cluster roles.
+func newPruneTestDeployer(objects ...runtime.Object) (*Deployer, *dynamicfake.FakeDynamicClient) {
+	deployer := &Deployer{logger: slog.Default()}
+
+	listKinds := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "namespaces"}:                                       "NamespaceList",
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}: "ClusterRoleList",
+	}
+
+	for _, rt := range deployer.getResourceTypesToDelete() {
+		listKinds[schema.GroupVersionResource{Group: rt.Group, Version: rt.Version, Resource: rt.Resource}] = rt.Kind + "List"
+	}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	deployer.dynamicClient = client
+
+	return deployer, client
+}
+
+func TestFindOrphanedResources(t *testing.T) {
+	deployer, _ := newPruneTestDeployer(
+		newManagedObject("apps/v1", "Deployment", "dev", "web", "dev-web"),
+		newManagedObject("v1", "Service", "dev", "web", "dev-web"),
+		newManagedObject("v1", "ConfigMap", "dev", "legacy-settings", "dev-web"),
+		newManagedObject("v1", "ConfigMap", "dev", "other-settings", "dev-other"),
+	)
+
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web`
+
+	orphans, err := deployer.FindOrphanedResources([]byte(manifest), "dev-web", "dev")
+	if err != nil {
+		t.Fatalf("FindOrphanedResources() error = %v", err)
+	}
+
+	if len(orphans) != 1 || orphans[0].String() != "ConfigMap/dev/legacy-settings" {
+		t.Errorf("Expected only ConfigMap/dev/legacy-settings to be orphaned, got %v", orphans)
+	}
+}
+
+func TestPruneManifestContent(t *testing.T) {
+	deployer, client := newPruneTestDeployer(
+		newManagedObject("v1", "Service", "dev", "web", "dev-web"),
+		newManagedObject("v1", "Service", "dev", "web-legacy", "dev-web"),
+	)
+
+	manifest := `apiVersion: v1
+kind: Service
+metadata:
+  name: web`
+
+	results, err := deployer.PruneManifestContent([]byte(manifest), "dev-web", "dev")
+	if err != nil {
+		t.Fatalf("PruneManifestContent() error = %v", err)
+	}
+
+	if len(results) != 1 || results[0].ResourceName != "web-legacy" || results[0].Error != nil {
+		t.Fatalf("Expected web-legacy to be pruned, got %+v", results)
+	}
+
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	_, err = client.Resource(gvr).Namespace("dev").Get(t.Context(), "web-legacy", metav1.GetOptions{})
+	if err == nil {
+		t.Error("Expected web-legacy to be deleted from the cluster")
+	}
+
+	_, err = client.Resource(gvr).Namespace("dev").Get(t.Context(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Errorf("Expected web to be kept, got %v", err)
+	}
+}
+
+func TestCurrentResourceVersion(t *testing.T) {
+	live := newManagedObject("v1", "Service", "dev", "web", "dev-web")
+	live.SetResourceVersion("42")
+
+	deployer, _ := newPruneTestDeployer(live)
+
+	tests := []struct {
+		name     string
+		resource string
+		expected string
+	}{
+		{name: "existing object", resource: "web", expected: "42"},
+		{name: "missing object", resource: "api", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := deployer.CurrentResourceVersion("v1", "Service", "dev", tt.resource)
+			if err != nil {
+				t.Fatalf("CurrentResourceVersion() error = %v", err)
+			}
+
+			if version != tt.expected {
+				t.Errorf("Expected resourceVersion %q, got %q", tt.expected, version)
+			}
+		})
+	}
+}
+
+func TestFindOrphanedResourcesListForbidden(t *testing.T) {
+	deployer, client := newPruneTestDeployer(newManagedObject("v1", "ConfigMap", "dev", "legacy-settings", "dev-web"))
+
+	client.PrependReactor("list", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New("list is not allowed"))
+	})
+
+	manifest := `apiVersion: v1
+kind: Service
+metadata:
+  name: web`
+
+	orphans, err := deployer.FindOrphanedResources([]byte(manifest), "dev-web", "dev")
+	if !apierrors.IsForbidden(err) {
+		t.Errorf("FindOrphanedResources() = %v, %v, want the forbidden list error", orphans, err)
+	}
+}
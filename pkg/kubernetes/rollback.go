@@ -0,0 +1,73 @@
+package kubernetes
+
+import (
+	"context"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// failedStatuses are the statuses of resources that didn't become ready.
+var failedStatuses = []string{"failed", "Failed", "ReplicaFailure", "timeout"}
+
+// Failed checks if the resource failed to apply, failed to become ready or timed out waiting.
+func (r DeployResult) Failed() bool {
+	return r.Error != nil || slices.Contains(failedStatuses, r.Status)
+}
+
+// Rollback undoes what an apply changed in a stack, in reverse order of the apply. Resources it updated
+// are restored to the version they had before the apply and resources it created are deleted. Resources
+// it left unchanged or failed to apply are skipped.
+func (d *Deployer) Rollback(results []DeployResult, stackName string) []RollbackResult {
+	var rollbacks []RollbackResult
+
+	for i := len(results) - 1; i >= 0; i-- {
+		result := results[i]
+		if result.Error != nil {
+			continue
+		}
+
+		switch result.Operation {
+		case "created":
+			rollbacks = append(rollbacks, d.deleteCreated(result, stackName))
+		case "applied":
+			rollbacks = append(rollbacks, d.restorePrevious(result, stackName))
+		}
+	}
+
+	return rollbacks
+}
+
+// deleteCreated deletes a resource the apply created.
+func (d *Deployer) deleteCreated(result DeployResult, stackName string) RollbackResult {
+	obj := result.Resource
+
+	d.logger.Warn("Rolling back created resource", "stack", stackName, "kind", obj.GetKind(), "name", obj.GetName(), "namespace", obj.GetNamespace())
+
+	err := d.dynamicClient.Resource(result.gvr).Namespace(obj.GetNamespace()).Delete(context.TODO(), obj.GetName(), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		err = nil
+	}
+
+	return RollbackResult{Resource: obj, Operation: "deleted", Error: err}
+}
+
+// restorePrevious writes back the version of a resource from before the apply updated it.
+func (d *Deployer) restorePrevious(result DeployResult, stackName string) RollbackResult {
+	previous := result.Previous.DeepCopy()
+	client := d.dynamicClient.Resource(result.gvr).Namespace(previous.GetNamespace())
+
+	d.logger.Warn("Rolling back updated resource", "stack", stackName, "kind", previous.GetKind(), "name", previous.GetName(), "namespace", previous.GetNamespace())
+
+	// The snapshot replaces whatever is live now, including changes made by controllers since the apply
+	live, err := client.Get(context.TODO(), previous.GetName(), metav1.GetOptions{})
+	if err == nil {
+		previous.SetResourceVersion(live.GetResourceVersion())
+		previous.SetManagedFields(nil)
+
+		_, err = client.Update(context.TODO(), previous, metav1.UpdateOptions{})
+	}
+
+	return RollbackResult{Resource: result.Previous, Operation: "restored", Error: err}
+}
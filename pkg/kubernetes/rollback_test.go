@@ -0,0 +1,75 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRollback(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	deployer, client := newPruneTestDeployer(newTestConfigMap())
+
+	updated := newTestConfigMap()
+	_ = unstructured.SetNestedField(updated.Object, "slow", "data", "mode")
+
+	results := []DeployResult{
+		deployer.applyPreparedResource(preparedResource{obj: updated, gvr: configMaps}, "dev-web"),
+		deployer.applyPreparedResource(preparedResource{obj: newManagedObject("v1", "Service", "dev", "web", "dev-web"), gvr: services}, "dev-web"),
+		{Resource: newManagedObject("v1", "Secret", "dev", "web", "dev-web"), Operation: "failed", Error: errors.New("forbidden")},
+	}
+
+	rollbacks := deployer.Rollback(results, "dev-web")
+
+	// Rollbacks run in reverse order and skip resources that failed to apply
+	if len(rollbacks) != 2 || rollbacks[0].Operation != "deleted" || rollbacks[1].Operation != "restored" {
+		t.Fatalf("Rollback() = %+v, want the Service deleted and the ConfigMap restored", rollbacks)
+	}
+
+	for _, rollback := range rollbacks {
+		if rollback.Error != nil {
+			t.Errorf("Rollback() %s %s error = %v", rollback.Operation, rollback.Resource.GetKind(), rollback.Error)
+		}
+	}
+
+	restored, err := client.Resource(configMaps).Namespace("dev").Get(context.Background(), "settings", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if mode, _, _ := unstructured.NestedString(restored.Object, "data", "mode"); mode != "fast" {
+		t.Errorf("restored data.mode = %q, want fast", mode)
+	}
+
+	_, err = client.Resource(services).Namespace("dev").Get(context.Background(), "web", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("created Service still exists, Get() error = %v", err)
+	}
+}
+
+func TestDeployResultFailed(t *testing.T) {
+	tests := []struct {
+		result   DeployResult
+		expected bool
+	}{
+		{result: DeployResult{Status: "Available"}, expected: false},
+		{result: DeployResult{Status: "ready"}, expected: false},
+		{result: DeployResult{Status: "timeout"}, expected: true},
+		{result: DeployResult{Status: "Failed"}, expected: true},
+		{result: DeployResult{Status: "failed", Error: errors.New("conflict")}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.result.Status, func(t *testing.T) {
+			if failed := tt.result.Failed(); failed != tt.expected {
+				t.Errorf("Failed() = %v, want %v", failed, tt.expected)
+			}
+		})
+	}
+}
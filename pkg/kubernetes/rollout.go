@@ -0,0 +1,121 @@
+package kubernetes
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// getDeploymentStatus checks the rollout of a Deployment like `kubectl rollout status`. It is Available once
+// the controller has observed the latest generation and every replica runs the new pod template and is
+// available, and Failed when the rollout exceeded its progress deadline.
+func (d *Deployer) getDeploymentStatus(resource *unstructured.Unstructured) string {
+	status, _, _ := unstructured.NestedMap(resource.Object, "status")
+	if status == nil || !observedLatestGeneration(resource, status) {
+		return "Progressing"
+	}
+
+	progressing := findCondition(status, "Progressing")
+	if progressing["reason"] == "ProgressDeadlineExceeded" {
+		return "Failed"
+	}
+
+	replicas := nestedInt(resource.Object, 1, "spec", "replicas")
+	updated := nestedInt(status, 0, "updatedReplicas")
+
+	rolledOut := updated >= replicas && // Every replica was updated
+		nestedInt(status, 0, "replicas") <= updated && // Old replicas have terminated
+		nestedInt(status, 0, "availableReplicas") >= updated && // New replicas are available
+		(progressing == nil || progressing["reason"] == "NewReplicaSetAvailable")
+
+	if rolledOut {
+		return "Available"
+	}
+
+	return "Progressing"
+}
+
+// getStatefulSetStatus checks the rollout of a StatefulSet like `kubectl rollout status`. It is Ready once
+// the controller has observed the latest generation, every replica is ready and the pods run the update
+// revision, or only the pods above the partition for a partitioned rolling update.
+func (d *Deployer) getStatefulSetStatus(resource *unstructured.Unstructured) string {
+	status, _, _ := unstructured.NestedMap(resource.Object, "status")
+	if status == nil || !observedLatestGeneration(resource, status) {
+		return "Progressing"
+	}
+
+	replicas := nestedInt(resource.Object, 1, "spec", "replicas")
+	if nestedInt(status, 0, "readyReplicas") < replicas {
+		return "Progressing"
+	}
+
+	partition := nestedInt(resource.Object, 0, "spec", "updateStrategy", "rollingUpdate", "partition")
+	if partition > 0 {
+		if nestedInt(status, 0, "updatedReplicas") < replicas-partition {
+			return "Progressing"
+		}
+
+		return "Ready"
+	}
+
+	// Pods of an OnDelete StatefulSet are only updated when they are deleted
+	strategy, _, _ := unstructured.NestedString(resource.Object, "spec", "updateStrategy", "type")
+	if strategy != "OnDelete" && status["updateRevision"] != status["currentRevision"] {
+		return "Progressing"
+	}
+
+	return "Ready"
+}
+
+// getDaemonSetStatus checks the rollout of a DaemonSet like `kubectl rollout status`. It is Ready once the
+// controller has observed the latest generation and the updated pod is scheduled and available on every node.
+func (d *Deployer) getDaemonSetStatus(resource *unstructured.Unstructured) string {
+	status, _, _ := unstructured.NestedMap(resource.Object, "status")
+	if status == nil || !observedLatestGeneration(resource, status) {
+		return "Progressing"
+	}
+
+	desired := nestedInt(status, 0, "desiredNumberScheduled")
+
+	// Pods of an OnDelete DaemonSet are only updated when they are deleted
+	strategy, _, _ := unstructured.NestedString(resource.Object, "spec", "updateStrategy", "type")
+	if strategy != "OnDelete" && nestedInt(status, 0, "updatedNumberScheduled") < desired {
+		return "Progressing"
+	}
+
+	if nestedInt(status, 0, "numberAvailable") < desired {
+		return "Progressing"
+	}
+
+	return "Ready"
+}
+
+// observedLatestGeneration checks if the controller has observed the latest spec of a resource, so the
+// status describes the current generation rather than the one before the update.
+func observedLatestGeneration(resource *unstructured.Unstructured, status map[string]any) bool {
+	return nestedInt(status, 0, "observedGeneration") >= resource.GetGeneration()
+}
+
+// findCondition returns the status condition of a type, or nil when it isn't set.
+func findCondition(status map[string]any, conditionType string) map[string]any {
+	conditions, _, _ := unstructured.NestedSlice(status, "conditions")
+
+	for _, cond := range conditions {
+		condMap, ok := cond.(map[string]any)
+		if ok && condMap["type"] == conditionType {
+			return condMap
+		}
+	}
+
+	return nil
+}
+
+// nestedInt returns an integer field of an object, or the default when it isn't set.
+func nestedInt(obj map[string]any, defaultValue int64, fields ...string) int64 {
+	value, found, _ := unstructured.NestedFieldNoCopy(obj, fields...)
+
+	number, isNumber := toFloat(value)
+	if !found || !isNumber {
+		return defaultValue
+	}
+
+	return int64(number)
+}
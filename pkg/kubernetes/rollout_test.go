@@ -0,0 +1,202 @@
+package kubernetes
+
+import (
+	"log/slog"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	// rolledOutStatus is the status of a Deployment whose single replica runs the latest generation.
+	rolledOutStatus = map[string]any{
+		"observedGeneration": int64(1),
+		"replicas":           int64(1),
+		"updatedReplicas":    int64(1),
+		"availableReplicas":  int64(1),
+		"conditions": []any{
+			map[string]any{"type": "Available", "status": "True"},
+			map[string]any{"type": "Progressing", "status": "True", "reason": "NewReplicaSetAvailable"},
+		},
+	}
+
+	// progressingStatus is the status of a Deployment still replacing its old replica.
+	progressingStatus = map[string]any{
+		"observedGeneration": int64(1),
+		"replicas":           int64(2),
+		"updatedReplicas":    int64(1),
+		"availableReplicas":  int64(1),
+		"conditions": []any{
+			map[string]any{"type": "Available", "status": "True"},
+			map[string]any{"type": "Progressing", "status": "True", "reason": "ReplicaSetUpdated"},
+		},
+	}
+
+	// deadlineExceededStatus is the status of a Deployment whose rollout exceeded its progress deadline.
+	deadlineExceededStatus = map[string]any{
+		"observedGeneration": int64(1),
+		"conditions": []any{
+			map[string]any{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+		},
+	}
+)
+
+// newRollout creates a workload at generation 2 with the given spec and status.
+func newRollout(kind string, spec, status map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{"spec": spec, "status": status}}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind(kind)
+	obj.SetName("web")
+	obj.SetGeneration(2)
+
+	return obj
+}
+
+func TestRolloutStatus(t *testing.T) {
+	deployer := &Deployer{logger: slog.Default()}
+	onDelete := map[string]any{"type": "OnDelete"}
+
+	tests := []struct {
+		name     string
+		kind     string
+		spec     map[string]any
+		status   map[string]any
+		expected string
+	}{
+		{
+			name:     "deployment with old generation observed",
+			kind:     "Deployment",
+			status:   map[string]any{"observedGeneration": int64(1), "replicas": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(1)},
+			expected: "Progressing",
+		},
+		{
+			name:     "deployment rolled out",
+			kind:     "Deployment",
+			spec:     map[string]any{"replicas": int64(3)},
+			status:   map[string]any{"observedGeneration": int64(2), "replicas": int64(3), "updatedReplicas": int64(3), "availableReplicas": int64(3)},
+			expected: "Available",
+		},
+		{
+			name:     "deployment with replicas left to update",
+			kind:     "Deployment",
+			spec:     map[string]any{"replicas": int64(3)},
+			status:   map[string]any{"observedGeneration": int64(2), "replicas": int64(3), "updatedReplicas": int64(2), "availableReplicas": int64(3)},
+			expected: "Progressing",
+		},
+		{
+			name:     "deployment with old replicas terminating",
+			kind:     "Deployment",
+			status:   map[string]any{"observedGeneration": int64(2), "replicas": int64(2), "updatedReplicas": int64(1), "availableReplicas": int64(2)},
+			expected: "Progressing",
+		},
+		{
+			name:     "deployment with new replicas unavailable",
+			kind:     "Deployment",
+			status:   map[string]any{"observedGeneration": int64(2), "replicas": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(0)},
+			expected: "Progressing",
+		},
+		{
+			name: "deployment still progressing",
+			kind: "Deployment",
+			status: map[string]any{
+				"observedGeneration": int64(2), "replicas": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(1),
+				"conditions": []any{map[string]any{"type": "Progressing", "status": "True", "reason": "ReplicaSetUpdated"}},
+			},
+			expected: "Progressing",
+		},
+		{
+			name:     "deployment past its progress deadline",
+			kind:     "Deployment",
+			status:   map[string]any{"observedGeneration": int64(2), "conditions": deadlineExceededStatus["conditions"]},
+			expected: "Failed",
+		},
+		{
+			name:     "deployment scaled to zero",
+			kind:     "Deployment",
+			spec:     map[string]any{"replicas": int64(0)},
+			status:   map[string]any{"observedGeneration": int64(2)},
+			expected: "Available",
+		},
+		{
+			name:     "statefulset with old generation observed",
+			kind:     "StatefulSet",
+			status:   map[string]any{"observedGeneration": int64(1), "readyReplicas": int64(1)},
+			expected: "Progressing",
+		},
+		{
+			name:     "statefulset rolled out",
+			kind:     "StatefulSet",
+			status:   map[string]any{"observedGeneration": int64(2), "readyReplicas": int64(1), "currentRevision": "web-2", "updateRevision": "web-2"},
+			expected: "Ready",
+		},
+		{
+			name:     "statefulset with pods on the current revision",
+			kind:     "StatefulSet",
+			status:   map[string]any{"observedGeneration": int64(2), "readyReplicas": int64(1), "currentRevision": "web-1", "updateRevision": "web-2"},
+			expected: "Progressing",
+		},
+		{
+			name:     "statefulset with replicas not ready",
+			kind:     "StatefulSet",
+			spec:     map[string]any{"replicas": int64(3)},
+			status:   map[string]any{"observedGeneration": int64(2), "readyReplicas": int64(2)},
+			expected: "Progressing",
+		},
+		{
+			name: "statefulset with partition rolled out",
+			kind: "StatefulSet",
+			spec: map[string]any{"replicas": int64(3), "updateStrategy": map[string]any{"rollingUpdate": map[string]any{"partition": int64(2)}}},
+			status: map[string]any{
+				"observedGeneration": int64(2), "readyReplicas": int64(3), "updatedReplicas": int64(1),
+				"currentRevision": "web-1", "updateRevision": "web-2",
+			},
+			expected: "Ready",
+		},
+		{
+			name:     "statefulset updated on delete",
+			kind:     "StatefulSet",
+			spec:     map[string]any{"updateStrategy": onDelete},
+			status:   map[string]any{"observedGeneration": int64(2), "readyReplicas": int64(1), "currentRevision": "web-1", "updateRevision": "web-2"},
+			expected: "Ready",
+		},
+		{
+			name:     "daemonset rolled out",
+			kind:     "DaemonSet",
+			status:   map[string]any{"observedGeneration": int64(2), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(3)},
+			expected: "Ready",
+		},
+		{
+			name:     "daemonset with nodes left to update",
+			kind:     "DaemonSet",
+			status:   map[string]any{"observedGeneration": int64(2), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(2), "numberAvailable": int64(3)},
+			expected: "Progressing",
+		},
+		{
+			name:     "daemonset with pods unavailable",
+			kind:     "DaemonSet",
+			status:   map[string]any{"observedGeneration": int64(2), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(2)},
+			expected: "Progressing",
+		},
+		{
+			name:     "daemonset updated on delete",
+			kind:     "DaemonSet",
+			spec:     map[string]any{"updateStrategy": onDelete},
+			status:   map[string]any{"observedGeneration": int64(2), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(1), "numberAvailable": int64(3)},
+			expected: "Ready",
+		},
+		{
+			name:     "daemonset without status",
+			kind:     "DaemonSet",
+			expected: "Progressing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := deployer.getResourceStatus(newRollout(tt.kind, tt.spec, tt.status))
+			if status != tt.expected {
+				t.Errorf("getResourceStatus() = %q, want %q", status, tt.expected)
+			}
+		})
+	}
+}
@@ -2,9 +2,12 @@ package kubernetes
 
 import (
 	"log/slog"
+	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
@@ -13,20 +16,62 @@ import (
 type Deployer struct {
 	dynamicClient dynamic.Interface
 	clientset     kubernetes.Interface
+	mapper        meta.RESTMapper
 	logger        *slog.Logger
+	kubeContext   string
+	applyOptions  ApplyOptions
+	watches       *watchHub
+	watchesOnce   sync.Once
+}
+
+// ApplyOptions controls how resources are written to the cluster.
+type ApplyOptions struct {
+	// ServerSide applies resources with server-side apply instead of Get/Create/Update.
+	ServerSide bool
+	// ForceConflicts takes ownership of fields managed by other field managers.
+	// It only has an effect together with ServerSide.
+	ForceConflicts bool
 }
 
 // DeployResult represents the result of a single Kubernetes resource application.
 type DeployResult struct {
 	Resource  *unstructured.Unstructured
-	Operation string // "created", "applied", "unchanged"
-	Status    string // "Progressing", "Available", "Ready", "Complete", "Failed", "ReplicaFailure", "timeout"
+	Previous  *unstructured.Unstructured // The live object before the apply, nil if the resource was created
+	Operation string                     // "created", "applied", "no-change", "failed"
+	Status    string                     // "ready" when unchanged, "Available", "Ready", "Complete", "Failed", "ReplicaFailure", "failed", "timeout"
 	Error     error
+	Conflicts []FieldConflict // Fields owned by other managers when a server-side apply conflicts
 	Timestamp time.Time
+	gvr       schema.GroupVersionResource
+}
+
+// RollbackResult represents the result of rolling back a single resource of a failed stack.
+type RollbackResult struct {
+	Resource  *unstructured.Unstructured
+	Operation string // "restored", "deleted"
+	Error     error
+}
+
+// FieldConflict describes a single field that another field manager owns.
+type FieldConflict struct {
+	Field   string
+	Manager string
+	Message string
+}
+
+// preparedResource is a parsed manifest document ready to be applied.
+type preparedResource struct {
+	obj *unstructured.Unstructured
+	gvr schema.GroupVersionResource
+	// planned is set when the document comes from a saved plan, and resourceVersion is the
+	// version of the live object the plan observed, "" if it didn't exist.
+	planned         bool
+	resourceVersion string
 }
 
 // DeleteResult represents the result of a delete operation.
 type DeleteResult struct {
+	Context      string
 	StackName    string
 	ResourceType string
 	ResourceName string
@@ -0,0 +1,289 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// errWatchNotPermitted reports that the API server doesn't allow watching a resource type.
+var errWatchNotPermitted = errors.New("watch not permitted")
+
+// watchKey identifies the resources a shared watch covers.
+type watchKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+// watchHub shares one watch per resource type and namespace between every resource waiting to be ready,
+// so applying many resources doesn't open a watch, or poll, per resource.
+type watchHub struct {
+	client  dynamic.Interface
+	logger  *slog.Logger
+	mu      sync.Mutex
+	watches map[watchKey]*sharedWatch
+}
+
+// sharedWatch passes the events of a single watch to the waiters of each object.
+type sharedWatch struct {
+	cancel context.CancelFunc
+	// waiters maps object names to the channels of everything waiting for them.
+	waiters map[string][]chan *unstructured.Unstructured
+}
+
+// newWatchHub creates a watch hub without any watches, they are started when the first resource waits.
+func newWatchHub(client dynamic.Interface, logger *slog.Logger) *watchHub {
+	return &watchHub{
+		client:  client,
+		logger:  logger,
+		watches: make(map[watchKey]*sharedWatch),
+	}
+}
+
+// watchHub returns the deployer's watch hub, creating it on first use.
+func (d *Deployer) watchHub() *watchHub {
+	d.watchesOnce.Do(func() {
+		d.watches = newWatchHub(d.dynamicClient, d.logger)
+	})
+
+	return d.watches
+}
+
+// subscribe returns a channel receiving the latest state of an object every time it changes, and a function
+// to stop receiving. A new watch starts from resourceVersion, later subscribers join the running watch and
+// only receive the changes dispatched after they joined, which joined reports. The channel is closed when
+// the watch ends and can't be resumed.
+func (h *watchHub) subscribe(key watchKey, name, resourceVersion string) (<-chan *unstructured.Unstructured, func(), bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	shared, joined := h.watches[key]
+	if !joined {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		watcher, err := h.start(ctx, key, resourceVersion)
+		if err != nil {
+			cancel()
+
+			return nil, nil, false, err
+		}
+
+		shared = &sharedWatch{cancel: cancel, waiters: make(map[string][]chan *unstructured.Unstructured)}
+		h.watches[key] = shared
+
+		go h.run(ctx, key, shared, watcher, resourceVersion)
+	}
+
+	updates := make(chan *unstructured.Unstructured, 1)
+	shared.waiters[name] = append(shared.waiters[name], updates)
+
+	return updates, func() { h.unsubscribe(key, shared, name, updates) }, joined, nil
+}
+
+// unsubscribe removes a waiter and stops the watch once nothing waits on it anymore.
+func (h *watchHub) unsubscribe(key watchKey, shared *sharedWatch, name string, updates chan *unstructured.Unstructured) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	shared.waiters[name] = slices.DeleteFunc(shared.waiters[name], func(waiter chan *unstructured.Unstructured) bool {
+		return waiter == updates
+	})
+
+	if len(shared.waiters[name]) == 0 {
+		delete(shared.waiters, name)
+	}
+
+	if len(shared.waiters) == 0 && h.watches[key] == shared {
+		shared.cancel()
+		delete(h.watches, key)
+	}
+}
+
+// start opens a watch on every object of a resource type in a namespace from a resourceVersion.
+func (h *watchHub) start(ctx context.Context, key watchKey, resourceVersion string) (watch.Interface, error) {
+	watcher, err := h.client.Resource(key.gvr).Namespace(key.namespace).Watch(ctx, metav1.ListOptions{
+		ResourceVersion:     resourceVersion,
+		AllowWatchBookmarks: true,
+	})
+	if apierrors.IsForbidden(err) || apierrors.IsMethodNotSupported(err) {
+		return nil, fmt.Errorf("%w: %w", errWatchNotPermitted, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", key.gvr.Resource, err)
+	}
+
+	return watcher, nil
+}
+
+// run passes events to the waiters until the watch is stopped. When the API server ends the watch,
+// it is resumed from the last resourceVersion seen.
+func (h *watchHub) run(ctx context.Context, key watchKey, shared *sharedWatch, watcher watch.Interface, resourceVersion string) {
+	for {
+		resourceVersion = h.dispatch(ctx, shared, watcher, resourceVersion)
+		watcher.Stop()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		var err error
+
+		watcher, err = h.start(ctx, key, resourceVersion)
+		if err != nil {
+			h.logger.Debug("Failed to resume watch", "resource", key.gvr.Resource, "namespace", key.namespace, "error", err)
+			h.close(key, shared)
+
+			return
+		}
+	}
+}
+
+// dispatch passes the objects of a watch to their waiters until the watch ends, and returns the
+// resourceVersion to resume from.
+func (h *watchHub) dispatch(ctx context.Context, shared *sharedWatch, watcher watch.Interface, resourceVersion string) string {
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion
+			}
+
+			// The resourceVersion is too old to resume from, start again from the current state
+			if event.Type == watch.Error {
+				h.logger.Debug("Watch ended with an error", "error", apierrors.FromObject(event.Object))
+
+				return ""
+			}
+
+			resourceVersion = h.handle(shared, event, resourceVersion)
+		}
+	}
+}
+
+// handle passes the object of an event to its waiters and returns the resourceVersion to resume from.
+func (h *watchHub) handle(shared *sharedWatch, event watch.Event, resourceVersion string) string {
+	obj, isObject := event.Object.(*unstructured.Unstructured)
+	if !isObject {
+		return resourceVersion
+	}
+
+	if event.Type == watch.Added || event.Type == watch.Modified {
+		h.notify(shared, obj)
+	}
+
+	return obj.GetResourceVersion()
+}
+
+// notify sends the latest state of an object to everything waiting for it.
+func (h *watchHub) notify(shared *sharedWatch, obj *unstructured.Unstructured) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, updates := range shared.waiters[obj.GetName()] {
+		// Waiters only need the latest state, so replace an update they haven't read yet
+		select {
+		case <-updates:
+		default:
+		}
+
+		updates <- obj
+	}
+}
+
+// close stops a watch that can't be resumed and closes the channels of its waiters.
+func (h *watchHub) close(key watchKey, shared *sharedWatch) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	shared.cancel()
+
+	if h.watches[key] == shared {
+		delete(h.watches, key)
+	}
+
+	for name, waiters := range shared.waiters {
+		for _, updates := range waiters {
+			close(updates)
+		}
+
+		delete(shared.waiters, name)
+	}
+}
+
+// waitForReady waits until a created or updated resource is ready, failed or the timeout passes. It follows
+// the resource through a watch shared with the other resources of its type and namespace, resumed from the
+// resourceVersion the create or update returned, and polls instead when the API server doesn't permit watching.
+func (d *Deployer) waitForReady(gvr schema.GroupVersionResource, obj *unstructured.Unstructured, stackName string, timeout time.Duration) (string, error) {
+	namespace, name := obj.GetNamespace(), obj.GetName()
+	deadline := time.Now().Add(timeout)
+
+	updates, stop, joined, err := d.watchHub().subscribe(watchKey{gvr: gvr, namespace: namespace}, name, obj.GetResourceVersion())
+	if errors.Is(err, errWatchNotPermitted) {
+		d.logger.Debug("Watch not permitted, polling instead", "stack", stackName, "name", name, "error", err)
+
+		return d.pollForCompletion(gvr, namespace, name, stackName, timeout)
+	}
+
+	if err != nil {
+		return "failed", err
+	}
+
+	defer stop()
+
+	d.logger.Warn("Waiting for resource to be ready", "stack", stackName, "name", name, "namespace", namespace)
+
+	// A running watch may have passed changes between the create or update and joining it, so start
+	// from the current state; later changes arrive through the watch
+	if joined {
+		live, err := d.GetResource(gvr, namespace, name)
+		if err == nil {
+			obj = live
+		}
+	}
+
+	return d.followUpdates(gvr, obj, updates, stackName, deadline)
+}
+
+// followUpdates checks the status of a resource every time it changes until it is ready, failed or the
+// deadline passes. When the updates end early, it polls for the rest of the time.
+func (d *Deployer) followUpdates(gvr schema.GroupVersionResource, obj *unstructured.Unstructured, updates <-chan *unstructured.Unstructured, stackName string, deadline time.Time) (string, error) {
+	namespace, name := obj.GetNamespace(), obj.GetName()
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	// The object returned by the create or update may already be ready
+	current := obj
+
+	for {
+		status, err := d.handleResourceStatus(d.getResourceStatus(current), stackName, name, namespace)
+		if err != nil || status != "" {
+			return status, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "timeout", errors.New("timeout waiting for resource to be ready")
+		case next, ok := <-updates:
+			if !ok {
+				return d.pollForCompletion(gvr, namespace, name, stackName, time.Until(deadline))
+			}
+
+			current = next
+		}
+	}
+}
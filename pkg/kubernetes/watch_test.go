@@ -0,0 +1,267 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+// newWatchedDeployment creates a Deployment at a resourceVersion with the given status.
+func newWatchedDeployment(name, resourceVersion string, status map[string]any) *unstructured.Unstructured {
+	obj := withStatus(newManagedObject("apps/v1", "Deployment", "dev", name, "dev-web"), status)
+	obj.SetResourceVersion(resourceVersion)
+
+	return obj
+}
+
+// receive returns the next update from a subscription, failing the test if none arrives.
+func receive(t *testing.T, updates <-chan *unstructured.Unstructured) *unstructured.Unstructured {
+	t.Helper()
+
+	select {
+	case obj := <-updates:
+		return obj
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update received")
+
+		return nil
+	}
+}
+
+// serveWatch makes the fake client answer Deployment watches with the given events, and returns the
+// resourceVersion the watch started from.
+func serveWatch(client *dynamicfake.FakeDynamicClient, events ...*unstructured.Unstructured) *string {
+	watcher := watch.NewRaceFreeFake()
+	for _, event := range events {
+		watcher.Modify(event)
+	}
+
+	var resourceVersion string
+
+	client.PrependWatchReactor("deployments", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		resourceVersion = action.(k8stesting.WatchAction).GetWatchRestrictions().ResourceVersion
+
+		return true, watcher, nil
+	})
+
+	return &resourceVersion
+}
+
+// countWatches returns how many watches the fake client was asked to open.
+func countWatches(client *dynamicfake.FakeDynamicClient) int {
+	watches := 0
+
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "watch" {
+			watches++
+		}
+	}
+
+	return watches
+}
+
+func TestWaitForReady(t *testing.T) {
+	tests := []struct {
+		name        string
+		initial     *unstructured.Unstructured
+		events      []*unstructured.Unstructured
+		timeout     time.Duration
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "ready after update",
+			initial:  newWatchedDeployment("web", "7", progressingStatus),
+			events:   []*unstructured.Unstructured{newWatchedDeployment("web", "8", progressingStatus), newWatchedDeployment("web", "9", rolledOutStatus)},
+			timeout:  5 * time.Second,
+			expected: "Available",
+		},
+		{
+			name:        "failed after update",
+			initial:     newWatchedDeployment("web", "7", progressingStatus),
+			events:      []*unstructured.Unstructured{newWatchedDeployment("web", "8", deadlineExceededStatus)},
+			timeout:     5 * time.Second,
+			expected:    "Failed",
+			expectError: true,
+		},
+		{
+			name:     "already ready",
+			initial:  newWatchedDeployment("web", "7", rolledOutStatus),
+			timeout:  5 * time.Second,
+			expected: "Available",
+		},
+		{
+			name:        "other objects don't count",
+			initial:     newWatchedDeployment("web", "7", progressingStatus),
+			events:      []*unstructured.Unstructured{newWatchedDeployment("api", "8", rolledOutStatus)},
+			timeout:     100 * time.Millisecond,
+			expected:    "timeout",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployer, client := newPruneTestDeployer()
+
+			resourceVersion := serveWatch(client, tt.events...)
+
+			status, err := deployer.waitForReady(deploymentsGVR, tt.initial, "dev-web", tt.timeout)
+			if status != tt.expected || (err != nil) != tt.expectError {
+				t.Errorf("waitForReady() = %q, %v, want %q", status, err, tt.expected)
+			}
+
+			if *resourceVersion != "7" {
+				t.Errorf("watch resourceVersion = %q, want the resourceVersion of the update", *resourceVersion)
+			}
+		})
+	}
+}
+
+func TestWaitForReadyPollsWhenWatchForbidden(t *testing.T) {
+	interval := pollInterval
+	pollInterval = 10 * time.Millisecond
+
+	t.Cleanup(func() { pollInterval = interval })
+
+	live := newWatchedDeployment("web", "8", rolledOutStatus)
+	deployer, client := newPruneTestDeployer(live)
+
+	client.PrependWatchReactor("deployments", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, nil, apierrors.NewForbidden(deploymentsGVR.GroupResource(), "", errors.New("watch is not allowed"))
+	})
+
+	status, err := deployer.waitForReady(deploymentsGVR, newWatchedDeployment("web", "7", progressingStatus), "dev-web", 5*time.Second)
+	if status != "Available" || err != nil {
+		t.Errorf("waitForReady() = %q, %v, want Available", status, err)
+	}
+}
+
+func TestWatchHubSharesWatch(t *testing.T) {
+	deployer, client := newPruneTestDeployer(newWatchedDeployment("web", "", progressingStatus), newWatchedDeployment("api", "", progressingStatus))
+	hub := deployer.watchHub()
+	key := watchKey{gvr: deploymentsGVR, namespace: "dev"}
+
+	web, stopWeb, _, err := hub.subscribe(key, "web", "")
+	if err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+
+	api, stopAPI, joined, err := hub.subscribe(key, "api", "")
+	if err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+
+	if !joined {
+		t.Errorf("subscribe() didn't join the running watch")
+	}
+
+	if watches := countWatches(client); watches != 1 {
+		t.Errorf("watches = %d, want one shared watch", watches)
+	}
+
+	updated := newWatchedDeployment("web", "", rolledOutStatus)
+
+	_, err = client.Resource(deploymentsGVR).Namespace("dev").Update(context.Background(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if status := deployer.getResourceStatus(receive(t, web)); status != "Available" {
+		t.Errorf("web status = %q, want Available", status)
+	}
+
+	select {
+	case obj := <-api:
+		t.Errorf("api received an update of %s", obj.GetName())
+	default:
+	}
+
+	stopWeb()
+	stopAPI()
+
+	if len(hub.watches) != 0 {
+		t.Errorf("watches = %d after every waiter stopped, want 0", len(hub.watches))
+	}
+}
+
+func TestWatchHubResumesWatch(t *testing.T) {
+	deployer, client := newPruneTestDeployer()
+
+	// The first watch ends, the second expires and the third stays open
+	ended := watch.NewRaceFreeFake()
+	ended.Modify(newWatchedDeployment("web", "5", progressingStatus))
+	ended.Stop()
+
+	expired := watch.NewRaceFreeFake()
+	expired.Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+
+	watchers := []watch.Interface{ended, expired, watch.NewRaceFreeFake()}
+	resourceVersions := make(chan string, len(watchers))
+
+	client.PrependWatchReactor("deployments", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		watcher := watchers[len(resourceVersions)]
+		resourceVersions <- action.(k8stesting.WatchAction).GetWatchRestrictions().ResourceVersion
+
+		return true, watcher, nil
+	})
+
+	updates, stop, _, err := deployer.watchHub().subscribe(watchKey{gvr: deploymentsGVR, namespace: "dev"}, "web", "3")
+	if err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+	defer stop()
+
+	if obj := receive(t, updates); obj.GetResourceVersion() != "5" {
+		t.Errorf("update resourceVersion = %q, want 5", obj.GetResourceVersion())
+	}
+
+	// Resume from the last event, then from the current state once the resourceVersion expired
+	for _, expected := range []string{"3", "5", ""} {
+		select {
+		case resourceVersion := <-resourceVersions:
+			if resourceVersion != expected {
+				t.Errorf("watch resourceVersion = %q, want %q", resourceVersion, expected)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("watch from resourceVersion %q not started", expected)
+		}
+	}
+}
+
+func TestWaitForReadyJoinsRunningWatch(t *testing.T) {
+	deployer, client := newPruneTestDeployer(newWatchedDeployment("web", "9", rolledOutStatus))
+
+	watcher := watch.NewRaceFreeFake()
+	client.PrependWatchReactor("deployments", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, watcher, nil
+	})
+
+	// Another resource keeps the watch running
+	api, stop, _, err := deployer.watchHub().subscribe(watchKey{gvr: deploymentsGVR, namespace: "dev"}, "api", "")
+	if err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+	defer stop()
+
+	// web becomes ready before it waits, so the watch passes the change to nobody
+	watcher.Modify(newWatchedDeployment("web", "9", rolledOutStatus))
+	watcher.Modify(newWatchedDeployment("api", "10", progressingStatus))
+	receive(t, api)
+
+	status, err := deployer.waitForReady(deploymentsGVR, newWatchedDeployment("web", "8", progressingStatus), "dev-web", 200*time.Millisecond)
+	if status != "Available" || err != nil {
+		t.Errorf("waitForReady() = %q, %v, want Available", status, err)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package plan
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// FieldChange describes a single field that differs between the current and desired state.
+type FieldChange struct {
+	Path      string `json:"path"             yaml:"path"`
+	Operation string `json:"operation"        yaml:"operation"` // "add", "remove", "change"
+	Before    any    `json:"before,omitempty" yaml:"before,omitempty"`
+	After     any    `json:"after,omitempty"  yaml:"after,omitempty"`
+}
+
+// fieldChanges lists the field-level changes between two states, sorted by path.
+func fieldChanges(current, desired any) []FieldChange {
+	var changes []FieldChange
+
+	collectChanges("", current, desired, &changes)
+
+	return changes
+}
+
+// collectChanges compares two values at the given path and records their differences.
+func collectChanges(path string, before, after any, changes *[]FieldChange) {
+	beforeMap, beforeIsMap := before.(map[string]any)
+	afterMap, afterIsMap := after.(map[string]any)
+
+	if beforeIsMap && afterIsMap {
+		collectMapChanges(path, beforeMap, afterMap, changes)
+
+		return
+	}
+
+	beforeList, beforeIsList := before.([]any)
+	afterList, afterIsList := after.([]any)
+
+	if beforeIsList && afterIsList && len(beforeList) == len(afterList) {
+		for i := range beforeList {
+			collectChanges(fmt.Sprintf("%s[%d]", path, i), beforeList[i], afterList[i], changes)
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, FieldChange{Path: path, Operation: "change", Before: before, After: after})
+	}
+}
+
+// collectMapChanges compares two maps key by key.
+func collectMapChanges(path string, before, after map[string]any, changes *[]FieldChange) {
+	keys := slices.Collect(maps.Keys(before))
+	for key := range after {
+		if _, exists := before[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		beforeValue, inBefore := before[key]
+		afterValue, inAfter := after[key]
+		fieldPath := joinFieldPath(path, key)
+
+		switch {
+		case !inBefore:
+			*changes = append(*changes, FieldChange{Path: fieldPath, Operation: "add", After: afterValue})
+		case !inAfter:
+			*changes = append(*changes, FieldChange{Path: fieldPath, Operation: "remove", Before: beforeValue})
+		default:
+			collectChanges(fieldPath, beforeValue, afterValue, changes)
+		}
+	}
+}
+
+// joinFieldPath appends a map key to a field path, quoting keys that contain dots or slashes.
+func joinFieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package plan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  any
+		desired  any
+		expected []FieldChange
+	}{
+		{
+			name:     "identical",
+			current:  map[string]any{"spec": map[string]any{"replicas": 1}},
+			desired:  map[string]any{"spec": map[string]any{"replicas": 1}},
+			expected: nil,
+		},
+		{
+			name:    "changed scalar",
+			current: map[string]any{"spec": map[string]any{"replicas": 1}},
+			desired: map[string]any{"spec": map[string]any{"replicas": 3}},
+			expected: []FieldChange{
+				{Path: ".spec.replicas", Operation: "change", Before: 1, After: 3},
+			},
+		},
+		{
+			name:    "added and removed keys in sorted order",
+			current: map[string]any{"data": map[string]any{"b": "old", "c": "same"}},
+			desired: map[string]any{"data": map[string]any{"a": "new", "c": "same"}},
+			expected: []FieldChange{
+				{Path: ".data.a", Operation: "add", After: "new"},
+				{Path: ".data.b", Operation: "remove", Before: "old"},
+			},
+		},
+		{
+			name:    "list element",
+			current: map[string]any{"ports": []any{map[string]any{"port": 80}}},
+			desired: map[string]any{"ports": []any{map[string]any{"port": 8080}}},
+			expected: []FieldChange{
+				{Path: ".ports[0].port", Operation: "change", Before: 80, After: 8080},
+			},
+		},
+		{
+			name:    "list length change",
+			current: map[string]any{"args": []any{"a"}},
+			desired: map[string]any{"args": []any{"a", "b"}},
+			expected: []FieldChange{
+				{Path: ".args", Operation: "change", Before: []any{"a"}, After: []any{"a", "b"}},
+			},
+		},
+		{
+			name:    "quoted key",
+			current: map[string]any{"labels": map[string]any{"app.kubernetes.io/name": "a"}},
+			desired: map[string]any{"labels": map[string]any{"app.kubernetes.io/name": "b"}},
+			expected: []FieldChange{
+				{Path: `.labels["app.kubernetes.io/name"]`, Operation: "change", Before: "a", After: "b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fieldChanges(tt.current, tt.desired)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, result)
+			}
+		})
+	}
+}
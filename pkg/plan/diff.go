@@ -0,0 +1,252 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package plan
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultContextLines is the number of unchanged lines shown around each change.
+const DefaultContextLines = 3
+
+// diffOp is the kind of edit applied to a line.
+type diffOp int
+
+const (
+	opEqual diffOp = iota
+	opDelete
+	opInsert
+)
+
+// diffLine is a single line of an edit script.
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// hunk is a group of changes with surrounding context lines.
+type hunk struct {
+	oldStart int
+	oldCount int
+	newStart int
+	newCount int
+	lines    []diffLine
+}
+
+// unifiedDiff renders a unified diff with @@ hunk headers between two texts.
+// It returns an empty string when the texts are identical.
+func unifiedDiff(oldContent, newContent string, contextLines int) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	lines := diffLines(splitLines(oldContent), splitLines(newContent))
+
+	var diff strings.Builder
+
+	diff.WriteString("--- current\n+++ desired\n")
+
+	for _, h := range buildHunks(lines, max(contextLines, 0)) {
+		writeHunk(&diff, h)
+	}
+
+	return diff.String()
+}
+
+// splitLines splits text into lines, ignoring a single trailing newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes the shortest edit script that turns a into b using the Myers algorithm.
+func diffLines(a, b []string) []diffLine {
+	trace := myersTrace(a, b)
+
+	return backtrack(trace, a, b)
+}
+
+// myersTrace runs the forward pass of the Myers algorithm and records the
+// furthest reaching x for every diagonal before each edit distance d.
+func myersTrace(a, b []string) [][]int {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, slices.Clone(v))
+
+		for k := -d; k <= d; k += 2 {
+			x, y := followDiagonal(a, b, nextX(v, offset, k, d), k)
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return trace
+			}
+		}
+	}
+
+	return trace
+}
+
+// nextX picks the starting x on diagonal k, moving down (insert) or right (delete)
+// from whichever neighbouring diagonal reached further.
+func nextX(v []int, offset, k, d int) int {
+	if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+		return v[offset+k+1]
+	}
+
+	return v[offset+k-1] + 1
+}
+
+// followDiagonal follows the diagonal k of equal lines as far as possible starting at x.
+func followDiagonal(a, b []string, x, k int) (int, int) {
+	y := x - k
+
+	for x < len(a) && y < len(b) && a[x] == b[y] {
+		x++
+		y++
+	}
+
+	return x, y
+}
+
+// backtrack walks the recorded trace from the end to recover the edit script.
+func backtrack(trace [][]int, a, b []string) []diffLine {
+	x, y := len(a), len(b)
+	offset := len(a) + len(b) + 1
+
+	var lines []diffLine
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		prevK := k - 1
+
+		if k == -d || (k != d && trace[d][offset+k-1] < trace[d][offset+k+1]) {
+			prevK = k + 1
+		}
+
+		prevX := trace[d][offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, diffLine{op: opEqual, text: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			lines = append(lines, editLine(a, b, x, y, prevX))
+		}
+
+		x, y = prevX, prevY
+	}
+
+	slices.Reverse(lines)
+
+	return lines
+}
+
+// editLine returns the insert or delete that led from the previous point to (x, y).
+func editLine(a, b []string, x, y, prevX int) diffLine {
+	if x == prevX {
+		return diffLine{op: opInsert, text: b[y-1]}
+	}
+
+	return diffLine{op: opDelete, text: a[x-1]}
+}
+
+// buildHunks groups the changed lines of an edit script into hunks with context lines.
+func buildHunks(lines []diffLine, contextLines int) []hunk {
+	// Line numbers in the old and new text before each entry of the edit script
+	oldPos := make([]int, len(lines)+1)
+	newPos := make([]int, len(lines)+1)
+
+	for i, line := range lines {
+		oldPos[i+1] = oldPos[i]
+		newPos[i+1] = newPos[i]
+
+		if line.op != opInsert {
+			oldPos[i+1]++
+		}
+
+		if line.op != opDelete {
+			newPos[i+1]++
+		}
+	}
+
+	ranges := changeRanges(lines, contextLines)
+	hunks := make([]hunk, 0, len(ranges))
+
+	for _, r := range ranges {
+		hunks = append(hunks, hunk{
+			oldStart: oldPos[r[0]],
+			oldCount: oldPos[r[1]] - oldPos[r[0]],
+			newStart: newPos[r[0]],
+			newCount: newPos[r[1]] - newPos[r[0]],
+			lines:    lines[r[0]:r[1]],
+		})
+	}
+
+	return hunks
+}
+
+// changeRanges returns the [start, end) ranges of the edit script that make up each hunk,
+// merging changes whose context lines overlap.
+func changeRanges(lines []diffLine, contextLines int) [][2]int {
+	var ranges [][2]int
+
+	for i, line := range lines {
+		if line.op == opEqual {
+			continue
+		}
+
+		start := max(i-contextLines, 0)
+		end := min(i+contextLines+1, len(lines))
+
+		if n := len(ranges); n > 0 && start <= ranges[n-1][1] {
+			ranges[n-1][1] = max(ranges[n-1][1], end)
+
+			continue
+		}
+
+		ranges = append(ranges, [2]int{start, end})
+	}
+
+	return ranges
+}
+
+// writeHunk writes a hunk header and its lines.
+func writeHunk(diff *strings.Builder, h hunk) {
+	fmt.Fprintf(diff, "@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldCount), hunkRange(h.newStart, h.newCount))
+
+	for _, line := range h.lines {
+		switch line.op {
+		case opEqual:
+			fmt.Fprintf(diff, " %s\n", line.text)
+		case opDelete:
+			fmt.Fprintf(diff, "-%s\n", line.text)
+		case opInsert:
+			fmt.Fprintf(diff, "+%s\n", line.text)
+		}
+	}
+}
+
+// hunkRange formats the line range of a hunk side. Lines are 1-based; an empty
+// range refers to the line before it, as in GNU diff.
+func hunkRange(linesBefore, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", linesBefore)
+	}
+
+	return fmt.Sprintf("%d,%d", linesBefore+1, count)
+}
@@ -0,0 +1,126 @@
+package plan
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name         string
+		old          string
+		new          string
+		contextLines int
+		expected     string
+	}{
+		{
+			name:         "identical content",
+			old:          "a\nb\nc\n",
+			new:          "a\nb\nc\n",
+			contextLines: 3,
+			expected:     "",
+		},
+		{
+			name:         "inserted line does not shift the rest",
+			old:          "a\nb\nc\nd\n",
+			new:          "a\nb\nx\nc\nd\n",
+			contextLines: 1,
+			expected:     "--- current\n+++ desired\n@@ -2,2 +2,3 @@\n b\n+x\n c\n",
+		},
+		{
+			name:         "changed line",
+			old:          "a\nb\nc\n",
+			new:          "a\nB\nc\n",
+			contextLines: 3,
+			expected:     "--- current\n+++ desired\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:         "distant changes produce separate hunks",
+			old:          "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:          "one\n2\n3\n4\n5\n6\n7\n8\nnine\n",
+			contextLines: 1,
+			expected:     "--- current\n+++ desired\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -8,2 +8,2 @@\n 8\n-9\n+nine\n",
+		},
+		{
+			name:         "nearby changes share a hunk",
+			old:          "1\n2\n3\n4\n5\n",
+			new:          "one\n2\n3\n4\nfive\n",
+			contextLines: 2,
+			expected:     "--- current\n+++ desired\n@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+		{
+			name:         "new content",
+			old:          "",
+			new:          "a\nb\n",
+			contextLines: 3,
+			expected:     "--- current\n+++ desired\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:         "removed content",
+			old:          "a\nb\n",
+			new:          "",
+			contextLines: 3,
+			expected:     "--- current\n+++ desired\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:         "no context lines",
+			old:          "a\nb\nc\n",
+			new:          "a\nx\nc\n",
+			contextLines: 0,
+			expected:     "--- current\n+++ desired\n@@ -2,1 +2,1 @@\n-b\n+x\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := unifiedDiff(tt.old, tt.new, tt.contextLines)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	old := []string{"a", "b", "c", "a", "b", "b", "a"}
+	desired := []string{"c", "b", "a", "b", "a", "c"}
+
+	lines := diffLines(old, desired)
+
+	edits := 0
+
+	var rebuiltOld, rebuiltNew []string
+
+	for _, line := range lines {
+		if line.op != opEqual {
+			edits++
+		}
+
+		if line.op != opInsert {
+			rebuiltOld = append(rebuiltOld, line.text)
+		}
+
+		if line.op != opDelete {
+			rebuiltNew = append(rebuiltNew, line.text)
+		}
+	}
+
+	// The classic Myers example needs exactly five edits
+	if edits != 5 {
+		t.Errorf("Expected 5 edits, got %d", edits)
+	}
+
+	validateLines(t, "old", rebuiltOld, old)
+	validateLines(t, "new", rebuiltNew, desired)
+}
+
+func validateLines(t *testing.T, side string, got, expected []string) {
+	if len(got) != len(expected) {
+		t.Fatalf("Rebuilt %s side has %d lines, want %d", side, len(got), len(expected))
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Rebuilt %s line %d = %q, want %q", side, i, got[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Saved plan files are identified by their kind and format version.
+const (
+	FileKind    = "FrankPlan"
+	FileVersion = 1
+)
+
+// File is a saved plan that frank apply can execute exactly as it was reviewed.
+type File struct {
+	Kind      string      `yaml:"kind"`
+	Version   int         `yaml:"version"`
+	CreatedAt time.Time   `yaml:"created_at"`
+	Prune     bool        `yaml:"prune,omitempty"`
+	Stacks    []StackPlan `yaml:"stacks"`
+}
+
+// StackPlan is the saved plan for a single stack, including its rendered manifests.
+type StackPlan struct {
+	StackName string             `yaml:"stack_name"`
+	Context   string             `yaml:"context"`
+	Namespace string             `yaml:"namespace,omitempty"`
+	Manifest  string             `yaml:"manifest"`
+	Timeout   time.Duration      `yaml:"timeout,omitempty"`
+	OnFailure string             `yaml:"on_failure,omitempty"`
+	DependsOn []string           `yaml:"depends_on,omitempty"`
+	Content   string             `yaml:"content"`
+	Resources []ObservedResource `yaml:"resources"`
+}
+
+// ObservedResource records the state of a live object when the plan was taken.
+type ObservedResource struct {
+	APIVersion      string `yaml:"api_version"`
+	Kind            string `yaml:"kind"`
+	Name            string `yaml:"name"`
+	Namespace       string `yaml:"namespace,omitempty"`
+	Operation       string `yaml:"operation"`
+	ResourceVersion string `yaml:"resource_version,omitempty"` // Empty if the object didn't exist
+}
+
+// String formats the resource as Kind/namespace/name.
+func (r ObservedResource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// NewFile creates a saved plan from plan results. Plans with errors can't be saved.
+func NewFile(results []PlanResult, prune bool) (*File, error) {
+	file := &File{
+		Kind:      FileKind,
+		Version:   FileVersion,
+		CreatedAt: time.Now().UTC(),
+		Prune:     prune,
+		Stacks:    make([]StackPlan, 0, len(results)),
+	}
+
+	for _, result := range results {
+		if result.Error != nil {
+			return nil, fmt.Errorf("cannot save plan, stack %s failed to plan: %w", result.StackName, result.Error)
+		}
+
+		file.Stacks = append(file.Stacks, newStackPlan(result))
+	}
+
+	return file, nil
+}
+
+// newStackPlan converts the plan result of a stack into its saved form.
+func newStackPlan(result PlanResult) StackPlan {
+	resources := make([]ObservedResource, 0, len(result.Resources))
+
+	for _, resource := range result.Resources {
+		resources = append(resources, ObservedResource{
+			APIVersion:      resource.APIVersion,
+			Kind:            resource.Kind,
+			Name:            resource.Name,
+			Namespace:       resource.Namespace,
+			Operation:       resource.Operation,
+			ResourceVersion: resource.ResourceVersion,
+		})
+	}
+
+	return StackPlan{
+		StackName: result.StackName,
+		Context:   result.Context,
+		Namespace: result.Namespace,
+		Manifest:  result.Manifest,
+		Timeout:   result.Timeout,
+		OnFailure: result.OnFailure,
+		DependsOn: result.DependsOn,
+		Content:   result.ManifestContent,
+		Resources: resources,
+	}
+}
+
+// Write saves the plan to a file.
+func (f *File) Write(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("error encoding plan: %w", err)
+	}
+
+	err = os.WriteFile(path, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("error writing plan file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadFile reads a saved plan from a file.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan file: %w", err)
+	}
+
+	var file File
+
+	err = yaml.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing plan file: %w", err)
+	}
+
+	if file.Kind != FileKind {
+		return nil, fmt.Errorf("%s is not a frank plan file", path)
+	}
+
+	if file.Version != FileVersion {
+		return nil, fmt.Errorf("unsupported plan file version %d (expected %d)", file.Version, FileVersion)
+	}
+
+	if len(file.Stacks) == 0 {
+		return nil, errors.New("plan file contains no stacks")
+	}
+
+	return &file, nil
+}
+
+// IsFile reports whether the path points to a saved plan file.
+func IsFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var header struct {
+		Kind string `yaml:"kind"`
+	}
+
+	err = yaml.Unmarshal(data, &header)
+
+	return err == nil && header.Kind == FileKind
+}
+
+// DependencyGraph returns the saved stacks and their dependencies in the order they were planned.
+func (f *File) DependencyGraph() *stack.DependencyGraph {
+	graph := &stack.DependencyGraph{
+		Stacks:    make([]*stack.StackInfo, 0, len(f.Stacks)),
+		DependsOn: make(map[string][]string, len(f.Stacks)),
+	}
+
+	for _, stackPlan := range f.Stacks {
+		graph.Stacks = append(graph.Stacks, &stack.StackInfo{
+			Name:      stackPlan.StackName,
+			Context:   stackPlan.Context,
+			Namespace: stackPlan.Namespace,
+		})
+		graph.DependsOn[stackPlan.StackName] = stackPlan.DependsOn
+	}
+
+	return graph
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package plan
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileRoundTrip(t *testing.T) {
+	results := []PlanResult{
+		{
+			Context:         "dev",
+			StackName:       "dev-web",
+			Manifest:        "web.yaml",
+			Namespace:       "web",
+			Timeout:         5 * time.Minute,
+			OnFailure:       "rollback",
+			DependsOn:       []string{"dev-db"},
+			ManifestContent: "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+			Resources: []ResourcePlan{
+				{APIVersion: "v1", Kind: "Service", Name: "web", Namespace: "web", Operation: "update", ResourceVersion: "42", Diff: "ignored"},
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "old", Namespace: "web", Operation: "delete", ResourceVersion: "7"},
+			},
+		},
+	}
+
+	file, err := NewFile(results, true)
+	if err != nil {
+		t.Fatalf("NewFile() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.frank")
+
+	err = file.Write(path)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if !IsFile(path) {
+		t.Fatalf("Expected %s to be detected as a plan file", path)
+	}
+
+	loaded, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	validateLoadedPlanFile(t, loaded)
+}
+
+func validateLoadedPlanFile(t *testing.T, loaded *File) {
+	if !loaded.Prune || len(loaded.Stacks) != 1 {
+		t.Fatalf("Unexpected plan file: %+v", loaded)
+	}
+
+	stackPlan := loaded.Stacks[0]
+	if stackPlan.Content != "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n" {
+		t.Errorf("Manifest content was not preserved: %q", stackPlan.Content)
+	}
+
+	if stackPlan.Timeout != 5*time.Minute || stackPlan.Namespace != "web" || stackPlan.OnFailure != "rollback" {
+		t.Errorf("Stack settings were not preserved: %+v", stackPlan)
+	}
+
+	validateObservedResources(t, stackPlan.Resources)
+
+	graph := loaded.DependencyGraph()
+	if len(graph.Stacks) != 1 || graph.DependsOn["dev-web"][0] != "dev-db" {
+		t.Errorf("Unexpected dependency graph: %+v", graph)
+	}
+}
+
+func validateObservedResources(t *testing.T, resources []ObservedResource) {
+	if len(resources) != 2 {
+		t.Fatalf("Expected 2 observed resources, got %+v", resources)
+	}
+
+	if resources[0].ResourceVersion != "42" || resources[1].Operation != "delete" {
+		t.Errorf("Observed resources were not preserved: %+v", resources)
+	}
+}
+
+func TestNewFileRejectsFailedPlans(t *testing.T) {
+	results := []PlanResult{{StackName: "dev-web", Error: errors.New("template error")}}
+
+	_, err := NewFile(results, false)
+	if err == nil {
+		t.Error("Expected error when saving a plan with errors")
+	}
+}
+
+func TestIsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "app.yaml")
+
+	err := os.WriteFile(configPath, []byte("manifest: app.yaml\n"), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{name: "stack filter", path: "dev/app", expected: false},
+		{name: "directory", path: dir, expected: false},
+		{name: "config file", path: configPath, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if IsFile(tt.path) != tt.expected {
+				t.Errorf("Expected IsFile(%q) to be %v", tt.path, tt.expected)
+			}
+		})
+	}
+}
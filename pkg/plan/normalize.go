@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package plan
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// serverOwnedFields lists fields the API server sets that frank never writes, and the annotation
+// in which frank records the manifest it last applied.
+var serverOwnedFields = [][]string{
+	{"status"},
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "uid"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "generation"},
+	{"metadata", "selfLink"},
+	{"metadata", "deletionTimestamp"},
+	{"metadata", "deletionGracePeriodSeconds"},
+	{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"},
+	{"metadata", "annotations", "frankthetank.cloud/last-applied-configuration"},
+	{"metadata", "annotations", "deployment.kubernetes.io/revision"},
+}
+
+// normalizeObject returns a copy of the object without server-owned fields.
+func normalizeObject(obj *unstructured.Unstructured) map[string]any {
+	normalized := obj.DeepCopy()
+
+	for _, field := range serverOwnedFields {
+		unstructured.RemoveNestedField(normalized.Object, field...)
+	}
+
+	// Drop maps that only held server-owned entries
+	if len(normalized.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(normalized.Object, "metadata", "annotations")
+	}
+
+	return normalized.Object
+}
+
+// projectOnto keeps only the parts of the live value that are also set in the desired value,
+// so fields defaulted by the server or written by other controllers are not reported as changes.
+func projectOnto(live, desired any) any {
+	switch want := desired.(type) {
+	case map[string]any:
+		return projectMap(live, want)
+	case []any:
+		return projectList(live, want)
+	default:
+		return live
+	}
+}
+
+// projectMap projects a live map onto the keys of the desired map.
+func projectMap(live any, desired map[string]any) any {
+	have, ok := live.(map[string]any)
+	if !ok {
+		return live
+	}
+
+	projected := make(map[string]any, len(desired))
+
+	for key, value := range desired {
+		if liveValue, exists := have[key]; exists {
+			projected[key] = projectOnto(liveValue, value)
+		}
+	}
+
+	return projected
+}
+
+// projectList projects each element of a live list onto the matching desired element.
+// Lists of different lengths differ anyway and are kept as they are.
+func projectList(live any, desired []any) any {
+	have, ok := live.([]any)
+	if !ok || len(have) != len(desired) {
+		return live
+	}
+
+	projected := make([]any, len(have))
+
+	for i := range have {
+		projected[i] = projectOnto(have[i], desired[i])
+	}
+
+	return projected
+}
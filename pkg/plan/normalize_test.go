@@ -0,0 +1,231 @@
+package plan
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+	"github.com/schnauzersoft/frank-cli/pkg/template"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNormalizeObject(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":              "settings",
+				"namespace":         "dev",
+				"uid":               "1234",
+				"resourceVersion":   "42",
+				"generation":        int64(3),
+				"creationTimestamp": "2025-01-01T00:00:00Z",
+				"managedFields":     []any{map[string]any{"manager": "frank"}},
+				"annotations": map[string]any{
+					"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				},
+			},
+			"data":   map[string]any{"mode": "fast"},
+			"status": map[string]any{"phase": "Active"},
+		},
+	}
+
+	expected := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      "settings",
+			"namespace": "dev",
+		},
+		"data": map[string]any{"mode": "fast"},
+	}
+
+	normalized := normalizeObject(obj)
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Errorf("normalizeObject() = %v, want %v", normalized, expected)
+	}
+
+	if _, exists := obj.Object["status"]; !exists {
+		t.Error("normalizeObject() must not modify the original object")
+	}
+}
+
+func TestProjectOnto(t *testing.T) {
+	tests := []struct {
+		name     string
+		live     any
+		desired  any
+		expected any
+	}{
+		{
+			name:     "defaulted map keys are dropped",
+			live:     map[string]any{"replicas": int64(2), "revisionHistoryLimit": int64(10)},
+			desired:  map[string]any{"replicas": int64(2)},
+			expected: map[string]any{"replicas": int64(2)},
+		},
+		{
+			name:     "keys missing from the live object stay missing",
+			live:     map[string]any{},
+			desired:  map[string]any{"replicas": int64(2)},
+			expected: map[string]any{},
+		},
+		{
+			name: "list elements are projected by position",
+			live: []any{
+				map[string]any{"name": "web", "image": "nginx", "imagePullPolicy": "IfNotPresent"},
+			},
+			desired: []any{
+				map[string]any{"name": "web", "image": "nginx"},
+			},
+			expected: []any{
+				map[string]any{"name": "web", "image": "nginx"},
+			},
+		},
+		{
+			name:     "lists of different length are kept",
+			live:     []any{"a", "b"},
+			desired:  []any{"a"},
+			expected: []any{"a", "b"},
+		},
+		{
+			name:     "scalars are kept",
+			live:     "nginx:1.20",
+			desired:  "nginx:1.21",
+			expected: "nginx:1.20",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := projectOnto(tt.live, tt.desired)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("projectOnto() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+// liveKubernetesDeployer returns a fixed live object and optionally a dry-run result.
+type liveKubernetesDeployer struct {
+	live   *unstructured.Unstructured
+	dryRun *unstructured.Unstructured
+}
+
+func (m *liveKubernetesDeployer) ResolveResource(apiVersion, kind string) (schema.GroupVersionResource, bool, error) {
+	return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, true, nil
+}
+
+func (m *liveKubernetesDeployer) GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	return m.live.DeepCopy(), nil
+}
+
+func (m *liveKubernetesDeployer) DryRunApply(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	if m.dryRun == nil {
+		return nil, errors.New("dry-run not supported")
+	}
+
+	return m.dryRun.DeepCopy(), nil
+}
+
+func (m *liveKubernetesDeployer) FindOrphanedResources(manifestContent []byte, stackName, configNamespace string) ([]kubernetes.ManagedResource, error) {
+	return nil, nil
+}
+
+// newLiveDeployment creates a Deployment as the API server would return it.
+func newLiveDeployment(replicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]any{
+				"name":              "web",
+				"namespace":         "dev",
+				"uid":               "1234",
+				"resourceVersion":   "42",
+				"generation":        int64(1),
+				"creationTimestamp": "2025-01-01T00:00:00Z",
+				"annotations": map[string]any{
+					"frankthetank.cloud/stack-name":     "dev-web",
+					"deployment.kubernetes.io/revision": "1",
+				},
+				"labels": map[string]any{
+					"app.kubernetes.io/managed-by": "frank",
+				},
+			},
+			"spec": map[string]any{
+				"replicas":             replicas,
+				"revisionHistoryLimit": int64(10),
+			},
+			"status": map[string]any{"readyReplicas": replicas},
+		},
+	}
+}
+
+func TestPlanner_PlanManifestExistingResource(t *testing.T) {
+	manifest := []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 2\n")
+
+	tests := []struct {
+		name            string
+		deployer        *liveKubernetesDeployer
+		expectOperation string
+		expectInDiff    string
+	}{
+		{
+			name:            "unchanged resource without dry-run",
+			deployer:        &liveKubernetesDeployer{live: newLiveDeployment(2)},
+			expectOperation: "no-change",
+		},
+		{
+			name:            "changed resource without dry-run",
+			deployer:        &liveKubernetesDeployer{live: newLiveDeployment(3)},
+			expectOperation: "update",
+			expectInDiff:    "+    replicas: 2",
+		},
+		{
+			name:            "unchanged resource with dry-run",
+			deployer:        &liveKubernetesDeployer{live: newLiveDeployment(2), dryRun: newLiveDeployment(2)},
+			expectOperation: "no-change",
+		},
+		{
+			name:            "changed resource with dry-run",
+			deployer:        &liveKubernetesDeployer{live: newLiveDeployment(3), dryRun: newLiveDeployment(2)},
+			expectOperation: "update",
+			expectInDiff:    "-    replicas: 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planner := NewPlanner(tt.deployer, template.NewRenderer(nil), slog.Default())
+			planner.options.NoColor = true
+
+			result := planner.PlanManifest(manifest, &project.ManifestConfig{Manifest: "web.yaml"}, &stack.StackInfo{Name: "dev-web", Namespace: "dev"})
+			validateExistingResourcePlan(t, result, tt.expectOperation, tt.expectInDiff)
+		})
+	}
+}
+
+func validateExistingResourcePlan(t *testing.T, result PlanResult, expectOperation, expectInDiff string) {
+	if result.Error != nil {
+		t.Fatalf("Unexpected error: %v", result.Error)
+	}
+
+	if result.Operation != expectOperation {
+		t.Errorf("Expected operation %s, got %s\n%s", expectOperation, result.Operation, result.Diff)
+	}
+
+	if expectInDiff != "" && !strings.Contains(result.Diff, expectInDiff) {
+		t.Errorf("Expected diff to contain %q, got:\n%s", expectInDiff, result.Diff)
+	}
+
+	if len(result.Resources) != 1 || result.Resources[0].String() != "Deployment/dev/web" {
+		t.Errorf("Expected a single Deployment/dev/web resource plan, got %v", result.Resources)
+	}
+}
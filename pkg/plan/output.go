@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by FormatResults.
+const (
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// planResultView is the machine-readable form of a PlanResult.
+type planResultView struct {
+	StackName string         `json:"stack_name"          yaml:"stack_name"`
+	Context   string         `json:"context"             yaml:"context"`
+	Manifest  string         `json:"manifest"            yaml:"manifest"`
+	Operation string         `json:"operation,omitempty" yaml:"operation,omitempty"`
+	Resources []ResourcePlan `json:"resources"           yaml:"resources"`
+	Error     string         `json:"error,omitempty"     yaml:"error,omitempty"`
+}
+
+// view converts the result to its machine-readable form.
+func (r PlanResult) view() planResultView {
+	view := planResultView{
+		StackName: r.StackName,
+		Context:   r.Context,
+		Manifest:  r.Manifest,
+		Operation: r.Operation,
+		Resources: r.Resources,
+	}
+
+	if view.Resources == nil {
+		view.Resources = []ResourcePlan{}
+	}
+
+	if r.Error != nil {
+		view.Error = r.Error.Error()
+	}
+
+	return view
+}
+
+// MarshalJSON encodes the result with its error as a string.
+func (r PlanResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.view())
+}
+
+// MarshalYAML encodes the result with its error as a string.
+func (r PlanResult) MarshalYAML() (any, error) {
+	return r.view(), nil
+}
+
+// HasChanges reports whether applying the stack would change anything in the cluster.
+func (r PlanResult) HasChanges() bool {
+	for _, resource := range r.Resources {
+		if resource.Operation != "no-change" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FormatResults encodes plan results as JSON or YAML.
+func FormatResults(results []PlanResult, format string) ([]byte, error) {
+	if results == nil {
+		results = []PlanResult{}
+	}
+
+	switch format {
+	case OutputJSON:
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error encoding plan as JSON: %w", err)
+		}
+
+		return append(data, '\n'), nil
+	case OutputYAML:
+		data, err := yaml.Marshal(results)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding plan as YAML: %w", err)
+		}
+
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q (use %s or %s)", format, OutputJSON, OutputYAML)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFormatResultsJSON(t *testing.T) {
+	results := []PlanResult{
+		{
+			Context:   "dev",
+			StackName: "dev-app",
+			Manifest:  "app.yaml",
+			Operation: "update",
+			Diff:      "\033[31m-colored\033[0m",
+			Resources: []ResourcePlan{
+				{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "app",
+					Namespace:  "default",
+					Operation:  "update",
+					Changes:    []FieldChange{{Path: ".spec.replicas", Operation: "change", Before: 1, After: 3}},
+				},
+			},
+		},
+		{
+			Context:   "dev",
+			StackName: "dev-broken",
+			Manifest:  "broken.yaml",
+			Error:     errors.New("template error"),
+		},
+	}
+
+	data, err := FormatResults(results, OutputJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var decoded []map[string]any
+
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, data)
+	}
+
+	validateJSONResults(t, decoded)
+}
+
+func validateJSONResults(t *testing.T, decoded []map[string]any) {
+	if len(decoded) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(decoded))
+	}
+
+	if decoded[0]["stack_name"] != "dev-app" {
+		t.Errorf("Expected stack_name dev-app, got %v", decoded[0]["stack_name"])
+	}
+
+	if _, exists := decoded[0]["diff"]; exists {
+		t.Errorf("Expected stack-level colored diff to be omitted")
+	}
+
+	resource, _ := decoded[0]["resources"].([]any)[0].(map[string]any)
+	if resource["api_version"] != "apps/v1" || resource["operation"] != "update" {
+		t.Errorf("Unexpected resource: %v", resource)
+	}
+
+	if decoded[1]["error"] != "template error" {
+		t.Errorf("Expected error string, got %v", decoded[1]["error"])
+	}
+}
+
+func TestFormatResultsYAML(t *testing.T) {
+	results := []PlanResult{{Context: "dev", StackName: "dev-app", Manifest: "app.yaml", Error: errors.New("boom")}}
+
+	data, err := FormatResults(results, OutputYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"stack_name: dev-app", "error: boom", "resources: []"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected output to contain %q:\n%s", expected, data)
+		}
+	}
+}
+
+func TestFormatResultsUnsupported(t *testing.T) {
+	_, err := FormatResults(nil, "xml")
+	if err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestPlanResultHasChanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []string
+		expected   bool
+	}{
+		{name: "no resources", operations: nil, expected: false},
+		{name: "up to date", operations: []string{"no-change", "no-change"}, expected: false},
+		{name: "update", operations: []string{"no-change", "update"}, expected: true},
+		{name: "delete", operations: []string{"delete"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result PlanResult
+			for _, operation := range tt.operations {
+				result.Resources = append(result.Resources, ResourcePlan{Operation: operation})
+			}
+
+			if result.HasChanges() != tt.expected {
+				t.Errorf("Expected HasChanges %v, got %v", tt.expected, !tt.expected)
+			}
+		})
+	}
+}
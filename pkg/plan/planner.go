@@ -5,24 +5,30 @@ Copyright © 2025 Ben Sapp ya.bsapp.ru
 package plan
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
 	"github.com/schnauzersoft/frank-cli/pkg/stack"
 	"github.com/schnauzersoft/frank-cli/pkg/template"
 
 	"gopkg.in/yaml.v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 )
 
 // KubernetesDeployer interface for planning operations.
 type KubernetesDeployer interface {
-	GetGVR(apiVersion, kind string) (schema.GroupVersionResource, error)
+	ResolveResource(apiVersion, kind string) (schema.GroupVersionResource, bool, error)
 	GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error)
+	DryRunApply(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) (*unstructured.Unstructured, error)
+	FindOrphanedResources(manifestContent []byte, stackName, configNamespace string) ([]kubernetes.ManagedResource, error)
 }
 
 // Planner handles planning operations.
@@ -30,6 +36,7 @@ type Planner struct {
 	k8sDeployer      KubernetesDeployer
 	templateRenderer *template.Renderer
 	logger           *slog.Logger
+	options          Options
 }
 
 // PlanResult represents the result of a plan operation.
@@ -37,23 +44,50 @@ type PlanResult struct {
 	Context         string
 	StackName       string
 	Manifest        string
+	Namespace       string        // Namespace from the stack configuration
+	Timeout         time.Duration // Readiness timeout from the stack configuration
+	OnFailure       string        // What apply does when the stack fails, from the stack configuration
+	DependsOn       []string      // Names of the stacks this stack depends on
 	Operation       string
 	Diff            string
 	ManifestContent string
+	Resources       []ResourcePlan // Planned change for every document, plus deletions with --prune
 	Error           error
 }
 
+// ResourcePlan represents the planned change for a single manifest document.
+type ResourcePlan struct {
+	APIVersion      string        `json:"api_version"                yaml:"api_version"`
+	Kind            string        `json:"kind"                       yaml:"kind"`
+	Name            string        `json:"name"                       yaml:"name"`
+	Namespace       string        `json:"namespace,omitempty"        yaml:"namespace,omitempty"`
+	Operation       string        `json:"operation"                  yaml:"operation"`                  // "create", "update", "no-change", "delete"
+	ResourceVersion string        `json:"resource_version,omitempty" yaml:"resource_version,omitempty"` // Observed resourceVersion, empty if the object doesn't exist
+	Changes         []FieldChange `json:"changes,omitempty"          yaml:"changes,omitempty"`
+	Diff            string        `json:"diff,omitempty"             yaml:"diff,omitempty"`
+}
+
+// String formats the resource as Kind/namespace/name.
+func (r ResourcePlan) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
 // NewPlanner creates a new planner instance.
 func NewPlanner(k8sDeployer KubernetesDeployer, templateRenderer *template.Renderer, logger *slog.Logger) *Planner {
 	return &Planner{
 		k8sDeployer:      k8sDeployer,
 		templateRenderer: templateRenderer,
 		logger:           logger,
+		options:          Options{ContextLines: DefaultContextLines},
 	}
 }
 
 // PlanManifest plans a manifest by comparing current vs desired state.
-func (p *Planner) PlanManifest(manifestData any, manifestConfig *ManifestConfig, stackInfo *stack.StackInfo) PlanResult {
+func (p *Planner) PlanManifest(manifestData any, manifestConfig *project.ManifestConfig, stackInfo *stack.StackInfo) PlanResult {
 	// Convert manifest data to bytes for processing
 	manifestContent, err := p.convertManifestData(manifestData)
 	if err != nil {
@@ -65,8 +99,8 @@ func (p *Planner) PlanManifest(manifestData any, manifestConfig *ManifestConfig,
 		}
 	}
 
-	// Get the current state from Kubernetes
-	currentState, err := p.getCurrentState(stackInfo, manifestContent)
+	// Plan every document against its current state in Kubernetes
+	resources, err := p.planResources(stackInfo, manifestContent)
 	if err != nil {
 		return PlanResult{
 			Context:   stackInfo.Context,
@@ -76,23 +110,50 @@ func (p *Planner) PlanManifest(manifestData any, manifestConfig *ManifestConfig,
 		}
 	}
 
-	// Generate diff
-	var currentStateBytes []byte
-	if currentState != "" {
-		currentStateBytes = []byte(currentState)
-	}
-
-	diff, operation := p.generateDiff(currentStateBytes, manifestContent)
+	// Find resources that were removed from the stack's manifests
+	prune, err := p.findPruneCandidates(manifestContent, stackInfo)
+	resources = append(resources, prune...)
 
 	return PlanResult{
 		Context:         stackInfo.Context,
 		StackName:       stackInfo.Name,
 		Manifest:        manifestConfig.Manifest,
-		Operation:       operation,
-		Diff:            diff,
+		Namespace:       stackInfo.Namespace,
+		Timeout:         manifestConfig.Timeout,
+		OnFailure:       manifestConfig.OnFailure,
+		Operation:       summarizeOperations(resources),
+		Diff:            joinDiffs(resources),
 		ManifestContent: string(manifestContent),
-		Error:           nil,
+		Resources:       resources,
+		Error:           err,
+	}
+}
+
+// findPruneCandidates plans the deletion of resources apply --prune would remove, when pruning is enabled.
+func (p *Planner) findPruneCandidates(manifestContent []byte, stackInfo *stack.StackInfo) ([]ResourcePlan, error) {
+	if !p.options.Prune {
+		return nil, nil
+	}
+
+	orphans, err := p.k8sDeployer.FindOrphanedResources(manifestContent, stackInfo.Name, stackInfo.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error finding resources to prune: %w", err)
+	}
+
+	resources := make([]ResourcePlan, 0, len(orphans))
+
+	for _, orphan := range orphans {
+		resources = append(resources, ResourcePlan{
+			APIVersion:      orphan.GVR.GroupVersion().String(),
+			Kind:            orphan.Kind,
+			Name:            orphan.Name,
+			Namespace:       orphan.Namespace,
+			Operation:       "delete",
+			ResourceVersion: orphan.ResourceVersion,
+		})
 	}
+
+	return resources, nil
 }
 
 // convertManifestData converts manifest data to bytes.
@@ -114,131 +175,228 @@ func (p *Planner) readManifestFile(filePath string) ([]byte, error) {
 	return os.ReadFile(filePath)
 }
 
-// getCurrentState gets the current state of the resource from Kubernetes.
-func (p *Planner) getCurrentState(stackInfo *stack.StackInfo, manifestContent []byte) (string, error) {
-	// Parse the manifest to get resource info
-	decoder := k8syaml.NewYAMLOrJSONDecoder(strings.NewReader(string(manifestContent)), 4096)
+// planResources plans every document in the manifest content.
+func (p *Planner) planResources(stackInfo *stack.StackInfo, manifestContent []byte) ([]ResourcePlan, error) {
+	objects, err := kubernetes.DecodeManifest(manifestContent)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+
+	if len(objects) == 0 {
+		return nil, errors.New("no resources found in manifest")
+	}
 
-	var obj unstructured.Unstructured
+	resources := make([]ResourcePlan, 0, len(objects))
 
-	err := decoder.Decode(&obj)
-	if err != nil {
-		return "", fmt.Errorf("error parsing manifest: %w", err)
+	for _, obj := range objects {
+		resource, err := p.planResource(stackInfo, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, resource)
 	}
 
-	// Get the GVR for the resource
-	gvr, err := p.k8sDeployer.GetGVR(obj.GetAPIVersion(), obj.GetKind())
+	return resources, nil
+}
+
+// planResource plans a single document the way apply would write it.
+func (p *Planner) planResource(stackInfo *stack.StackInfo, desired *unstructured.Unstructured) (ResourcePlan, error) {
+	// Get the GVR for the resource and whether it is namespaced
+	gvr, namespaced, err := p.k8sDeployer.ResolveResource(desired.GetAPIVersion(), desired.GetKind())
 	if err != nil {
-		return "", fmt.Errorf("failed to get GVR: %w", err)
+		return ResourcePlan{}, fmt.Errorf("failed to get GVR: %w", err)
 	}
 
-	// Try to get the current resource
-	namespace := obj.GetNamespace()
-	if namespace == "" {
-		namespace = stackInfo.Namespace
-		if namespace == "" {
-			namespace = "default"
-		}
+	// Set namespace and frank metadata exactly as apply does; cluster-scoped resources have no namespace
+	namespace := ""
+	if namespaced {
+		namespace = p.resourceNamespace(desired.GetNamespace(), stackInfo.Namespace)
+	}
+
+	desired.SetNamespace(namespace)
+	kubernetes.SetStackMetadata(desired, stackInfo.Name)
+
+	state, err := p.getCurrentState(gvr, desired)
+	if err != nil {
+		return ResourcePlan{}, err
 	}
 
-	existing, err := p.k8sDeployer.GetResource(gvr, namespace, obj.GetName())
+	diff, operation, err := p.diffStates(state.current, state.desired)
 	if err != nil {
-		// Resource doesn't exist
-		//nolint:nilerr
-		return "", nil
+		return ResourcePlan{}, err
+	}
+
+	resource := ResourcePlan{
+		APIVersion:      desired.GetAPIVersion(),
+		Kind:            desired.GetKind(),
+		Name:            desired.GetName(),
+		Namespace:       namespace,
+		Operation:       operation,
+		ResourceVersion: state.resourceVersion,
+		Diff:            diff,
+	}
+
+	if operation == "update" {
+		resource.Changes = fieldChanges(state.current, state.desired)
+	}
+
+	return resource, nil
+}
+
+// resourceState holds the normalized current and desired state of a resource.
+type resourceState struct {
+	current         any // nil when the resource doesn't exist
+	desired         any
+	resourceVersion string
+}
+
+// getCurrentState gets the normalized current state of the resource from Kubernetes
+// together with the desired state it should be compared with. Only a resource the API server
+// reports as not found is planned as created; any other error fails the plan.
+func (p *Planner) getCurrentState(gvr schema.GroupVersionResource, desired *unstructured.Unstructured) (resourceState, error) {
+	existing, err := p.k8sDeployer.GetResource(gvr, desired.GetNamespace(), desired.GetName())
+	if apierrors.IsNotFound(err) {
+		return resourceState{desired: normalizeObject(desired)}, nil
 	}
 
-	// Convert to YAML
-	existingYAML, err := yaml.Marshal(existing.Object)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling existing resource: %w", err)
+		return resourceState{}, fmt.Errorf("error reading %s %s: %w", desired.GetKind(), desired.GetName(), err)
 	}
 
-	return string(existingYAML), nil
+	current, want := p.comparableStates(gvr, existing, desired)
+
+	return resourceState{current: current, desired: want, resourceVersion: existing.GetResourceVersion()}, nil
 }
 
-// generateDiff generates a colored diff between current and desired state.
-func (p *Planner) generateDiff(currentState, desiredState []byte) (string, string) {
+// diffStates renders the diff between the current and desired state and determines the operation.
+func (p *Planner) diffStates(currentState, desiredState any) (string, string, error) {
+	desiredYAML, err := marshalState(desiredState)
+	if err != nil {
+		return "", "", err
+	}
+
 	if currentState == nil {
-		// Resource doesn't exist, will be created
-		return p.colorizeDiff("", string(desiredState), "+"), "create"
+		diff, operation := p.generateDiff(nil, desiredYAML)
+
+		return diff, operation, nil
 	}
 
-	// Generate unified diff
-	diff := p.createUnifiedDiff(string(currentState), string(desiredState))
-	if diff == "" {
-		// No changes needed
-		return "", "no-change"
+	currentYAML, err := marshalState(currentState)
+	if err != nil {
+		return "", "", err
 	}
 
-	return p.colorizeDiff(string(currentState), string(desiredState), diff), "update"
+	diff, operation := p.generateDiff(currentYAML, desiredYAML)
+
+	return diff, operation, nil
 }
 
-// createUnifiedDiff creates a unified diff between two strings.
-func (p *Planner) createUnifiedDiff(old, newContent string) string {
-	// Simple diff implementation - in a real implementation you'd use a proper diff library
-	// For now, just return a basic comparison
-	if old == newContent {
-		return ""
+// comparableStates returns the current and desired state restricted to what apply would change.
+// It prefers a server-side dry-run apply, which includes server defaults on both sides, and falls
+// back to comparing only the fields set in the manifest.
+func (p *Planner) comparableStates(gvr schema.GroupVersionResource, existing, desired *unstructured.Unstructured) (any, any) {
+	dryRun, err := p.k8sDeployer.DryRunApply(desired, gvr)
+	if err == nil {
+		return normalizeObject(existing), normalizeObject(dryRun)
 	}
 
-	return "--- current\n+++ desired\n" + p.basicDiff(old, newContent)
+	p.logger.Debug("Server-side dry-run unavailable, comparing fields set in the manifest", "name", desired.GetName(), "error", err)
+
+	want := normalizeObject(desired)
+
+	return projectOnto(normalizeObject(existing), want), want
 }
 
-// basicDiff creates a basic line-by-line diff.
-func (p *Planner) basicDiff(old, newContent string) string {
-	oldLines := strings.Split(old, "\n")
-	newLines := strings.Split(newContent, "\n")
+// marshalState converts a state to YAML for diffing.
+func marshalState(state any) ([]byte, error) {
+	stateYAML, err := yaml.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling resource: %w", err)
+	}
+
+	return stateYAML, nil
+}
 
-	var diff strings.Builder
+// summarizeOperations combines the operations of all resources into the stack operation.
+func summarizeOperations(resources []ResourcePlan) string {
+	operations := make(map[string]int)
+	for _, resource := range resources {
+		operations[resource.Operation]++
+	}
 
-	maxLines := max(len(oldLines), len(newLines))
+	if operations["create"] == len(resources) {
+		return "create"
+	}
 
-	for i := range maxLines {
-		oldLine := p.getLineAt(oldLines, i)
-		newLine := p.getLineAt(newLines, i)
-		p.writeDiffLine(&diff, oldLine, newLine)
+	if operations["no-change"] == len(resources) {
+		return "no-change"
 	}
 
-	return diff.String()
+	return "update"
 }
 
-// getLineAt safely gets a line at the given index.
-func (p *Planner) getLineAt(lines []string, index int) string {
-	if index < len(lines) {
-		return lines[index]
+// joinDiffs joins the diffs of all changed resources, each preceded by the resource it belongs to.
+func joinDiffs(resources []ResourcePlan) string {
+	var diffs strings.Builder
+
+	for _, resource := range resources {
+		if resource.Diff == "" {
+			continue
+		}
+
+		fmt.Fprintf(&diffs, "# %s (%s)\n%s", resource, resource.Operation, resource.Diff)
 	}
 
-	return ""
+	return diffs.String()
 }
 
-// writeDiffLine writes a diff line for the given old and new lines.
-func (p *Planner) writeDiffLine(diff *strings.Builder, oldLine, newLine string) {
-	if oldLine != newLine {
-		p.writeChangedLines(diff, oldLine, newLine)
-	} else {
-		fmt.Fprintf(diff, " %s\n", oldLine)
+// resourceNamespace determines the namespace of a namespaced resource.
+func (p *Planner) resourceNamespace(manifestNamespace, configNamespace string) string {
+	if manifestNamespace != "" {
+		return manifestNamespace
+	}
+
+	if configNamespace != "" {
+		return configNamespace
 	}
+
+	return "default"
 }
 
-// writeChangedLines writes the old and new lines when they differ.
-func (p *Planner) writeChangedLines(diff *strings.Builder, oldLine, newLine string) {
-	if oldLine != "" {
-		fmt.Fprintf(diff, "-%s\n", oldLine)
+// generateDiff generates a colored diff between current and desired state.
+func (p *Planner) generateDiff(currentState, desiredState []byte) (string, string) {
+	if currentState == nil {
+		// Resource doesn't exist, will be created
+		return p.colorizeDiff("", string(desiredState), p.createUnifiedDiff("", string(desiredState))), "create"
 	}
 
-	if newLine != "" {
-		fmt.Fprintf(diff, "+%s\n", newLine)
+	// Generate unified diff
+	diff := p.createUnifiedDiff(string(currentState), string(desiredState))
+	if diff == "" {
+		// No changes needed
+		return "", "no-change"
 	}
+
+	return p.colorizeDiff(string(currentState), string(desiredState), diff), "update"
 }
 
-// colorizeDiff adds ANSI color codes to diff output.
+// createUnifiedDiff creates a unified diff between two strings with the configured number of context lines.
+func (p *Planner) createUnifiedDiff(old, newContent string) string {
+	return unifiedDiff(old, newContent, p.options.ContextLines)
+}
+
+// colorizeDiff adds ANSI color codes to diff output unless color is disabled.
 func (p *Planner) colorizeDiff(_, _, diff string) string {
 	if diff == "" {
 		return ""
 	}
 
-	lines := strings.Split(diff, "\n")
+	if p.options.NoColor {
+		return diff
+	}
+
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
 
 	var colored strings.Builder
 
@@ -247,6 +405,9 @@ func (p *Planner) colorizeDiff(_, _, diff string) string {
 		case strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++"):
 			// Header lines - bold
 			colored.WriteString(fmt.Sprintf("\033[1m%s\033[0m\n", line))
+		case strings.HasPrefix(line, "@@"):
+			// Hunk headers - cyan
+			colored.WriteString(fmt.Sprintf("\033[36m%s\033[0m\n", line))
 		case strings.HasPrefix(line, "-"):
 			// Deleted lines - red
 			colored.WriteString(fmt.Sprintf("\033[31m%s\033[0m\n", line))
@@ -261,12 +422,3 @@ func (p *Planner) colorizeDiff(_, _, diff string) string {
 
 	return colored.String()
 }
-
-// ManifestConfig represents manifest-specific configuration for planning.
-type ManifestConfig struct {
-	Manifest  string         `yaml:"manifest"`
-	Timeout   int            `yaml:"timeout"`
-	Version   string         `yaml:"version"`
-	Vars      map[string]any `yaml:"vars"`
-	DependsOn []string       `yaml:"depends_on"`
-}
@@ -8,11 +8,13 @@ import (
 	"errors"
 	"log/slog"
 	"os"
-	"strings"
 	"testing"
 
+	"github.com/schnauzersoft/frank-cli/pkg/kubernetes"
+	"github.com/schnauzersoft/frank-cli/pkg/project"
 	"github.com/schnauzersoft/frank-cli/pkg/stack"
 	"github.com/schnauzersoft/frank-cli/pkg/template"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -35,7 +37,7 @@ func createPlanManifestTestCases() []planManifestTestCase {
 		{
 			name:         "string manifest data",
 			manifestData: "test-manifest.yaml",
-			manifestConfig: &ManifestConfig{
+			manifestConfig: &project.ManifestConfig{
 				Manifest: "test-manifest.yaml",
 			},
 			stackInfo: &stack.StackInfo{
@@ -48,7 +50,7 @@ func createPlanManifestTestCases() []planManifestTestCase {
 		{
 			name:         "byte manifest data",
 			manifestData: []byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: test"),
-			manifestConfig: &ManifestConfig{
+			manifestConfig: &project.ManifestConfig{
 				Manifest: "test-pod.yaml",
 			},
 			stackInfo: &stack.StackInfo{
@@ -58,10 +60,23 @@ func createPlanManifestTestCases() []planManifestTestCase {
 			expectError:     false,
 			expectOperation: "create", // Should be create since resource doesn't exist
 		},
+		{
+			name:         "multiple documents",
+			manifestData: []byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: b"),
+			manifestConfig: &project.ManifestConfig{
+				Manifest: "test-pods.yaml",
+			},
+			stackInfo: &stack.StackInfo{
+				Context: "test",
+				Name:    "test-stack",
+			},
+			expectError:     false,
+			expectOperation: "create",
+		},
 		{
 			name:         "invalid manifest data type",
 			manifestData: 123,
-			manifestConfig: &ManifestConfig{
+			manifestConfig: &project.ManifestConfig{
 				Manifest: "test.yaml",
 			},
 			stackInfo: &stack.StackInfo{
@@ -77,7 +92,7 @@ func createPlanManifestTestCases() []planManifestTestCase {
 type planManifestTestCase struct {
 	name            string
 	manifestData    any
-	manifestConfig  *ManifestConfig
+	manifestConfig  *project.ManifestConfig
 	stackInfo       *stack.StackInfo
 	expectError     bool
 	expectOperation string
@@ -136,63 +151,6 @@ func TestPlanner_convertManifestData(t *testing.T) {
 	}
 }
 
-func TestPlanner_basicDiff(t *testing.T) {
-	planner := createTestPlanner()
-
-	tests := []struct {
-		name     string
-		old      string
-		new      string
-		expected string
-	}{
-		{
-			name:     "identical strings",
-			old:      "line1\nline2\nline3",
-			new:      "line1\nline2\nline3",
-			expected: " line1\n line2\n line3\n",
-		},
-		{
-			name:     "different strings",
-			old:      "line1\nline2\nline3",
-			new:      "line1\nmodified\nline3",
-			expected: " line1\n-line2\n+modified\n line3\n",
-		},
-		{
-			name:     "added lines",
-			old:      "line1\nline2",
-			new:      "line1\nline2\nline3",
-			expected: " line1\n line2\n+line3\n",
-		},
-		{
-			name:     "removed lines",
-			old:      "line1\nline2\nline3",
-			new:      "line1\nline2",
-			expected: " line1\n line2\n-line3\n",
-		},
-		{
-			name:     "empty old",
-			old:      "",
-			new:      "line1\nline2",
-			expected: "+line1\n+line2\n",
-		},
-		{
-			name:     "empty new",
-			old:      "line1\nline2",
-			new:      "",
-			expected: "-line1\n-line2\n",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := planner.basicDiff(tt.old, tt.new)
-			if result != tt.expected {
-				t.Errorf("Expected:\n%q\nGot:\n%q", tt.expected, result)
-			}
-		})
-	}
-}
-
 func TestPlanner_createUnifiedDiff(t *testing.T) {
 	planner := createTestPlanner()
 
@@ -212,7 +170,7 @@ func TestPlanner_createUnifiedDiff(t *testing.T) {
 			name:     "different strings",
 			old:      "old content",
 			new:      "new content",
-			expected: "--- current\n+++ desired\n-old content\n+new content\n",
+			expected: "--- current\n+++ desired\n@@ -1,1 +1,1 @@\n-old content\n+new content\n",
 		},
 	}
 
@@ -337,155 +295,63 @@ func TestPlanner_colorizeDiff(t *testing.T) {
 	}
 }
 
-func TestPlanner_getLineAt(t *testing.T) {
+func TestPlanner_colorizeDiffNoColor(t *testing.T) {
 	planner := createTestPlanner()
+	planner.options.NoColor = true
 
-	tests := []struct {
-		name     string
-		lines    []string
-		index    int
-		expected string
-	}{
-		{
-			name:     "valid index",
-			lines:    []string{"line1", "line2", "line3"},
-			index:    1,
-			expected: "line2",
-		},
-		{
-			name:     "index out of bounds",
-			lines:    []string{"line1", "line2"},
-			index:    5,
-			expected: "",
-		},
-		{
-			name:     "empty slice",
-			lines:    []string{},
-			index:    0,
-			expected: "",
-		},
-	}
+	diff := "--- current\n+++ desired\n@@ -1,1 +1,1 @@\n-old\n+new\n"
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := planner.getLineAt(tt.lines, tt.index)
-			if result != tt.expected {
-				t.Errorf("Expected %q, got %q", tt.expected, result)
-			}
-		})
+	result := planner.colorizeDiff("", "", diff)
+	if result != diff {
+		t.Errorf("Expected diff without color codes:\n%q\nGot:\n%q", diff, result)
 	}
 }
 
-func TestPlanner_writeDiffLine(t *testing.T) {
-	planner := createTestPlanner()
-
-	tests := []struct {
-		name     string
-		oldLine  string
-		newLine  string
-		expected string
-	}{
-		{
-			name:     "identical lines",
-			oldLine:  "same line",
-			newLine:  "same line",
-			expected: " same line\n",
-		},
-		{
-			name:     "different lines",
-			oldLine:  "old line",
-			newLine:  "new line",
-			expected: "-old line\n+new line\n",
-		},
-		{
-			name:     "empty old line",
-			oldLine:  "",
-			newLine:  "new line",
-			expected: "+new line\n",
-		},
-		{
-			name:     "empty new line",
-			oldLine:  "old line",
-			newLine:  "",
-			expected: "-old line\n",
-		},
-	}
+// mockKubernetesDeployer is a mock implementation for testing. Resources don't exist unless getErr is set.
+type mockKubernetesDeployer struct {
+	getErr error
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			var diff strings.Builder
-			planner.writeDiffLine(&diff, tt.oldLine, tt.newLine)
+func (m *mockKubernetesDeployer) ResolveResource(apiVersion, kind string) (schema.GroupVersionResource, bool, error) {
+	return schema.GroupVersionResource{
+		Group:    "apps",
+		Version:  "v1",
+		Resource: "deployments",
+	}, true, nil
+}
 
-			result := diff.String()
-			if result != tt.expected {
-				t.Errorf("Expected %q, got %q", tt.expected, result)
-			}
-		})
-	}
+func (m *mockKubernetesDeployer) DryRunApply(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	return nil, errors.New("dry-run not supported")
 }
 
-func TestPlanner_writeChangedLines(t *testing.T) {
-	planner := createTestPlanner()
+func (m *mockKubernetesDeployer) FindOrphanedResources(manifestContent []byte, stackName, configNamespace string) ([]kubernetes.ManagedResource, error) {
+	return nil, nil
+}
 
-	tests := []struct {
-		name     string
-		oldLine  string
-		newLine  string
-		expected string
-	}{
-		{
-			name:     "both lines present",
-			oldLine:  "old line",
-			newLine:  "new line",
-			expected: "-old line\n+new line\n",
-		},
-		{
-			name:     "only old line",
-			oldLine:  "old line",
-			newLine:  "",
-			expected: "-old line\n",
-		},
-		{
-			name:     "only new line",
-			oldLine:  "",
-			newLine:  "new line",
-			expected: "+new line\n",
-		},
-		{
-			name:     "both empty",
-			oldLine:  "",
-			newLine:  "",
-			expected: "",
-		},
+func (m *mockKubernetesDeployer) GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			var diff strings.Builder
-			planner.writeChangedLines(&diff, tt.oldLine, tt.newLine)
+	// Simulate resource not found (create operation)
+	return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+}
 
-			result := diff.String()
-			if result != tt.expected {
-				t.Errorf("Expected %q, got %q", tt.expected, result)
-			}
-		})
+func TestPlanner_PlanManifestReadError(t *testing.T) {
+	planner := createTestPlanner()
+	planner.k8sDeployer = &mockKubernetesDeployer{
+		getErr: apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "test", errors.New("access denied")),
 	}
-}
 
-// mockKubernetesDeployer is a mock implementation for testing.
-type mockKubernetesDeployer struct{}
+	result := planner.PlanManifest([]byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: test"), &project.ManifestConfig{Manifest: "test-pod.yaml"}, &stack.StackInfo{Context: "test", Name: "test-stack"})
 
-func (m *mockKubernetesDeployer) GetGVR(apiVersion, kind string) (schema.GroupVersionResource, error) {
-	return schema.GroupVersionResource{
-		Group:    "apps",
-		Version:  "v1",
-		Resource: "deployments",
-	}, nil
-}
+	if result.Error == nil || !apierrors.IsForbidden(result.Error) {
+		t.Errorf("PlanManifest() error = %v, want the forbidden error instead of a create", result.Error)
+	}
 
-func (m *mockKubernetesDeployer) GetResource(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
-	// Return an error to simulate resource not found (create operation)
-	return nil, errors.New("resource not found")
+	if len(result.Resources) != 0 {
+		t.Errorf("PlanManifest() resources = %+v, want none", result.Resources)
+	}
 }
 
 // Helper function to create a test planner.
@@ -0,0 +1,144 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateExtensions are the extensions tried when a manifest is only available as a template.
+var templateExtensions = []string{".jinja", ".j2"}
+
+// OnFailureRollback is the on_failure value that rolls a stack back when it fails to apply or become ready.
+const OnFailureRollback = "rollback"
+
+// ManifestConfig represents the configuration of a stack config file.
+type ManifestConfig struct {
+	Manifest    string            `yaml:"manifest"`
+	Timeout     time.Duration     `yaml:"timeout"`
+	App         string            `yaml:"app"`
+	Version     string            `yaml:"version"`
+	Vars        map[string]any    `yaml:"vars"`
+	ValuesFiles []string          `yaml:"values_files"`
+	DependsOn   []string          `yaml:"depends_on"`
+	Tags        map[string]string `yaml:"tags"`
+	OnFailure   string            `yaml:"on_failure"`
+}
+
+// ReadManifestConfig reads a stack config file. Unknown keys are rejected so typos like
+// "depend_on" don't go unnoticed, and the manifest is required.
+func ReadManifestConfig(configPath string) (*ManifestConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ManifestConfig
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(&config)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if config.Manifest == "" {
+		return nil, fmt.Errorf("manifest not specified in config file %s", configPath)
+	}
+
+	if config.OnFailure != "" && config.OnFailure != OnFailureRollback {
+		return nil, fmt.Errorf("invalid on_failure %q in config file %s, use %q", config.OnFailure, configPath, OnFailureRollback)
+	}
+
+	return &config, nil
+}
+
+// FindManifestFile searches for a manifest in the manifests directory and its subdirectories.
+// In every directory the manifest itself is preferred over a .jinja or .j2 template of the same name.
+func FindManifestFile(manifestsDir, manifestName string) (string, error) {
+	if found := findManifestInDir(manifestsDir, manifestName); found != "" {
+		return found, nil
+	}
+
+	found, err := findManifestInSubdirectories(manifestsDir, manifestName)
+	if err != nil {
+		return "", err
+	}
+
+	if found == "" {
+		return "", fmt.Errorf("manifest file not found: %s (searched in manifests directory and subdirectories)", manifestName)
+	}
+
+	return found, nil
+}
+
+// findManifestInSubdirectories recursively searches the subdirectories of dir in lexical order.
+func findManifestInSubdirectories(dir, manifestName string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		found, err := searchSubdirectory(filepath.Join(dir, entry.Name()), manifestName)
+		if found != "" || err != nil {
+			return found, err
+		}
+	}
+
+	return "", nil
+}
+
+// searchSubdirectory searches a subdirectory itself before the directories below it.
+func searchSubdirectory(subdirPath, manifestName string) (string, error) {
+	if found := findManifestInDir(subdirPath, manifestName); found != "" {
+		return found, nil
+	}
+
+	return findManifestInSubdirectories(subdirPath, manifestName)
+}
+
+// findManifestInDir returns the manifest or one of its templates in a single directory, or "" if there is none.
+func findManifestInDir(dir, manifestName string) string {
+	manifestPath := filepath.Join(dir, manifestName)
+	if fileExists(manifestPath) {
+		return manifestPath
+	}
+
+	for _, ext := range templateExtensions {
+		templatePath := strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + ext
+		if fileExists(templatePath) {
+			return templatePath
+		}
+	}
+
+	return ""
+}
+
+// fileExists checks if a regular file exists.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,157 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadManifestConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "test.yaml")
+	writeTestFile(t, configFile, `manifest: test-deployment.yaml
+timeout: 5m
+app: web
+version: "1.0.0"
+vars:
+  replicas: 3
+  environment: test
+values_files: [prod.yaml]
+depends_on: [database]
+`)
+
+	config, err := ReadManifestConfig(configFile)
+	if err != nil {
+		t.Fatalf("ReadManifestConfig() error = %v", err)
+	}
+
+	if config.Manifest != "test-deployment.yaml" || config.App != "web" || config.Version != "1.0.0" {
+		t.Errorf("ReadManifestConfig() = %+v", config)
+	}
+
+	if config.Timeout != 5*time.Minute {
+		t.Errorf("Timeout = %v, want 5m", config.Timeout)
+	}
+
+	if config.Vars["replicas"] != 3 || config.Vars["environment"] != "test" {
+		t.Errorf("Vars = %v", config.Vars)
+	}
+
+	if len(config.ValuesFiles) != 1 || len(config.DependsOn) != 1 {
+		t.Errorf("ValuesFiles = %v, DependsOn = %v", config.ValuesFiles, config.DependsOn)
+	}
+}
+
+func TestReadManifestConfigOnFailure(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "test.yaml")
+	writeTestFile(t, configFile, "manifest: test-deployment.yaml\non_failure: rollback\n")
+
+	config, err := ReadManifestConfig(configFile)
+	if err != nil {
+		t.Fatalf("ReadManifestConfig() error = %v", err)
+	}
+
+	if config.OnFailure != OnFailureRollback {
+		t.Errorf("OnFailure = %q, want %q", config.OnFailure, OnFailureRollback)
+	}
+}
+
+func TestReadManifestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "unknown key",
+			content:  "manifest: test-deployment.yaml\ndepend_on:\n  - database\n",
+			expected: "field depend_on not found",
+		},
+		{
+			name:     "timeout without unit",
+			content:  "manifest: test-deployment.yaml\ntimeout: 300\n",
+			expected: "cannot unmarshal !!int `300` into time.Duration",
+		},
+		{
+			name:     "unknown on_failure",
+			content:  "manifest: test-deployment.yaml\non_failure: retry\n",
+			expected: `invalid on_failure "retry"`,
+		},
+		{
+			name:     "missing manifest",
+			content:  "timeout: 5m\n",
+			expected: "manifest not specified",
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			expected: "manifest not specified",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "test.yaml")
+			writeTestFile(t, configFile, tt.content)
+
+			_, err := ReadManifestConfig(configFile)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ReadManifestConfig() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindManifestFile(t *testing.T) {
+	manifestsDir := filepath.Join(t.TempDir(), "manifests")
+
+	for _, dir := range []string{manifestsDir, filepath.Join(manifestsDir, "apps", "web")} {
+		err := os.MkdirAll(dir, 0o755)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	writeTestFile(t, filepath.Join(manifestsDir, "db.yaml"), "kind: StatefulSet")
+	writeTestFile(t, filepath.Join(manifestsDir, "db.jinja"), "kind: StatefulSet")
+	writeTestFile(t, filepath.Join(manifestsDir, "api.j2"), "kind: Deployment")
+	writeTestFile(t, filepath.Join(manifestsDir, "apps", "web", "web.jinja"), "kind: Deployment")
+
+	tests := []struct {
+		manifest string
+		expected string
+	}{
+		{manifest: "db.yaml", expected: filepath.Join(manifestsDir, "db.yaml")},
+		{manifest: "api.yaml", expected: filepath.Join(manifestsDir, "api.j2")},
+		{manifest: "web.yaml", expected: filepath.Join(manifestsDir, "apps", "web", "web.jinja")},
+		{manifest: "web/web.jinja", expected: filepath.Join(manifestsDir, "apps", "web", "web.jinja")},
+	}
+
+	for _, tt := range tests {
+		found, err := FindManifestFile(manifestsDir, tt.manifest)
+		if err != nil {
+			t.Errorf("FindManifestFile(%s) error = %v", tt.manifest, err)
+
+			continue
+		}
+
+		if found != tt.expected {
+			t.Errorf("FindManifestFile(%s) = %s, want %s", tt.manifest, found, tt.expected)
+		}
+	}
+
+	_, err := FindManifestFile(manifestsDir, "non-existent.yaml")
+	if err == nil || !strings.Contains(err.Error(), "manifest file not found: non-existent.yaml") {
+		t.Errorf("FindManifestFile(non-existent.yaml) error = %v, want manifest file not found", err)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,372 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+)
+
+// Stack is a stack of a project with everything needed to render and apply it.
+type Stack struct {
+	// Info is the stack info resolved from the config.yaml chain, with the app and version
+	// of the stack config file applied.
+	Info *stack.StackInfo
+	// Config is the stack config file.
+	Config *ManifestConfig
+	// ManifestPath is the manifest file or template the stack deploys.
+	ManifestPath string
+	// Vars are the template vars of the stack: inherited vars, the vars of the stack config,
+	// its values files in order and finally the command line overrides.
+	Vars map[string]any
+	// DependsOn lists the names of the selected stacks this stack depends on.
+	DependsOn []string
+	// ExternalDependencies lists the stacks this stack depends on that aren't selected, with their
+	// manifest and template vars resolved. They aren't applied with the stack and are expected to be
+	// deployed and healthy already.
+	ExternalDependencies []*Stack
+}
+
+// Project is a config directory with its stacks resolved.
+type Project struct {
+	// Dir is the project directory holding config/, manifests/ and values/.
+	Dir string
+	// ConfigDir is the config directory the stacks were loaded from.
+	ConfigDir string
+	// Stacks lists the selected stacks in a valid sequential execution order.
+	Stacks []*Stack
+	// Graph is the dependency graph of the selected stacks.
+	Graph *stack.DependencyGraph
+}
+
+// Options controls which stacks a project is loaded with.
+type Options struct {
+	// Selector selects the stacks to load. The zero value selects every stack.
+	Selector Selector
+	// Overrides are template vars from --values and --set, applied after every config and values file.
+	Overrides stack.Overrides
+}
+
+// Load reads every stack config file in a config directory, orders the stacks by their dependencies
+// and resolves the manifest and template vars of the selected stacks. It fails on the first stack
+// that can't be read or resolved. Dependencies on stacks that aren't selected are left out of the
+// dependency graph of the project and recorded as external dependencies of the stack instead.
+func Load(configDir string, options Options) (*Project, error) {
+	project := &Project{
+		Dir:       filepath.Dir(configDir),
+		ConfigDir: configDir,
+	}
+
+	stacks, err := loadStacks(configDir, options.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := stack.ResolveDependencyGraph(stackDependencies(stacks))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving dependencies: %w", err)
+	}
+
+	err = project.selectStacks(graph, stacks, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// LoadGraph reads every stack config file in a config directory and returns the dependency graph of the
+// selected stacks, in config file order and with dependencies on stacks that aren't selected left out.
+// Unlike Load it doesn't resolve manifests or template vars and doesn't fail on circular dependencies,
+// use Cycle on the graph to find them.
+func LoadGraph(configDir string, selector Selector) (*stack.DependencyGraph, error) {
+	stacks, err := loadStacks(configDir, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := stack.BuildDependencyGraph(stackDependencies(stacks))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving dependencies: %w", err)
+	}
+
+	byName := make(map[string]*Stack, len(stacks))
+	for _, s := range stacks {
+		byName[s.Info.Name] = s
+	}
+
+	selected := expandSelection(graph, matchingStacks(configDir, stacks, selector), selector)
+	selectedGraph := &stack.DependencyGraph{DependsOn: make(map[string][]string)}
+
+	for _, stackInfo := range graph.Stacks {
+		if selected[stackInfo.Name] {
+			selectedGraph.Stacks = append(selectedGraph.Stacks, stackInfo)
+			selectedGraph.DependsOn[stackInfo.Name], _ = splitDependencies(graph.DependsOn[stackInfo.Name], selected, byName)
+		}
+	}
+
+	if len(selectedGraph.Stacks) == 0 {
+		return nil, fmt.Errorf("no stacks match %s", selector)
+	}
+
+	return selectedGraph, nil
+}
+
+// loadStacks validates the selector and reads the stack config file of every stack in a config directory.
+func loadStacks(configDir string, selector Selector) ([]*Stack, error) {
+	err := selector.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := stack.ListStacks(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("error finding config files: %w", err)
+	}
+
+	if len(listings) == 0 {
+		return nil, errors.New("no config files found")
+	}
+
+	return readStacks(listings)
+}
+
+// readStacks reads the stack config file of every stack.
+func readStacks(listings []stack.StackListing) ([]*Stack, error) {
+	stacks := make([]*Stack, 0, len(listings))
+
+	for _, listing := range listings {
+		if listing.Err != nil {
+			return nil, listing.Err
+		}
+
+		manifestConfig, err := ReadManifestConfig(listing.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", listing.ConfigPath, err)
+		}
+
+		stacks = append(stacks, &Stack{Info: listing.Info, Config: manifestConfig})
+	}
+
+	return stacks, nil
+}
+
+// stackDependencies returns every stack of the project together with its depends_on references.
+func stackDependencies(stacks []*Stack) []stack.StackWithDependencies {
+	stacksWithDeps := make([]stack.StackWithDependencies, 0, len(stacks))
+
+	for _, s := range stacks {
+		stacksWithDeps = append(stacksWithDeps, stack.StackWithDependencies{StackInfo: s.Info, DependsOn: s.Config.DependsOn})
+	}
+
+	return stacksWithDeps
+}
+
+// selectStacks resolves the stacks matched by the selector in execution order and builds their dependency graph.
+func (p *Project) selectStacks(graph *stack.DependencyGraph, stacks []*Stack, options Options) error {
+	p.Graph = &stack.DependencyGraph{DependsOn: make(map[string][]string)}
+
+	byName := make(map[string]*Stack, len(stacks))
+	for _, s := range stacks {
+		byName[s.Info.Name] = s
+	}
+
+	selected := expandSelection(graph, matchingStacks(p.ConfigDir, stacks, options.Selector), options.Selector)
+
+	for _, stackInfo := range graph.Stacks {
+		if !selected[stackInfo.Name] {
+			continue
+		}
+
+		s := byName[stackInfo.Name]
+
+		err := p.resolveStack(s, options.Overrides)
+		if err != nil {
+			return fmt.Errorf("error loading %s: %w", stackInfo.ConfigPath, err)
+		}
+
+		p.Stacks = append(p.Stacks, s)
+		p.Graph.Stacks = append(p.Graph.Stacks, stackInfo)
+	}
+
+	if len(p.Stacks) == 0 {
+		return fmt.Errorf("no stacks match %s", options.Selector)
+	}
+
+	// Keep only dependencies between selected stacks, the others have to be deployed already
+	resolved := make(map[string]bool)
+
+	for _, s := range p.Stacks {
+		s.DependsOn, s.ExternalDependencies = splitDependencies(graph.DependsOn[s.Info.Name], selected, byName)
+		p.Graph.DependsOn[s.Info.Name] = s.DependsOn
+
+		err := p.resolveExternal(s.ExternalDependencies, resolved, options.Overrides)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveExternal resolves the manifest and template vars of dependencies that aren't selected,
+// so their objects can be looked up. Stacks in resolved are skipped and the others added to it.
+func (p *Project) resolveExternal(dependencies []*Stack, resolved map[string]bool, overrides stack.Overrides) error {
+	for _, dependency := range dependencies {
+		if resolved[dependency.Info.Name] {
+			continue
+		}
+
+		err := p.resolveStack(dependency, overrides)
+		if err != nil {
+			return fmt.Errorf("error loading %s: %w", dependency.Info.ConfigPath, err)
+		}
+
+		resolved[dependency.Info.Name] = true
+	}
+
+	return nil
+}
+
+// matchingStacks returns the names of the stacks the selector matches.
+func matchingStacks(configDir string, stacks []*Stack, selector Selector) map[string]bool {
+	matched := make(map[string]bool)
+
+	for _, s := range stacks {
+		if selector.Matches(StackPath(configDir, s.Info.ConfigPath), s.Info.Name, s.Config.Tags) {
+			matched[s.Info.Name] = true
+		}
+	}
+
+	return matched
+}
+
+// expandSelection adds the dependencies and dependents of the matched stacks, as requested by the selector.
+func expandSelection(graph *stack.DependencyGraph, matched map[string]bool, selector Selector) map[string]bool {
+	selected := make(map[string]bool, len(matched))
+	for name := range matched {
+		selected[name] = true
+	}
+
+	if selector.WithDeps {
+		addReachable(selected, matched, graph.DependsOn)
+	}
+
+	if selector.WithDependents {
+		addReachable(selected, matched, dependents(graph))
+	}
+
+	return selected
+}
+
+// addReachable adds every stack reachable from the start stacks by following edges.
+func addReachable(selected, start map[string]bool, edges map[string][]string) {
+	queue := make([]string, 0, len(start))
+	for name := range start {
+		queue = append(queue, name)
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, next := range edges[name] {
+			if !selected[next] {
+				selected[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+}
+
+// dependents maps each stack name to the names of the stacks that depend on it.
+func dependents(graph *stack.DependencyGraph) map[string][]string {
+	reverse := make(map[string][]string)
+
+	for name, deps := range graph.DependsOn {
+		for _, dep := range deps {
+			reverse[dep] = append(reverse[dep], name)
+		}
+	}
+
+	return reverse
+}
+
+// splitDependencies splits the dependencies of a stack into the selected stacks and the stacks left out of the selection.
+func splitDependencies(names []string, selected map[string]bool, byName map[string]*Stack) ([]string, []*Stack) {
+	var (
+		dependsOn []string
+		external  []*Stack
+	)
+
+	for _, name := range names {
+		if selected[name] {
+			dependsOn = append(dependsOn, name)
+		} else {
+			external = append(external, byName[name])
+		}
+	}
+
+	return dependsOn, external
+}
+
+// resolveStack resolves the manifest and template vars of a stack.
+func (p *Project) resolveStack(s *Stack, overrides stack.Overrides) error {
+	// The stack config can name the app and version instead of inheriting them
+	if s.Config.App != "" {
+		s.Info.App = s.Config.App
+	}
+
+	if s.Config.Version != "" {
+		s.Info.Version = s.Config.Version
+	}
+
+	manifestPath, err := FindManifestFile(filepath.Join(p.Dir, "manifests"), s.Config.Manifest)
+	if err != nil {
+		return err
+	}
+
+	values, err := stack.LoadValuesFiles(filepath.Join(p.Dir, "values"), s.Config.ValuesFiles)
+	if err != nil {
+		return fmt.Errorf("error loading values: %w", err)
+	}
+
+	s.ManifestPath = manifestPath
+	s.Vars = overrides.Apply(stack.MergeVars(stack.MergeVars(s.Info.Vars, s.Config.Vars), values))
+
+	return nil
+}
+
+// Stack returns the stack with a name, or nil if the project has no such stack.
+func (p *Project) Stack(name string) *Stack {
+	for _, s := range p.Stacks {
+		if s.Info.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// Contexts returns the distinct kubeconfig contexts the stacks deploy to, in lexical order.
+func (p *Project) Contexts() []string {
+	seen := make(map[string]bool)
+	contexts := make([]string, 0, len(p.Stacks))
+
+	for _, s := range p.Stacks {
+		if !seen[s.Info.Context] {
+			seen[s.Info.Context] = true
+			contexts = append(contexts, s.Info.Context)
+		}
+	}
+
+	sort.Strings(contexts)
+
+	return contexts
+}
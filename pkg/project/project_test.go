@@ -0,0 +1,317 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+)
+
+// writeProject writes a project directory from file paths relative to it and returns its config directory.
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	projectDir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(projectDir, name)
+
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+
+		writeTestFile(t, path, content)
+	}
+
+	return filepath.Join(projectDir, "config")
+}
+
+// stackNames returns the names of the stacks of a project in order.
+func stackNames(project *Project) []string {
+	names := make([]string, 0, len(project.Stacks))
+	for _, s := range project.Stacks {
+		names = append(names, s.Info.Name)
+	}
+
+	return names
+}
+
+func TestLoad(t *testing.T) {
+	configDir := writeProject(t, map[string]string{
+		"config/config.yaml":      "project_code: shop\ncontext: dev\nversion: 1.0.0\nvars:\n  replicas: 1\n",
+		"config/prod/config.yaml": "context: prod\n",
+		"config/prod/api.yaml":    "manifest: api.yaml\napp: backend\nversion: 2.0.0\ndepends_on: [prod/db.yaml]\nvalues_files: [prod.yaml]\nvars:\n  tier: api\n",
+		"config/prod/db.yaml":     "manifest: db.yaml\n",
+		"manifests/api.jinja":     "kind: Deployment",
+		"manifests/db/db.yaml":    "kind: StatefulSet",
+		"values/prod.yaml":        "replicas: 3\n",
+	})
+
+	project, err := Load(configDir, Options{Overrides: stack.Overrides{Set: map[string]any{"tier": "web"}}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if names := stackNames(project); !reflect.DeepEqual(names, []string{"shop-prod-db", "shop-prod-api"}) {
+		t.Errorf("Load() stacks = %v, want [shop-prod-db shop-prod-api]", names)
+	}
+
+	api := project.Stack("shop-prod-api")
+	if api == nil {
+		t.Fatal("Stack(shop-prod-api) = nil")
+	}
+
+	if api.Info.App != "backend" || api.Info.Version != "2.0.0" {
+		t.Errorf("api app = %s, version = %s, want backend, 2.0.0", api.Info.App, api.Info.Version)
+	}
+
+	if api.ManifestPath != filepath.Join(project.Dir, "manifests", "api.jinja") {
+		t.Errorf("api manifest = %s", api.ManifestPath)
+	}
+
+	if !reflect.DeepEqual(api.DependsOn, []string{"shop-prod-db"}) {
+		t.Errorf("api depends on %v, want [shop-prod-db]", api.DependsOn)
+	}
+
+	expectedVars := map[string]any{"replicas": 3, "tier": "web"}
+	if !reflect.DeepEqual(api.Vars, expectedVars) {
+		t.Errorf("api vars = %v, want %v", api.Vars, expectedVars)
+	}
+
+	if contexts := project.Contexts(); !reflect.DeepEqual(contexts, []string{"prod"}) {
+		t.Errorf("Contexts() = %v, want [prod]", contexts)
+	}
+}
+
+func TestLoadSelector(t *testing.T) {
+	configDir := writeProject(t, map[string]string{
+		"config/config.yaml":      "project_code: shop\ncontext: dev\n",
+		"config/dev/api.yaml":     "manifest: app.yaml\ntags:\n  tier: backend\n",
+		"config/dev/web.yaml":     "manifest: app.yaml\ntags:\n  tier: frontend\n",
+		"config/prod/config.yaml": "context: prod\n",
+		"config/prod/api.yaml":    "manifest: app.yaml\ntags:\n  tier: backend\n",
+		"manifests/app.yaml":      "kind: Deployment",
+	})
+
+	tests := []struct {
+		selector Selector
+		expected []string
+	}{
+		{selector: Selector{}, expected: []string{"shop-dev-api", "shop-dev-web", "shop-prod-api"}},
+		{selector: Selector{Stacks: []string{"dev"}}, expected: []string{"shop-dev-api", "shop-dev-web"}},
+		{selector: Selector{Stacks: []string{"dev/api.yaml", "config/prod"}}, expected: []string{"shop-dev-api", "shop-prod-api"}},
+		{selector: Selector{Stacks: []string{"*/api"}}, expected: []string{"shop-dev-api", "shop-prod-api"}},
+		{selector: Selector{Exclude: []string{"shop-dev-api"}}, expected: []string{"shop-dev-web", "shop-prod-api"}},
+		{selector: Selector{Tags: map[string]string{"tier": "backend"}}, expected: []string{"shop-dev-api", "shop-prod-api"}},
+	}
+
+	for _, tt := range tests {
+		project, err := Load(configDir, Options{Selector: tt.selector})
+		if err != nil {
+			t.Errorf("Load(%s) error = %v", tt.selector, err)
+
+			continue
+		}
+
+		// Stacks without dependencies between them can come in any order
+		names := stackNames(project)
+		sort.Strings(names)
+
+		if !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("Load(%s) stacks = %v, want %v", tt.selector, names, tt.expected)
+		}
+	}
+}
+
+func TestLoadDependenciesOutsideSelection(t *testing.T) {
+	configDir := writeProject(t, map[string]string{
+		"config/config.yaml": "project_code: shop\ncontext: dev\n",
+		"config/db.yaml":     "manifest: app.yaml\n",
+		"config/cache.yaml":  "manifest: app.yaml\n",
+		"config/api.yaml":    "manifest: app.yaml\ndepends_on: [db.yaml, cache.yaml]\n",
+		"config/web.yaml":    "manifest: app.yaml\ndepends_on: [api.yaml]\n",
+		"manifests/app.yaml": "kind: Deployment",
+	})
+
+	project, err := Load(configDir, Options{Selector: Selector{Stacks: []string{"api", "cache"}}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if names := stackNames(project); !reflect.DeepEqual(names, []string{"shop-dev-cache", "shop-dev-api"}) {
+		t.Errorf("Load() stacks = %v, want [shop-dev-cache shop-dev-api]", names)
+	}
+
+	// The dependency on the database stack isn't part of the selection
+	expected := map[string][]string{"shop-dev-cache": nil, "shop-dev-api": {"shop-dev-cache"}}
+	if !reflect.DeepEqual(project.Graph.DependsOn, expected) {
+		t.Errorf("Graph.DependsOn = %v, want %v", project.Graph.DependsOn, expected)
+	}
+
+	api := project.Stack("shop-dev-api")
+	if len(api.ExternalDependencies) != 1 || api.ExternalDependencies[0].Info.Name != "shop-dev-db" {
+		t.Fatalf("api external dependencies = %v, want [shop-dev-db]", api.ExternalDependencies)
+	}
+
+	// The dependency's manifest is resolved so its objects can be looked up
+	if db := api.ExternalDependencies[0]; filepath.Base(db.ManifestPath) != "app.yaml" {
+		t.Errorf("db manifest path = %q, want manifests/app.yaml", db.ManifestPath)
+	}
+
+	if cache := project.Stack("shop-dev-cache"); len(cache.ExternalDependencies) != 0 {
+		t.Errorf("cache external dependencies = %v, want none", cache.ExternalDependencies)
+	}
+}
+
+func TestLoadWithDependencies(t *testing.T) {
+	configDir := writeProject(t, map[string]string{
+		"config/config.yaml":  "project_code: shop\ncontext: dev\n",
+		"config/network.yaml": "manifest: app.yaml\n",
+		"config/db.yaml":      "manifest: app.yaml\ndepends_on: [network.yaml]\n",
+		"config/api.yaml":     "manifest: app.yaml\ndepends_on: [db.yaml]\n",
+		"config/web.yaml":     "manifest: app.yaml\ndepends_on: [api.yaml]\n",
+		"config/admin.yaml":   "manifest: app.yaml\ndepends_on: [api.yaml, auth.yaml]\n",
+		"config/auth.yaml":    "manifest: app.yaml\n",
+		"manifests/app.yaml":  "kind: Deployment",
+	})
+
+	tests := []struct {
+		selector Selector
+		expected []string
+	}{
+		{selector: Selector{Stacks: []string{"api"}}, expected: []string{"shop-dev-api"}},
+		{selector: Selector{Stacks: []string{"api"}, WithDeps: true}, expected: []string{"shop-dev-api", "shop-dev-db", "shop-dev-network"}},
+		{selector: Selector{Stacks: []string{"api"}, WithDependents: true}, expected: []string{"shop-dev-admin", "shop-dev-api", "shop-dev-web"}},
+		{selector: Selector{Stacks: []string{"db"}, WithDeps: true, WithDependents: true}, expected: []string{
+			"shop-dev-admin", "shop-dev-api", "shop-dev-db", "shop-dev-network", "shop-dev-web",
+		}},
+	}
+
+	for _, tt := range tests {
+		project, err := Load(configDir, Options{Selector: tt.selector})
+		if err != nil {
+			t.Errorf("Load(%s) error = %v", tt.selector, err)
+
+			continue
+		}
+
+		names := stackNames(project)
+		sort.Strings(names)
+
+		if !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("Load(%s) stacks = %v, want %v", tt.selector, names, tt.expected)
+		}
+	}
+
+	// Dependencies of added dependents that aren't selected are external
+	project, err := Load(configDir, Options{Selector: Selector{Stacks: []string{"api"}, WithDependents: true}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var external []string
+	for _, s := range project.Stacks {
+		for _, dep := range s.ExternalDependencies {
+			external = append(external, s.Info.Name+"->"+dep.Info.Name)
+		}
+	}
+
+	sort.Strings(external)
+
+	if expected := []string{"shop-dev-admin->shop-dev-auth", "shop-dev-api->shop-dev-db"}; !reflect.DeepEqual(external, expected) {
+		t.Errorf("external dependencies = %v, want %v", external, expected)
+	}
+}
+
+func TestLoadGraph(t *testing.T) {
+	configDir := writeProject(t, map[string]string{
+		"config/config.yaml": "project_code: shop\ncontext: dev\n",
+		"config/db.yaml":     "manifest: db.yaml\ndepends_on: [queue.yaml]\n",
+		"config/queue.yaml":  "manifest: queue.yaml\ndepends_on: [db.yaml]\n",
+		"config/api.yaml":    "manifest: api.yaml\ndepends_on: [db.yaml]\n",
+		"config/web.yaml":    "manifest: web.yaml\ndepends_on: [api.yaml]\n",
+	})
+
+	// Neither missing manifests nor the circular dependency stop the graph from loading
+	graph, err := LoadGraph(configDir, Selector{Stacks: []string{"api", "web"}})
+	if err != nil {
+		t.Fatalf("LoadGraph() error = %v", err)
+	}
+
+	var names []string
+	for _, stackInfo := range graph.Stacks {
+		names = append(names, stackInfo.Name)
+	}
+
+	if !reflect.DeepEqual(names, []string{"shop-dev-api", "shop-dev-web"}) {
+		t.Errorf("LoadGraph() stacks = %v, want [shop-dev-api shop-dev-web]", names)
+	}
+
+	expected := map[string][]string{"shop-dev-api": nil, "shop-dev-web": {"shop-dev-api"}}
+	if !reflect.DeepEqual(graph.DependsOn, expected) {
+		t.Errorf("LoadGraph() DependsOn = %v, want %v", graph.DependsOn, expected)
+	}
+
+	graph, err = LoadGraph(configDir, Selector{})
+	if err != nil {
+		t.Fatalf("LoadGraph() error = %v", err)
+	}
+
+	if cycle := graph.Cycle(); !reflect.DeepEqual(cycle, []string{"shop-dev-db", "shop-dev-queue", "shop-dev-db"}) {
+		t.Errorf("Cycle() = %v, want [shop-dev-db shop-dev-queue shop-dev-db]", cycle)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		selector Selector
+		expected string
+	}{
+		{
+			name:     "no stacks",
+			files:    map[string]string{"config/config.yaml": "project_code: shop\ncontext: dev\n"},
+			expected: "no config files found",
+		},
+		{
+			name:     "nothing matches the filter",
+			files:    map[string]string{"config/config.yaml": "project_code: shop\ncontext: dev\n", "config/api.yaml": "manifest: api.yaml\n"},
+			selector: Selector{Stacks: []string{"prod"}},
+			expected: "no stacks match prod",
+		},
+		{
+			name:     "incomplete config",
+			files:    map[string]string{"config/config.yaml": "project_code: shop\n", "config/api.yaml": "manifest: api.yaml\n"},
+			expected: "context not specified",
+		},
+		{
+			name:     "missing manifest",
+			files:    map[string]string{"config/config.yaml": "project_code: shop\ncontext: dev\n", "config/api.yaml": "manifest: api.yaml\n"},
+			expected: "manifest file not found: api.yaml",
+		},
+		{
+			name: "missing dependency",
+			files: map[string]string{
+				"config/config.yaml": "project_code: shop\ncontext: dev\n",
+				"config/api.yaml":    "manifest: api.yaml\ndepends_on: [db]\n",
+				"manifests/api.yaml": "kind: Deployment",
+			},
+			expected: "error resolving dependencies",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeProject(t, tt.files), Options{Selector: tt.selector})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Load() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package project
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Selector selects stacks of a project. The zero value selects every stack.
+type Selector struct {
+	// Stacks selects stacks matching any of these patterns. Empty selects every stack.
+	Stacks []string
+	// Exclude drops stacks matching any of these patterns.
+	Exclude []string
+	// Tags keeps only stacks whose tags have every one of these values.
+	Tags map[string]string
+	// WithDeps also selects every stack the matched stacks depend on, directly or transitively.
+	WithDeps bool
+	// WithDependents also selects every stack that depends on the matched stacks, directly or transitively.
+	WithDependents bool
+}
+
+// IsEmpty checks if the selector selects every stack.
+func (s Selector) IsEmpty() bool {
+	return len(s.Stacks) == 0 && len(s.Exclude) == 0 && len(s.Tags) == 0
+}
+
+// Validate checks that every pattern of the selector is a valid glob or regular expression.
+func (s Selector) Validate() error {
+	for _, pattern := range append(append([]string{}, s.Stacks...), s.Exclude...) {
+		err := validatePattern(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid stack pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// validatePattern checks that a regular expression pattern compiles and a glob pattern is well-formed.
+func validatePattern(pattern string) error {
+	if expr, ok := strings.CutPrefix(pattern, regexPrefix); ok {
+		_, err := regexp.Compile(expr)
+
+		return err
+	}
+
+	_, err := path.Match(normalizePattern(pattern), "")
+
+	return err
+}
+
+// Matches checks if a stack is selected, given its path below config/ (see StackPath), name and tags.
+// The dependencies and dependents added by WithDeps and WithDependents are selected by Load.
+func (s Selector) Matches(stackPath, stackName string, tags map[string]string) bool {
+	if len(s.Stacks) > 0 && !matchesAny(s.Stacks, stackPath, stackName) {
+		return false
+	}
+
+	if matchesAny(s.Exclude, stackPath, stackName) {
+		return false
+	}
+
+	for key, value := range s.Tags {
+		if tagValue, ok := tags[key]; !ok || tagValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String describes the selection, such as "prod/*-api excluding prod/legacy-api tagged tier=backend with dependencies".
+func (s Selector) String() string {
+	var parts []string
+
+	if len(s.Stacks) > 0 {
+		parts = append(parts, strings.Join(s.Stacks, " "))
+	}
+
+	if len(s.Exclude) > 0 {
+		parts = append(parts, "excluding "+strings.Join(s.Exclude, " "))
+	}
+
+	if len(s.Tags) > 0 {
+		parts = append(parts, "tagged "+formatTags(s.Tags))
+	}
+
+	switch {
+	case s.WithDeps && s.WithDependents:
+		parts = append(parts, "with dependencies and dependents")
+	case s.WithDeps:
+		parts = append(parts, "with dependencies")
+	case s.WithDependents:
+		parts = append(parts, "with dependents")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ParseTags parses tag selectors such as "tier=backend" or "tier=backend,team=payments".
+func ParseTags(selectors []string) (map[string]string, error) {
+	tags := make(map[string]string)
+
+	for _, selector := range selectors {
+		for requirement := range strings.SplitSeq(selector, ",") {
+			key, value, found := strings.Cut(strings.TrimSpace(requirement), "=")
+			if !found || key == "" {
+				return nil, fmt.Errorf("invalid tag selector %q, use key=value", requirement)
+			}
+
+			tags[key] = value
+		}
+	}
+
+	return tags, nil
+}
+
+// formatTags formats tags as key=value pairs sorted by key.
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// StackPath returns the path of a stack config file below the config directory, without
+// its extension and with forward slashes, such as "dev/app" for config/dev/app.yaml.
+func StackPath(configDir, configPath string) string {
+	relativePath, err := filepath.Rel(configDir, configPath)
+	if err != nil {
+		relativePath = configPath
+	}
+
+	relativePath = filepath.ToSlash(relativePath)
+
+	return strings.TrimSuffix(relativePath, filepath.Ext(relativePath))
+}
+
+// matchesAny checks if a stack matches any of the patterns.
+func matchesAny(patterns []string, stackPath, stackName string) bool {
+	for _, pattern := range patterns {
+		if MatchesPattern(pattern, stackPath, stackName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesPattern checks if a stack pattern matches a stack, given its path below config/ and its name:
+//
+//	prod           config/prod.yaml, every stack below config/prod/ and the stack named prod
+//	prod/api       config/prod/api.yaml and every stack below config/prod/api/
+//	prod/api.yaml  config/prod/api.yaml, the extension and a leading config/ are ignored
+//	prod/*-api     stack paths or names matching a glob, * doesn't match /
+//	re:^prod/.+  stack paths or names matching a regular expression anywhere
+//	shop-prod-api  the stack with that name
+func MatchesPattern(pattern, stackPath, stackName string) bool {
+	if expr, ok := strings.CutPrefix(pattern, regexPrefix); ok {
+		return matchesRegex(expr, stackPath, stackName)
+	}
+
+	pattern = normalizePattern(pattern)
+	if pattern == "" {
+		return false
+	}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		pathMatch, _ := path.Match(pattern, stackPath)
+		nameMatch, _ := path.Match(pattern, stackName)
+
+		return pathMatch || nameMatch
+	}
+
+	return stackPath == pattern || strings.HasPrefix(stackPath, pattern+"/") || stackName == pattern
+}
+
+// regexPrefix marks a stack pattern as a regular expression.
+const regexPrefix = "re:"
+
+// matchesRegex checks if a regular expression matches a stack path or name. Invalid
+// expressions match nothing, Validate reports them.
+func matchesRegex(expr, stackPath, stackName string) bool {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(stackPath) || re.MatchString(stackName)
+}
+
+// normalizePattern removes a leading config/ and a stack config file extension from a pattern.
+func normalizePattern(pattern string) string {
+	pattern = strings.Trim(strings.TrimPrefix(filepath.ToSlash(pattern), "config/"), "/")
+
+	switch ext := path.Ext(pattern); ext {
+	case ".yaml", ".yml", ".jinja", ".j2":
+		return strings.TrimSuffix(pattern, ext)
+	default:
+		return pattern
+	}
+}
@@ -0,0 +1,159 @@
+package project
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStackPath(t *testing.T) {
+	configDir := filepath.Join("project", "config")
+
+	tests := []struct {
+		configPath string
+		expected   string
+	}{
+		{configPath: filepath.Join(configDir, "app.yaml"), expected: "app"},
+		{configPath: filepath.Join(configDir, "dev", "app.yml"), expected: "dev/app"},
+		{configPath: filepath.Join(configDir, "prod", "web", "api.jinja"), expected: "prod/web/api"},
+	}
+
+	for _, tt := range tests {
+		result := StackPath(configDir, tt.configPath)
+		if result != tt.expected {
+			t.Errorf("StackPath(%s) = %s, want %s", tt.configPath, result, tt.expected)
+		}
+	}
+}
+
+func TestMatchesPattern(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		stackPath string
+		expected  bool
+	}{
+		{name: "exact path", pattern: "dev/app", stackPath: "dev/app", expected: true},
+		{name: "directory", pattern: "dev", stackPath: "dev/web/app", expected: true},
+		{name: "directory with trailing slash", pattern: "dev/", stackPath: "dev/app", expected: true},
+		{name: "file with extension", pattern: "dev/app.yaml", stackPath: "dev/app", expected: true},
+		{name: "config prefix", pattern: "config/dev/app.yml", stackPath: "dev/app", expected: true},
+		{name: "stack name", pattern: "shop-dev-app", stackPath: "dev/app", expected: true},
+		{name: "glob on path", pattern: "prod/*-api", stackPath: "prod/orders-api", expected: true},
+		{name: "glob on name", pattern: "shop-*-app", stackPath: "dev/app", expected: true},
+		{name: "glob with extension", pattern: "dev/*.yaml", stackPath: "dev/app", expected: true},
+		{name: "glob doesn't cross directories", pattern: "prod/*", stackPath: "prod/web/api", expected: false},
+		{name: "partial name doesn't match", pattern: "dev", stackPath: "dev-app", expected: false},
+		{name: "path prefix doesn't match", pattern: "dev/app", stackPath: "dev/app-worker", expected: false},
+		{name: "file name doesn't match in subdirectories", pattern: "app", stackPath: "dev/app", expected: false},
+		{name: "empty pattern", pattern: "", stackPath: "dev/app", expected: false},
+		{name: "regex on path", pattern: "re:^prod/.*-api$", stackPath: "prod/web/orders-api", expected: true},
+		{name: "regex on name", pattern: "re:-dev-", stackPath: "dev/app", expected: true},
+		{name: "regex matches anywhere", pattern: "re:app", stackPath: "prod/app-worker", expected: true},
+		{name: "regex without match", pattern: "re:^staging/", stackPath: "prod/api", expected: false},
+		{name: "invalid regex", pattern: "re:prod/(", stackPath: "prod/(", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MatchesPattern(tt.pattern, tt.stackPath, "shop-dev-app")
+			if result != tt.expected {
+				t.Errorf("MatchesPattern(%q, %q) = %v, want %v", tt.pattern, tt.stackPath, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	tags := map[string]string{"tier": "backend", "team": "payments"}
+
+	tests := []struct {
+		name     string
+		selector Selector
+		expected bool
+	}{
+		{name: "empty selector", selector: Selector{}, expected: true},
+		{name: "any stack pattern", selector: Selector{Stacks: []string{"dev", "prod"}}, expected: true},
+		{name: "no stack pattern", selector: Selector{Stacks: []string{"dev", "staging"}}, expected: false},
+		{name: "excluded", selector: Selector{Stacks: []string{"prod"}, Exclude: []string{"prod/*-api"}}, expected: false},
+		{name: "not excluded", selector: Selector{Exclude: []string{"prod/web"}}, expected: true},
+		{name: "matching tags", selector: Selector{Tags: map[string]string{"tier": "backend", "team": "payments"}}, expected: true},
+		{name: "different tag value", selector: Selector{Tags: map[string]string{"tier": "frontend"}}, expected: false},
+		{name: "missing tag", selector: Selector{Tags: map[string]string{"owner": "ops"}}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.selector.Matches("prod/orders-api", "shop-prod-orders-api", tags)
+			if result != tt.expected {
+				t.Errorf("Matches() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags([]string{"tier=backend,team=payments", "env="})
+	if err != nil {
+		t.Fatalf("ParseTags() error = %v", err)
+	}
+
+	expected := map[string]string{"tier": "backend", "team": "payments", "env": ""}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("ParseTags() = %v, want %v", tags, expected)
+	}
+
+	for _, invalid := range []string{"tier", "=backend", "tier=backend,"} {
+		_, err = ParseTags([]string{invalid})
+		if err == nil {
+			t.Errorf("ParseTags(%q) expected an error", invalid)
+		}
+	}
+}
+
+func TestSelectorString(t *testing.T) {
+	selector := Selector{
+		Stacks:  []string{"prod/*-api", "dev"},
+		Exclude: []string{"prod/legacy-api"},
+		Tags:    map[string]string{"tier": "backend", "team": "payments"},
+	}
+
+	expected := "prod/*-api dev excluding prod/legacy-api tagged team=payments,tier=backend"
+	if selector.String() != expected {
+		t.Errorf("String() = %q, want %q", selector.String(), expected)
+	}
+
+	selector.WithDeps = true
+	selector.WithDependents = true
+
+	expected += " with dependencies and dependents"
+	if selector.String() != expected {
+		t.Errorf("String() = %q, want %q", selector.String(), expected)
+	}
+
+	if (Selector{}).String() != "" || !(Selector{}).IsEmpty() {
+		t.Error("empty selector should have an empty description")
+	}
+}
+
+func TestSelectorValidate(t *testing.T) {
+	err := Selector{Stacks: []string{"prod/*"}, Exclude: []string{"dev"}}.Validate()
+	if err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	err = Selector{Exclude: []string{"prod/[api"}}.Validate()
+	if err == nil {
+		t.Error("Validate() expected an error for an invalid glob")
+	}
+
+	err = Selector{Stacks: []string{"re:^prod/(web|api)$"}}.Validate()
+	if err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	err = Selector{Stacks: []string{"re:prod/("}}.Validate()
+	if err == nil {
+		t.Error("Validate() expected an error for an invalid regular expression")
+	}
+}
@@ -5,6 +5,8 @@ Copyright © 2025 Ben Sapp ya.bsapp.ru
 package stack
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -153,6 +155,24 @@ func TestResolveDependencies_MissingDependency(t *testing.T) {
 	}
 }
 
+func TestResolveDependencies_DuplicateStackName(t *testing.T) {
+	stacks := []StackWithDependencies{
+		{StackInfo: &StackInfo{Name: "shop-prod-api", ConfigPath: "config/us/api.yaml"}, DependsOn: []string{}},
+		{StackInfo: &StackInfo{Name: "shop-prod-api", ConfigPath: "config/eu/api.yaml"}, DependsOn: []string{}},
+	}
+
+	_, err := ResolveDependencies(stacks)
+	if err == nil {
+		t.Errorf("expected error but got none")
+
+		return
+	}
+
+	if !containsString(err.Error(), `duplicate stack name "shop-prod-api" used by config/us/api.yaml and config/eu/api.yaml`) {
+		t.Errorf("expected duplicate stack name error, got '%s'", err.Error())
+	}
+}
+
 func TestResolveDependencies_SelfDependency(t *testing.T) {
 	stacks := []StackWithDependencies{
 		{StackInfo: &StackInfo{Name: "stack1"}, DependsOn: []string{"stack1"}},
@@ -374,6 +394,96 @@ func TestDetectCircularDependencies_MultipleComponents(t *testing.T) {
 	}
 }
 
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		graph    map[string][]string
+		expected []string
+	}{
+		{name: "no cycle", graph: map[string][]string{"api": {"db"}, "web": {"api", "db"}, "db": {}}},
+		{name: "self dependency", graph: map[string][]string{"api": {"api"}}, expected: []string{"api", "api"}},
+		{
+			name:     "full path",
+			graph:    map[string][]string{"web": {"api"}, "api": {"queue"}, "queue": {"worker"}, "worker": {"api"}},
+			expected: []string{"api", "queue", "worker", "api"},
+		},
+		{
+			name:     "cycle behind other stacks",
+			graph:    map[string][]string{"app": {"cache", "db"}, "cache": {}, "db": {"network"}, "network": {"db"}},
+			expected: []string{"db", "network", "db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle := findCycle(tt.graph)
+			if !reflect.DeepEqual(cycle, tt.expected) {
+				t.Errorf("findCycle() = %v, want %v", cycle, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveDependencyGraph_CycleError(t *testing.T) {
+	stacks := []StackWithDependencies{
+		{StackInfo: &StackInfo{Name: "web"}, DependsOn: []string{"api"}},
+		{StackInfo: &StackInfo{Name: "api"}, DependsOn: []string{"db"}},
+		{StackInfo: &StackInfo{Name: "db"}, DependsOn: []string{"web"}},
+	}
+
+	_, err := ResolveDependencyGraph(stacks)
+
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("expected a CycleError, got %v", err)
+	}
+
+	if err.Error() != "circular dependency detected: api -> db -> web -> api" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+
+	// Building the graph doesn't check for cycles
+	graph, err := BuildDependencyGraph(stacks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(graph.Cycle(), cycleErr.Path) {
+		t.Errorf("Cycle() = %v, want %v", graph.Cycle(), cycleErr.Path)
+	}
+}
+
+func TestDependencyGraphWaves(t *testing.T) {
+	graph := &DependencyGraph{
+		Stacks: []*StackInfo{{Name: "web"}, {Name: "worker"}, {Name: "api"}, {Name: "redis"}, {Name: "database"}, {Name: "loop"}},
+		DependsOn: map[string][]string{
+			"web":      {"api"},
+			"worker":   {"database", "redis"},
+			"api":      {"database", "redis", "external"},
+			"redis":    {},
+			"database": {},
+			"loop":     {"loop"},
+		},
+	}
+
+	var waves [][]string
+
+	for _, wave := range graph.Waves() {
+		var names []string
+		for _, stack := range wave {
+			names = append(names, stack.Name)
+		}
+
+		waves = append(waves, names)
+	}
+
+	// Stacks on a cycle never become ready and dependencies outside the graph are ignored
+	expected := [][]string{{"database", "redis"}, {"api", "worker"}, {"web"}}
+	if !reflect.DeepEqual(waves, expected) {
+		t.Errorf("Waves() = %v, want %v", waves, expected)
+	}
+}
+
 func TestTopologicalSort_SimpleLinear(t *testing.T) {
 	graph := map[string][]string{
 		"stack1": {"stack2"},
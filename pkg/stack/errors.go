@@ -0,0 +1,118 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoConfig is returned when no config.yaml applies to a stack config file.
+var ErrNoConfig = errors.New("no config.yaml found")
+
+// MissingFieldError is returned when none of the config.yaml files of a stack sets a required field.
+type MissingFieldError struct {
+	// Field is the YAML name of the missing field, such as "context".
+	Field string
+	// ConfigPath is the stack config file whose config chain lacks the field.
+	ConfigPath string
+	// Files are the config.yaml files that were merged.
+	Files []string
+}
+
+// Error implements error.
+func (e *MissingFieldError) Error() string {
+	return fmt.Sprintf("%s not specified in any config.yaml for %s", e.Field, e.ConfigPath)
+}
+
+// SyntaxError is returned when a config file is not valid YAML or a value has the wrong type.
+type SyntaxError struct {
+	Path string
+	// Line and Column locate the problem, or are 0 when the YAML parser doesn't report them.
+	Line    int
+	Column  int
+	Message string
+}
+
+// Error implements error.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", position(e.Path, e.Line, e.Column), e.Message)
+}
+
+// UnknownKeyError is returned when a config file contains a key frank doesn't know, which is usually a typo.
+type UnknownKeyError struct {
+	Path   string
+	Key    string
+	Line   int
+	Column int
+}
+
+// Error implements error.
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("%s: unknown key %q", position(e.Path, e.Line, e.Column), e.Key)
+}
+
+// DuplicateStackNameError is returned when two stack config files produce the same stack name.
+type DuplicateStackNameError struct {
+	Name        string
+	ConfigPaths []string
+}
+
+// Error implements error.
+func (e *DuplicateStackNameError) Error() string {
+	return fmt.Sprintf("duplicate stack name %q used by %s, set stack_name_template to make stack names unique",
+		e.Name, strings.Join(e.ConfigPaths, " and "))
+}
+
+// CycleError is returned when stacks depend on each other in a circle.
+type CycleError struct {
+	// Path lists the stack names along the cycle, each depending on the next. It starts and ends with the same stack.
+	Path []string
+}
+
+// Error implements error.
+func (e *CycleError) Error() string {
+	return "circular dependency detected: " + strings.Join(e.Path, " -> ")
+}
+
+// position formats a file position as path:line:column, leaving out the parts that are unknown.
+func position(path string, line, column int) string {
+	switch {
+	case line == 0:
+		return path
+	case column == 0:
+		return fmt.Sprintf("%s:%d", path, line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", path, line, column)
+	}
+}
+
+// yamlErrorLine matches the line number in yaml.v3 error messages such as "yaml: line 3: did not find expected key".
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// newSyntaxError converts a yaml.v3 decoding error into a SyntaxError for a file.
+func newSyntaxError(path string, err error) *SyntaxError {
+	message := err.Error()
+
+	// Type errors carry one message per offending value, report the first one
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		message = typeErr.Errors[0]
+	}
+
+	match := yamlErrorLine.FindStringSubmatch(message)
+	if match == nil {
+		return &SyntaxError{Path: path, Message: message}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+
+	return &SyntaxError{Path: path, Line: line, Message: match[2]}
+}
@@ -0,0 +1,129 @@
+package stack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetStackInfoErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected error
+	}{
+		{
+			name:     "missing context",
+			config:   "project_code: shop\n",
+			expected: &MissingFieldError{Field: "context"},
+		},
+		{
+			name:     "missing project_code",
+			config:   "context: dev\n",
+			expected: &MissingFieldError{Field: "project_code"},
+		},
+		{
+			name:     "syntax error",
+			config:   "context: dev\n\tproject_code: shop\n",
+			expected: &SyntaxError{Line: 2, Message: "found a tab character that violates indentation"},
+		},
+		{
+			name:     "wrong type",
+			config:   "context: dev\nproject_code:\n  - shop\n",
+			expected: &SyntaxError{Line: 3, Column: 3, Message: "cannot unmarshal !!seq into string"},
+		},
+		{
+			name:     "unknown key",
+			config:   "context: dev\nproject_code: shop\n\nnamepsace: web\n",
+			expected: &UnknownKeyError{Key: "namepsace", Line: 4, Column: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configDir := filepath.Join(t.TempDir(), "config")
+
+			err := os.MkdirAll(configDir, 0o750)
+			if err != nil {
+				t.Fatalf("Failed to create config directory: %v", err)
+			}
+
+			writeTestFile(t, filepath.Join(configDir, "config.yaml"), tt.config)
+
+			stackInfo, err := GetStackInfo(filepath.Join(configDir, "api.yaml"))
+			if stackInfo != nil {
+				t.Errorf("GetStackInfo() returned stack info %+v for an invalid config", stackInfo)
+			}
+
+			if !reflect.DeepEqual(withoutPaths(err), tt.expected) {
+				t.Errorf("GetStackInfo() error = %#v, want %#v", withoutPaths(err), tt.expected)
+			}
+		})
+	}
+}
+
+// withoutPaths returns a copy of a config error without its file paths, so it can be compared to an expected error.
+func withoutPaths(err error) error {
+	var (
+		missingErr *MissingFieldError
+		syntaxErr  *SyntaxError
+		keyErr     *UnknownKeyError
+	)
+
+	switch {
+	case errors.As(err, &missingErr):
+		return &MissingFieldError{Field: missingErr.Field}
+	case errors.As(err, &syntaxErr):
+		return &SyntaxError{Line: syntaxErr.Line, Column: syntaxErr.Column, Message: syntaxErr.Message}
+	case errors.As(err, &keyErr):
+		return &UnknownKeyError{Key: keyErr.Key, Line: keyErr.Line, Column: keyErr.Column}
+	default:
+		return err
+	}
+}
+
+func TestGetStackInfoWithoutConfig(t *testing.T) {
+	_, err := GetStackInfo(filepath.Join(t.TempDir(), "api.yaml"))
+	if !errors.Is(err, ErrNoConfig) {
+		t.Errorf("GetStackInfo() error = %v, want ErrNoConfig", err)
+	}
+}
+
+func TestListStacksKeepsIncompleteStacks(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+
+	err := os.MkdirAll(filepath.Join(configDir, "dev"), 0o750)
+	if err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(configDir, "config.yaml"), "context: prod\nproject_code: shop\n")
+	writeTestFile(t, filepath.Join(configDir, "api.yaml"), "manifest: api.yaml\n")
+	writeTestFile(t, filepath.Join(configDir, "dev", "config.yaml"), "contxt: dev\n")
+	writeTestFile(t, filepath.Join(configDir, "dev", "api.yaml"), "manifest: api.yaml\n")
+
+	listings, err := ListStacks(configDir)
+	if err != nil {
+		t.Fatalf("ListStacks() error = %v", err)
+	}
+
+	if len(listings) != 2 {
+		t.Fatalf("ListStacks() returned %d stacks, want 2", len(listings))
+	}
+
+	if listings[0].Err != nil || listings[0].Info.Name != "shop-prod-api" {
+		t.Errorf("ListStacks()[0] = %+v, want shop-prod-api", listings[0])
+	}
+
+	var keyErr *UnknownKeyError
+	if !errors.As(listings[1].Err, &keyErr) {
+		t.Errorf("ListStacks()[1].Err = %v, want UnknownKeyError", listings[1].Err)
+	}
+
+	_, err = FindStacks(configDir)
+	if !errors.As(err, &keyErr) {
+		t.Errorf("FindStacks() error = %v, want UnknownKeyError", err)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package stack
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// stackNamePlaceholder matches a {{name}} placeholder in a stack_name_template.
+var stackNamePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_]*)\s*\}\}`)
+
+// repeatedDashes matches runs of dashes left behind by empty placeholders.
+var repeatedDashes = regexp.MustCompile(`-{2,}`)
+
+// StackNameVars returns the values available to a stack_name_template for a config file:
+//
+//	project_code, context, namespace, app  the effective config values
+//	env                                    the first directory below config/ (prod for config/prod/web/api.yaml)
+//	dir                                    the directories between env and the file (web for config/prod/web/api.yaml)
+//	file                                   the config file name without extension (api)
+//	path                                   the config path below config/ without extension (prod-web-api)
+func StackNameVars(config *Config, configFilePath string) map[string]string {
+	relativePath := filepath.ToSlash(getRelativeConfigPath(configFilePath))
+	relativePath = strings.TrimSuffix(relativePath, filepath.Ext(relativePath))
+	parts := strings.Split(relativePath, "/")
+
+	vars := map[string]string{
+		"project_code": config.ProjectCode,
+		"context":      config.Context,
+		"namespace":    config.Namespace,
+		"app":          config.App,
+		"env":          "",
+		"dir":          "",
+		"file":         parts[len(parts)-1],
+		"path":         strings.Join(parts, "-"),
+	}
+
+	if vars["app"] == "" {
+		vars["app"] = extractAppNameFromFilename(configFilePath)
+	}
+
+	if len(parts) > 1 {
+		vars["env"] = parts[0]
+		vars["dir"] = strings.Join(parts[1:len(parts)-1], "-")
+	}
+
+	return vars
+}
+
+// StackName returns the name of the stack defined by a config file. It renders the config's
+// stack_name_template when one is set and falls back to project_code-context-filename otherwise.
+func StackName(config *Config, configFilePath string) (string, error) {
+	if config.StackNameTemplate == "" {
+		return GenerateStackName(config.ProjectCode, config.Context, configFilePath), nil
+	}
+
+	return RenderStackName(config.StackNameTemplate, StackNameVars(config, configFilePath))
+}
+
+// RenderStackName renders a stack_name_template such as "{{project_code}}-{{env}}-{{dir}}-{{file}}".
+// The result is cleaned up like generated names, and dashes left by empty placeholders are collapsed.
+func RenderStackName(template string, vars map[string]string) (string, error) {
+	var renderErr error
+
+	stackName := stackNamePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := stackNamePlaceholder.FindStringSubmatch(placeholder)[1]
+
+		value, ok := vars[name]
+		if !ok && renderErr == nil {
+			renderErr = fmt.Errorf("unknown placeholder %s in stack_name_template %q", placeholder, template)
+		}
+
+		return value
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+
+	// Clean up the stack name the same way as generated names
+	stackName = strings.ToLower(stackName)
+	stackName = strings.NewReplacer("_", "-", "/", "-").Replace(stackName)
+	stackName = strings.Trim(repeatedDashes.ReplaceAllString(stackName, "-"), "-")
+
+	if stackName == "" {
+		return "", fmt.Errorf("stack_name_template %q renders an empty stack name", template)
+	}
+
+	return stackName, nil
+}
+
+// ValidateStackNames checks that no two stacks share a name. Stack names identify the resources of a stack
+// in the cluster, so a duplicate would make apply, prune and delete act on another stack's resources.
+func ValidateStackNames(stacks []*StackInfo) error {
+	configPaths := make(map[string]string)
+
+	for _, stackInfo := range stacks {
+		if existing, exists := configPaths[stackInfo.Name]; exists {
+			return &DuplicateStackNameError{Name: stackInfo.Name, ConfigPaths: []string{existing, stackInfo.ConfigPath}}
+		}
+
+		configPaths[stackInfo.Name] = stackInfo.ConfigPath
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStackName(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *Config
+		configPath string
+		expected   string
+		expectErr  bool
+	}{
+		{
+			name:       "default naming scheme",
+			config:     &Config{ProjectCode: "shop", Context: "prod"},
+			configPath: "/project/config/prod/web/api.yaml",
+			expected:   "shop-prod-api",
+		},
+		{
+			name:       "environment, directory and file",
+			config:     &Config{ProjectCode: "shop", Context: "prod", StackNameTemplate: "{{project_code}}-{{env}}-{{dir}}-{{file}}"},
+			configPath: "/project/config/prod/web/api.yaml",
+			expected:   "shop-prod-web-api",
+		},
+		{
+			name:       "empty placeholders collapse",
+			config:     &Config{ProjectCode: "shop", Context: "prod", StackNameTemplate: "{{project_code}}-{{env}}-{{dir}}-{{file}}"},
+			configPath: "/project/config/api.yaml",
+			expected:   "shop-api",
+		},
+		{
+			name:       "full config path with cleanup",
+			config:     &Config{ProjectCode: "Shop", Context: "prod", StackNameTemplate: "{{ project_code }}_{{path}}"},
+			configPath: "/project/config/prod/us_east/api.yaml",
+			expected:   "shop-prod-us-east-api",
+		},
+		{
+			name:       "app defaults to the file name",
+			config:     &Config{ProjectCode: "shop", Context: "prod", StackNameTemplate: "{{context}}-{{app}}"},
+			configPath: "/project/config/prod/api.yaml",
+			expected:   "prod-api",
+		},
+		{
+			name:       "unknown placeholder",
+			config:     &Config{ProjectCode: "shop", Context: "prod", StackNameTemplate: "{{project_code}}-{{cluster}}"},
+			configPath: "/project/config/prod/api.yaml",
+			expectErr:  true,
+		},
+		{
+			name:       "empty stack name",
+			config:     &Config{ProjectCode: "shop", Context: "prod", StackNameTemplate: "{{dir}}"},
+			configPath: "/project/config/prod/api.yaml",
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := StackName(tt.config, tt.configPath)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("StackName() error = %v, expectErr %v", err, tt.expectErr)
+			}
+
+			if result != tt.expected {
+				t.Errorf("StackName() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindStacksWithStackNameTemplate(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+
+	for _, dir := range []string{"us", "eu"} {
+		err := os.MkdirAll(filepath.Join(configDir, dir), 0o750)
+		if err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		writeTestFile(t, filepath.Join(configDir, dir, "api.yaml"), "manifest: api.yaml\n")
+	}
+
+	writeTestFile(t, filepath.Join(configDir, "config.yaml"), "context: prod\nproject_code: shop\n")
+
+	stacks, err := FindStacks(configDir)
+	if err != nil {
+		t.Fatalf("FindStacks() error = %v", err)
+	}
+
+	err = ValidateStackNames(stacks)
+	if err == nil {
+		t.Fatal("ValidateStackNames() expected an error for the default naming scheme")
+	}
+
+	writeTestFile(t, filepath.Join(configDir, "config.yaml"), "context: prod\nproject_code: shop\nstack_name_template: \"{{project_code}}-{{env}}-{{file}}\"\n")
+
+	stacks, err = FindStacks(configDir)
+	if err != nil {
+		t.Fatalf("FindStacks() error = %v", err)
+	}
+
+	err = ValidateStackNames(stacks)
+	if err != nil {
+		t.Fatalf("ValidateStackNames() error = %v", err)
+	}
+
+	if stacks[0].Name != "shop-eu-api" || stacks[1].Name != "shop-us-api" {
+		t.Errorf("FindStacks() names = %s, %s, want shop-eu-api, shop-us-api", stacks[0].Name, stacks[1].Name)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -16,11 +18,13 @@ import (
 
 // Config represents the base configuration structure.
 type Config struct {
-	Context     string `yaml:"context"`
-	ProjectCode string `yaml:"project_code"`
-	Namespace   string `yaml:"namespace"`
-	App         string `yaml:"app"`
-	Version     string `yaml:"version"`
+	Context           string         `yaml:"context"`
+	ProjectCode       string         `yaml:"project_code"`
+	Namespace         string         `yaml:"namespace"`
+	App               string         `yaml:"app"`
+	Version           string         `yaml:"version"`
+	Vars              map[string]any `yaml:"vars"`
+	StackNameTemplate string         `yaml:"stack_name_template"`
 }
 
 // StackInfo represents information about a stack.
@@ -31,6 +35,7 @@ type StackInfo struct {
 	Namespace   string
 	App         string
 	Version     string
+	Vars        map[string]any // Template vars inherited from config.yaml files
 	ConfigPath  string
 }
 
@@ -50,65 +55,172 @@ func GenerateStackName(projectCode, context, configFilePath string) string {
 	return stackName
 }
 
-// GenerateFallbackStackName creates a fallback stack name when config reading fails.
-func GenerateFallbackStackName(configFilePath string) string {
-	// Get the file name without extension
-	fileName := filepath.Base(configFilePath)
-	fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
-
-	// Get the directory name as context
-	dirName := filepath.Base(filepath.Dir(configFilePath))
-	if dirName == "." {
-		dirName = "unknown"
-	}
-
-	// Generate fallback stack name: unknown-context-filename
-	stackName := fmt.Sprintf("unknown-%s-%s", dirName, fileName)
+// ConfigFields lists the inheritable config fields in the order they are displayed.
+var ConfigFields = []string{"context", "project_code", "namespace", "app", "version", "stack_name_template"}
 
-	// Clean up the stack name
-	stackName = strings.ReplaceAll(stackName, "_", "-")
-	stackName = strings.ToLower(stackName)
+// ResolvedConfig is the effective configuration for a config file together with where each value came from.
+type ResolvedConfig struct {
+	Config *Config
+	// Files lists the config.yaml files that were merged, from the config root down to the file's directory.
+	Files []string
+	// Sources maps each config field to the config.yaml file that set its effective value.
+	Sources map[string]string
+}
 
-	return stackName
+// Field returns the value of a config field by its YAML name.
+func (c *Config) Field(name string) string {
+	switch name {
+	case "context":
+		return c.Context
+	case "project_code":
+		return c.ProjectCode
+	case "namespace":
+		return c.Namespace
+	case "app":
+		return c.App
+	case "version":
+		return c.Version
+	case "stack_name_template":
+		return c.StackNameTemplate
+	default:
+		return ""
+	}
 }
 
 // ReadConfigForFile reads the context configuration with inheritance support for a specific file.
 func ReadConfigForFile(configFilePath string) (*Config, error) {
-	// Determine the config directory for this file
-	configDir := filepath.Dir(configFilePath)
+	resolved, err := ResolveConfigForFile(configFilePath)
+	if err != nil {
+		return nil, err
+	}
 
-	// Start with the config in the same directory as the file
-	configPath := filepath.Join(configDir, "config.yaml")
+	return resolved.Config, nil
+}
 
-	config, err := readConfigFile(configPath)
+// ResolveConfigForFile merges every config.yaml from the config root down to the file's directory
+// (deeper files override their ancestors) and records which file set each value.
+func ResolveConfigForFile(configFilePath string) (*ResolvedConfig, error) {
+	files, err := configChain(filepath.Dir(configFilePath))
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if we're in a subdirectory and need to inherit from parent
-	currentDir := configDir
-	parentDir := filepath.Dir(currentDir)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoConfig, configFilePath)
+	}
 
-	// If we're in a subdirectory (like config/dev/), try to read parent config
-	if filepath.Base(currentDir) != "config" && filepath.Base(currentDir) != "." {
-		parentConfigPath := filepath.Join(parentDir, "config.yaml")
+	resolved := &ResolvedConfig{
+		Config:  &Config{},
+		Files:   files,
+		Sources: make(map[string]string),
+	}
 
-		parentConfig, err := readConfigFile(parentConfigPath)
-		if err == nil {
-			// Merge parent config with child config (child overrides parent)
-			config = mergeConfigs(parentConfig, config)
+	for _, configPath := range files {
+		config, err := readConfigFile(configPath)
+		if err != nil {
+			return nil, err
 		}
+
+		resolved.Config = mergeConfigs(resolved.Config, config)
+		recordSources(resolved.Sources, config, configPath)
 	}
 
-	if config.Context == "" {
-		return nil, errors.New("context not specified in config files")
+	if resolved.Config.Context == "" {
+		return nil, &MissingFieldError{Field: "context", ConfigPath: configFilePath, Files: files}
 	}
 
-	if config.ProjectCode == "" {
-		return nil, errors.New("project_code not specified in config files")
+	if resolved.Config.ProjectCode == "" {
+		return nil, &MissingFieldError{Field: "project_code", ConfigPath: configFilePath, Files: files}
+	}
+
+	return resolved, nil
+}
+
+// configChain returns the config.yaml (or config.yml) files that apply to a directory, ordered from the config root down.
+// Inside a config directory every ancestor up to it is considered and missing config.yaml files are skipped.
+// Outside of one, the chain ends at the first directory without a config.yaml.
+func configChain(dir string) ([]string, error) {
+	insideConfigDir := hasConfigDirAncestor(dir)
+
+	var chain []string
+
+	for {
+		configPath, err := configFileIn(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		exists := configPath != ""
+		if exists {
+			chain = append(chain, configPath)
+		}
+
+		parent := filepath.Dir(dir)
+		if filepath.Base(dir) == "config" || parent == dir || (!insideConfigDir && !exists) {
+			break
+		}
+
+		dir = parent
+	}
+
+	slices.Reverse(chain)
+
+	return chain, nil
+}
+
+// configFileIn returns the config.yaml or config.yml of a directory, or "" when it has neither.
+// A directory with both is an error because it's unclear which one should apply.
+func configFileIn(dir string) (string, error) {
+	yamlPath := filepath.Join(dir, "config.yaml")
+	ymlPath := filepath.Join(dir, "config.yml")
+
+	switch yamlExists, ymlExists := fileExists(yamlPath), fileExists(ymlPath); {
+	case yamlExists && ymlExists:
+		return "", fmt.Errorf("%s has both config.yaml and config.yml, keep only one", dir)
+	case yamlExists:
+		return yamlPath, nil
+	case ymlExists:
+		return ymlPath, nil
+	default:
+		return "", nil
+	}
+}
+
+// hasConfigDirAncestor checks whether a directory is a config directory or inside one.
+func hasConfigDirAncestor(dir string) bool {
+	for {
+		if filepath.Base(dir) == "config" {
+			return true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+
+		dir = parent
+	}
+}
+
+// fileExists checks if a regular file exists.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && info.Mode().IsRegular()
+}
+
+// recordSources records the config file as the source of every field and top-level var it sets.
+// Vars are recorded as "vars.<name>".
+func recordSources(sources map[string]string, config *Config, configPath string) {
+	for _, field := range ConfigFields {
+		if config.Field(field) != "" {
+			sources[field] = configPath
+		}
 	}
 
-	return config, nil
+	for name := range config.Vars {
+		sources["vars."+name] = configPath
+	}
 }
 
 // extractAppNameFromFilename extracts the app name from a config file path.
@@ -123,24 +235,18 @@ func extractAppNameFromFilename(configFilePath string) string {
 	return fileName
 }
 
-// GetStackInfo extracts stack information from a config file path.
+// GetStackInfo extracts stack information from a config file path. Config problems are returned
+// as ErrNoConfig, *MissingFieldError, *SyntaxError or *UnknownKeyError.
 func GetStackInfo(configFilePath string) (*StackInfo, error) {
 	config, err := ReadConfigForFile(configFilePath)
 	if err != nil {
-		// Return fallback stack info if config reading fails
-		//nolint:nilerr
-		return &StackInfo{
-			Name:        GenerateFallbackStackName(configFilePath),
-			Context:     "unknown",
-			ProjectCode: "unknown",
-			Namespace:   "",
-			App:         extractAppNameFromFilename(configFilePath),
-			Version:     "",
-			ConfigPath:  configFilePath,
-		}, nil
+		return nil, err
 	}
 
-	stackName := GenerateStackName(config.ProjectCode, config.Context, configFilePath)
+	stackName, err := StackName(config, configFilePath)
+	if err != nil {
+		return nil, err
+	}
 
 	// Extract app name from filename if not specified in config
 	appName := config.App
@@ -155,35 +261,89 @@ func GetStackInfo(configFilePath string) (*StackInfo, error) {
 		Namespace:   config.Namespace,
 		App:         appName,
 		Version:     config.Version,
+		Vars:        config.Vars,
 		ConfigPath:  configFilePath,
 	}, nil
 }
 
-// readConfigFile reads a single config file.
+// readConfigFile reads a single config file and rejects unknown keys.
 func readConfigFile(configPath string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", configPath, err)
+	}
+
+	var document yaml.Node
+
+	err = yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, newSyntaxError(configPath, err)
+	}
+
+	// An empty file is an empty config
+	if len(document.Content) == 0 {
+		return &Config{}, nil
+	}
+
+	err = checkConfigKeys(configPath, document.Content[0])
 	if err != nil {
 		return nil, err
 	}
 
 	var config Config
 
-	err = yaml.Unmarshal(data, &config)
+	err = document.Content[0].Decode(&config)
 	if err != nil {
-		return nil, err
+		syntaxErr := newSyntaxError(configPath, err)
+		syntaxErr.Column = valueColumn(document.Content[0], syntaxErr.Line)
+
+		return nil, syntaxErr
 	}
 
 	return &config, nil
 }
 
+// valueColumn returns the column of the top-level value on a line, or 0 if there is none.
+func valueColumn(node *yaml.Node, line int) int {
+	if node.Kind != yaml.MappingNode {
+		return 0
+	}
+
+	for i := 1; i < len(node.Content); i += 2 {
+		if node.Content[i].Line == line {
+			return node.Content[i].Column
+		}
+	}
+
+	return 0
+}
+
+// checkConfigKeys returns an UnknownKeyError for the first top-level key that isn't a config field.
+func checkConfigKeys(configPath string, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if key.Value != "vars" && !slices.Contains(ConfigFields, key.Value) {
+			return &UnknownKeyError{Path: configPath, Key: key.Value, Line: key.Line, Column: key.Column}
+		}
+	}
+
+	return nil
+}
+
 // mergeConfigs merges parent and child configs (child overrides parent).
 func mergeConfigs(parent, child *Config) *Config {
 	result := &Config{
-		Context:     parent.Context,
-		ProjectCode: parent.ProjectCode,
-		Namespace:   parent.Namespace,
-		App:         parent.App,
-		Version:     parent.Version,
+		Context:           parent.Context,
+		ProjectCode:       parent.ProjectCode,
+		Namespace:         parent.Namespace,
+		App:               parent.App,
+		Version:           parent.Version,
+		Vars:              MergeVars(parent.Vars, child.Vars),
+		StackNameTemplate: parent.StackNameTemplate,
 	}
 
 	// Child overrides parent if set
@@ -207,6 +367,10 @@ func mergeConfigs(parent, child *Config) *Config {
 		result.Version = child.Version
 	}
 
+	if child.StackNameTemplate != "" {
+		result.StackNameTemplate = child.StackNameTemplate
+	}
+
 	return result
 }
 
@@ -216,8 +380,33 @@ type StackWithDependencies struct {
 	DependsOn []string
 }
 
+// DependencyGraph is a resolved set of stacks together with the stacks each one depends on.
+type DependencyGraph struct {
+	// Stacks lists every stack in a valid sequential execution order.
+	Stacks []*StackInfo
+	// DependsOn maps each stack name to the names of the stacks it depends on.
+	DependsOn map[string][]string
+}
+
 // ResolveDependencies resolves the execution order for stacks based on their dependencies.
 func ResolveDependencies(stacksWithDeps []StackWithDependencies) ([]*StackInfo, error) {
+	graph, err := ResolveDependencyGraph(stacksWithDeps)
+	if err != nil {
+		return nil, err
+	}
+
+	return graph.Stacks, nil
+}
+
+// BuildDependencyGraph validates the stack names and dependencies and returns the dependency graph with the
+// stacks in the given order. Unlike ResolveDependencyGraph it doesn't check for circular dependencies.
+func BuildDependencyGraph(stacksWithDeps []StackWithDependencies) (*DependencyGraph, error) {
+	// Two stacks with the same name would silently replace each other below
+	err := ValidateStackNames(stackInfos(stacksWithDeps))
+	if err != nil {
+		return nil, err
+	}
+
 	// Create maps for both stack names and config file paths
 	stackMap := make(map[string]*StackInfo)
 	configPathMap := make(map[string]*StackInfo)
@@ -241,19 +430,30 @@ func ResolveDependencies(stacksWithDeps []StackWithDependencies) ([]*StackInfo,
 	}
 
 	// Validate dependencies exist
-	err := validateDependencies(stackMap, stacksWithDeps)
+	err = validateDependencies(stackMap, stacksWithDeps)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DependencyGraph{Stacks: stackInfos(stacksWithDeps), DependsOn: graph}, nil
+}
+
+// ResolveDependencyGraph validates the dependencies between stacks and returns the dependency graph
+// along with a sequential execution order in which dependencies come before dependents.
+func ResolveDependencyGraph(stacksWithDeps []StackWithDependencies) (*DependencyGraph, error) {
+	graph, err := BuildDependencyGraph(stacksWithDeps)
 	if err != nil {
 		return nil, err
 	}
 
 	// Check for circular dependencies
-	err = detectCircularDependencies(graph)
+	err = detectCircularDependencies(graph.DependsOn)
 	if err != nil {
 		return nil, err
 	}
 
 	// Topological sort to determine execution order
-	executionOrder, err := topologicalSort(graph)
+	executionOrder, err := topologicalSort(graph.DependsOn)
 	if err != nil {
 		return nil, err
 	}
@@ -264,6 +464,11 @@ func ResolveDependencies(stacksWithDeps []StackWithDependencies) ([]*StackInfo,
 		executionOrder[i], executionOrder[j] = executionOrder[j], executionOrder[i]
 	}
 
+	stackMap := make(map[string]*StackInfo, len(graph.Stacks))
+	for _, stack := range graph.Stacks {
+		stackMap[stack.Name] = stack
+	}
+
 	// Convert back to StackInfo slice in execution order
 	var orderedStacks []*StackInfo
 
@@ -273,7 +478,84 @@ func ResolveDependencies(stacksWithDeps []StackWithDependencies) ([]*StackInfo,
 		}
 	}
 
-	return orderedStacks, nil
+	graph.Stacks = orderedStacks
+
+	return graph, nil
+}
+
+// Cycle returns the path of a circular dependency in the graph, starting and ending with the same stack,
+// or nil if the graph has no cycles.
+func (g *DependencyGraph) Cycle() []string {
+	return findCycle(g.DependsOn)
+}
+
+// Waves groups the stacks into execution waves. Each stack is in the wave after the last of its
+// dependencies, so the stacks of a wave can run concurrently. Stacks within a wave are in lexical
+// order, dependencies on stacks outside the graph are ignored and stacks on or behind a circular
+// dependency are left out.
+func (g *DependencyGraph) Waves() [][]*StackInfo {
+	done := make(map[string]bool, len(g.Stacks))
+	for _, stack := range g.Stacks {
+		done[stack.Name] = false
+	}
+
+	var waves [][]*StackInfo
+
+	remaining := g.Stacks
+	for len(remaining) > 0 {
+		wave, rest := readyStacks(remaining, g.DependsOn, done)
+		if len(wave) == 0 {
+			break
+		}
+
+		for _, stack := range wave {
+			done[stack.Name] = true
+		}
+
+		sort.Slice(wave, func(i, j int) bool { return wave[i].Name < wave[j].Name })
+
+		waves = append(waves, wave)
+		remaining = rest
+	}
+
+	return waves
+}
+
+// readyStacks splits stacks into those whose dependencies are all done and the rest. Dependencies
+// missing from done are outside the graph and count as done.
+func readyStacks(stacks []*StackInfo, dependsOn map[string][]string, done map[string]bool) ([]*StackInfo, []*StackInfo) {
+	var ready, rest []*StackInfo
+
+	for _, stack := range stacks {
+		if waitsForAny(dependsOn[stack.Name], done) {
+			rest = append(rest, stack)
+		} else {
+			ready = append(ready, stack)
+		}
+	}
+
+	return ready, rest
+}
+
+// waitsForAny checks if any of the dependencies is part of the graph and not done yet.
+func waitsForAny(deps []string, done map[string]bool) bool {
+	for _, dep := range deps {
+		if finished, inGraph := done[dep]; inGraph && !finished {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stackInfos returns the stack info of every stack.
+func stackInfos(stacksWithDeps []StackWithDependencies) []*StackInfo {
+	stacks := make([]*StackInfo, 0, len(stacksWithDeps))
+	for _, stackWithDep := range stacksWithDeps {
+		stacks = append(stacks, stackWithDep.StackInfo)
+	}
+
+	return stacks
 }
 
 // validateDependencies checks that all dependencies exist.
@@ -300,40 +582,63 @@ func validateDependencies(stackMap map[string]*StackInfo, stacksWithDeps []Stack
 	return nil
 }
 
-// detectCircularDependencies checks for circular dependencies using DFS.
+// detectCircularDependencies checks for circular dependencies and reports the full path of the first cycle found.
 func detectCircularDependencies(graph map[string][]string) error {
-	visited := make(map[string]bool)
-	recStack := make(map[string]bool)
+	cycle := findCycle(graph)
+	if cycle != nil {
+		return &CycleError{Path: cycle}
+	}
+
+	return nil
+}
 
+// findCycle returns the path of the first circular dependency found by a depth-first search, starting and
+// ending with the same stack, or nil if there is none. Stacks are visited in lexical order so the reported
+// cycle doesn't change between runs.
+func findCycle(graph map[string][]string) []string {
+	nodes := make([]string, 0, len(graph))
 	for node := range graph {
-		if !visited[node] {
-			err := detectCircularDependenciesFromNode(graph, node, visited, recStack)
-			if err != nil {
-				return err
-			}
+		nodes = append(nodes, node)
+	}
+
+	sort.Strings(nodes)
+
+	visited := make(map[string]bool)
+
+	for _, node := range nodes {
+		if visited[node] {
+			continue
+		}
+
+		cycle := findCycleFromNode(graph, node, visited, nil)
+		if cycle != nil {
+			return cycle
 		}
 	}
 
 	return nil
 }
 
-// detectCircularDependenciesFromNode performs DFS from a specific node to detect cycles.
-func detectCircularDependenciesFromNode(graph map[string][]string, node string, visited, recStack map[string]bool) error {
+// findCycleFromNode performs a depth-first search from a node. The path holds the stacks currently being
+// visited, a dependency on one of them closes a cycle.
+func findCycleFromNode(graph map[string][]string, node string, visited map[string]bool, path []string) []string {
 	visited[node] = true
-	recStack[node] = true
+	path = append(path, node)
 
 	for _, neighbor := range graph[node] {
-		if !visited[neighbor] {
-			err := detectCircularDependenciesFromNode(graph, neighbor, visited, recStack)
-			if err != nil {
-				return err
-			}
-		} else if recStack[neighbor] {
-			return fmt.Errorf("circular dependency detected: %s -> %s", node, neighbor)
+		if i := slices.Index(path, neighbor); i >= 0 {
+			return append(slices.Clone(path[i:]), neighbor)
+		}
+
+		if visited[neighbor] {
+			continue
 		}
-	}
 
-	recStack[node] = false
+		cycle := findCycleFromNode(graph, neighbor, visited, path)
+		if cycle != nil {
+			return cycle
+		}
+	}
 
 	return nil
 }
@@ -433,6 +738,9 @@ func findNodesWithNoIncomingEdges(inDegree map[string]int) []string {
 		}
 	}
 
+	// Sort so that the execution order is deterministic
+	sort.Strings(queue)
+
 	return queue
 }
 
@@ -458,3 +766,69 @@ func processTopologicalQueue(graph map[string][]string, inDegree map[string]int,
 
 	return result, nil
 }
+
+// StackListing is a stack config file together with its stack info, or the error that prevented reading it.
+type StackListing struct {
+	ConfigPath string
+	Info       *StackInfo
+	Err        error
+}
+
+// ListStacks returns every stack config file in a config directory in lexical order. Stacks whose
+// config can't be read are listed with their error instead of failing the whole listing.
+func ListStacks(configDir string) ([]StackListing, error) {
+	var listings []StackListing
+
+	err := filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !IsStackConfigFile(info.Name()) {
+			return nil
+		}
+
+		stackInfo, err := GetStackInfo(path)
+		listings = append(listings, StackListing{ConfigPath: path, Info: stackInfo, Err: err})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return listings, nil
+}
+
+// FindStacks returns the stack info of every stack config file in a config directory, in lexical order.
+// It fails on the first stack whose config can't be read.
+func FindStacks(configDir string) ([]*StackInfo, error) {
+	listings, err := ListStacks(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := make([]*StackInfo, 0, len(listings))
+
+	for _, listing := range listings {
+		if listing.Err != nil {
+			return nil, listing.Err
+		}
+
+		stacks = append(stacks, listing.Info)
+	}
+
+	return stacks, nil
+}
+
+// IsStackConfigFile checks if a file name is a stack config file rather than a config.yaml.
+// Every command finds stacks by this check.
+func IsStackConfigFile(filename string) bool {
+	if filename == "config.yaml" || filename == "config.yml" {
+		return false
+	}
+
+	ext := filepath.Ext(filename)
+
+	return ext == ".yaml" || ext == ".yml" || ext == ".jinja" || ext == ".j2"
+}
@@ -3,6 +3,8 @@ package stack
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -337,3 +339,155 @@ func TestMergeConfigs(t *testing.T) {
 		t.Errorf("App = %v, want child-app", merged.App)
 	}
 }
+
+func TestResolveConfigForFileDeepHierarchy(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+	apiDir := filepath.Join(configDir, "staging", "web")
+
+	err := os.MkdirAll(apiDir, 0o755)
+	if err != nil {
+		t.Fatalf("Failed to create config directories: %v", err)
+	}
+
+	// No config.yaml in config/staging/web
+	writeTestFile(t, filepath.Join(configDir, "config.yaml"), "project_code: shop\nnamespace: default\n")
+	writeTestFile(t, filepath.Join(configDir, "staging", "config.yaml"), "context: staging\nnamespace: staging\n")
+
+	resolved, err := ResolveConfigForFile(filepath.Join(apiDir, "api.yaml"))
+	if err != nil {
+		t.Fatalf("ResolveConfigForFile() unexpected error: %v", err)
+	}
+
+	validateConfigResult(t, resolved.Config, nil, &Config{
+		Context:     "staging",
+		ProjectCode: "shop",
+		Namespace:   "staging",
+	}, false)
+
+	expectedSources := map[string]string{
+		"project_code": filepath.Join(configDir, "config.yaml"),
+		"context":      filepath.Join(configDir, "staging", "config.yaml"),
+		"namespace":    filepath.Join(configDir, "staging", "config.yaml"),
+	}
+
+	if !reflect.DeepEqual(resolved.Sources, expectedSources) {
+		t.Errorf("Sources = %v, want %v", resolved.Sources, expectedSources)
+	}
+
+	if len(resolved.Files) != 2 || resolved.Files[0] != filepath.Join(configDir, "config.yaml") {
+		t.Errorf("Files = %v, want root config first", resolved.Files)
+	}
+}
+
+func TestConfigChainStopsAtConfigDirectory(t *testing.T) {
+	projectDir := t.TempDir()
+	configDir := filepath.Join(projectDir, "config")
+
+	err := os.MkdirAll(filepath.Join(configDir, "dev"), 0o755)
+	if err != nil {
+		t.Fatalf("Failed to create config directories: %v", err)
+	}
+
+	// A config.yaml above the config directory must not be inherited
+	writeTestFile(t, filepath.Join(projectDir, "config.yaml"), "context: outside\n")
+	writeTestFile(t, filepath.Join(configDir, "config.yaml"), "project_code: shop\n")
+	writeTestFile(t, filepath.Join(configDir, "dev", "config.yaml"), "context: dev\n")
+
+	chain, err := configChain(filepath.Join(configDir, "dev"))
+	if err != nil {
+		t.Fatalf("configChain() error = %v", err)
+	}
+
+	expected := []string{filepath.Join(configDir, "config.yaml"), filepath.Join(configDir, "dev", "config.yaml")}
+	if !reflect.DeepEqual(chain, expected) {
+		t.Errorf("configChain() = %v, want %v", chain, expected)
+	}
+}
+
+func TestConfigChainAcceptsConfigYml(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+
+	err := os.MkdirAll(filepath.Join(configDir, "dev"), 0o755)
+	if err != nil {
+		t.Fatalf("Failed to create config directories: %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(configDir, "config.yml"), "project_code: shop\n")
+	writeTestFile(t, filepath.Join(configDir, "dev", "config.yaml"), "context: dev\n")
+
+	chain, err := configChain(filepath.Join(configDir, "dev"))
+	if err != nil {
+		t.Fatalf("configChain() error = %v", err)
+	}
+
+	expected := []string{filepath.Join(configDir, "config.yml"), filepath.Join(configDir, "dev", "config.yaml")}
+	if !reflect.DeepEqual(chain, expected) {
+		t.Errorf("configChain() = %v, want %v", chain, expected)
+	}
+}
+
+func TestConfigChainRejectsBothConfigNames(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+
+	err := os.MkdirAll(configDir, 0o755)
+	if err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(configDir, "config.yaml"), "context: dev\n")
+	writeTestFile(t, filepath.Join(configDir, "config.yml"), "context: prod\n")
+
+	_, err = configChain(configDir)
+	if err == nil || !strings.Contains(err.Error(), "both config.yaml and config.yml") {
+		t.Errorf("configChain() error = %v, want an error about both config files", err)
+	}
+}
+
+func TestResolveConfigForFileInheritsVars(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "config")
+	webDir := filepath.Join(configDir, "prod", "web")
+
+	err := os.MkdirAll(webDir, 0o755)
+	if err != nil {
+		t.Fatalf("Failed to create config directories: %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(configDir, "config.yaml"), `context: prod
+project_code: shop
+vars:
+  registry: registry.example.com
+  resources:
+    cpu: 100m
+    memory: 128Mi
+  allowed_hosts: [shop.example.com]`)
+	writeTestFile(t, filepath.Join(webDir, "config.yaml"), `vars:
+  resources:
+    memory: 512Mi
+  allowed_hosts:
+    $replace: [web.example.com]`)
+
+	stackInfo, err := GetStackInfo(filepath.Join(webDir, "web.yaml"))
+	if err != nil {
+		t.Fatalf("GetStackInfo() unexpected error: %v", err)
+	}
+
+	expected := map[string]any{
+		"registry":      "registry.example.com",
+		"resources":     map[string]any{"cpu": "100m", "memory": "512Mi"},
+		"allowed_hosts": []any{"web.example.com"},
+	}
+
+	if !reflect.DeepEqual(stackInfo.Vars, expected) {
+		t.Errorf("Vars = %#v, want %#v", stackInfo.Vars, expected)
+	}
+}
+
+// writeTestFile writes a file for a test and fails the test on error.
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package stack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides holds template vars given on the command line. They are applied after every config and values file.
+type Overrides struct {
+	// Values are the --values files merged in order.
+	Values map[string]any
+	// Set are the --set values, each wrapped in a ReplaceKey map so it replaces the value it overrides.
+	Set map[string]any
+}
+
+// Apply layers the overrides onto vars.
+func (o Overrides) Apply(vars map[string]any) map[string]any {
+	return MergeVars(MergeVars(vars, o.Values), o.Set)
+}
+
+// LoadOverrides reads --values files and parses --set key=value pairs.
+func LoadOverrides(valuesFiles, setValues []string) (Overrides, error) {
+	values, err := LoadValuesFiles("", valuesFiles)
+	if err != nil {
+		return Overrides{}, err
+	}
+
+	set, err := ParseSetValues(setValues)
+	if err != nil {
+		return Overrides{}, err
+	}
+
+	return Overrides{Values: values, Set: set}, nil
+}
+
+// LoadValuesFiles reads values files and deep-merges them in order. Relative paths are resolved against valuesDir.
+func LoadValuesFiles(valuesDir string, files []string) (map[string]any, error) {
+	var merged map[string]any
+
+	for _, file := range files {
+		path := file
+		if !filepath.IsAbs(path) && valuesDir != "" {
+			path = filepath.Join(valuesDir, file)
+		}
+
+		values, err := ReadValuesFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		merged = MergeVars(merged, values)
+	}
+
+	return merged, nil
+}
+
+// ReadValuesFile reads a YAML values file. The file must contain a map at the top level.
+func ReadValuesFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading values file: %w", err)
+	}
+
+	var values map[string]any
+
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing values file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// ParseSetValues parses key=value pairs into nested vars. Dots in keys select nested maps
+// (image.tag=1.2.3) and values are parsed as YAML, so numbers, booleans and lists keep their type.
+func ParseSetValues(setValues []string) (map[string]any, error) {
+	var vars map[string]any
+
+	for _, setValue := range setValues {
+		key, raw, found := strings.Cut(setValue, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid --set value %q, expected key=value", setValue)
+		}
+
+		path := strings.Split(key, ".")
+		if slices.Contains(path, "") {
+			return nil, fmt.Errorf("invalid --set key %q", key)
+		}
+
+		if vars == nil {
+			vars = make(map[string]any)
+		}
+
+		setPath(vars, path, map[string]any{ReplaceKey: parseSetValue(raw)})
+	}
+
+	return vars, nil
+}
+
+// parseSetValue parses a --set value as YAML and falls back to the raw string.
+func parseSetValue(raw string) any {
+	if raw == "" {
+		return ""
+	}
+
+	var value any
+
+	err := yaml.Unmarshal([]byte(raw), &value)
+	if err != nil || value == nil {
+		return raw
+	}
+
+	return value
+}
+
+// setPath sets a value in nested maps, creating or replacing intermediate maps as needed.
+func setPath(vars map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := vars[key].(map[string]any)
+		if _, isReplacement := replacementValue(next); !ok || isReplacement {
+			next = make(map[string]any)
+			vars[key] = next
+		}
+
+		vars = next
+	}
+
+	vars[path[len(path)-1]] = value
+}
@@ -0,0 +1,116 @@
+package stack
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSetValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		setValues []string
+		expected  map[string]any
+		expectErr bool
+	}{
+		{
+			name:     "no values",
+			expected: nil,
+		},
+		{
+			name:      "nested key with typed value",
+			setValues: []string{"image.tag=1.2.3", "replicas=3", "debug=true"},
+			expected: map[string]any{
+				"image":    map[string]any{"tag": map[string]any{ReplaceKey: "1.2.3"}},
+				"replicas": map[string]any{ReplaceKey: 3},
+				"debug":    map[string]any{ReplaceKey: true},
+			},
+		},
+		{
+			name:      "value containing equals sign",
+			setValues: []string{"args=--level=debug"},
+			expected:  map[string]any{"args": map[string]any{ReplaceKey: "--level=debug"}},
+		},
+		{
+			name:      "empty value",
+			setValues: []string{"suffix="},
+			expected:  map[string]any{"suffix": map[string]any{ReplaceKey: ""}},
+		},
+		{
+			name:      "missing equals sign",
+			setValues: []string{"image.tag"},
+			expectErr: true,
+		},
+		{
+			name:      "empty key segment",
+			setValues: []string{"image..tag=1"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseSetValues(tt.setValues)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseSetValues() error = %v, expectErr %v", err, tt.expectErr)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseSetValues() = %#v, want %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadValuesFiles(t *testing.T) {
+	valuesDir := t.TempDir()
+	writeTestFile(t, filepath.Join(valuesDir, "prod.yaml"), "image:\n  tag: 1.0.0\n  pullPolicy: Always\nhosts:\n  - a.example.com\n")
+	writeTestFile(t, filepath.Join(valuesDir, "prod-eu.yaml"), "image:\n  tag: 1.1.0\nhosts:\n  - b.example.com\n")
+
+	values, err := LoadValuesFiles(valuesDir, []string{"prod.yaml", "prod-eu.yaml"})
+	if err != nil {
+		t.Fatalf("LoadValuesFiles() error = %v", err)
+	}
+
+	expected := map[string]any{
+		"image": map[string]any{"tag": "1.1.0", "pullPolicy": "Always"},
+		"hosts": []any{"a.example.com", "b.example.com"},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("LoadValuesFiles() = %#v, want %#v", values, expected)
+	}
+
+	_, err = LoadValuesFiles(valuesDir, []string{"missing.yaml"})
+	if err == nil {
+		t.Error("LoadValuesFiles() expected error for a missing file")
+	}
+}
+
+func TestOverridesApply(t *testing.T) {
+	set, err := ParseSetValues([]string{"hosts=[c.example.com]", "image.tag=2.0.0"})
+	if err != nil {
+		t.Fatalf("ParseSetValues() error = %v", err)
+	}
+
+	overrides := Overrides{
+		Values: map[string]any{"image": map[string]any{"tag": "1.5.0"}, "replicas": 5},
+		Set:    set,
+	}
+
+	vars := map[string]any{
+		"image":    map[string]any{"repository": "nginx", "tag": "1.0.0"},
+		"hosts":    []any{"a.example.com"},
+		"replicas": 2,
+	}
+
+	expected := map[string]any{
+		"image":    map[string]any{"repository": "nginx", "tag": "2.0.0"},
+		"hosts":    []any{"c.example.com"},
+		"replicas": 5,
+	}
+
+	result := overrides.Apply(vars)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Apply() = %#v, want %#v", result, expected)
+	}
+}
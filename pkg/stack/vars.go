@@ -0,0 +1,99 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package stack
+
+// ReplaceKey marks a vars value that replaces the inherited value instead of being merged with it:
+//
+//	vars:
+//	  allowed_hosts:
+//	    $replace: [api.example.com]
+const ReplaceKey = "$replace"
+
+// MergeVars deep-merges override vars onto base vars and returns the result without modifying either.
+// Maps are merged key by key, lists are appended to the inherited list, and any other value
+// replaces the inherited one. A value wrapped in a ReplaceKey map always replaces the inherited value.
+func MergeVars(base, override map[string]any) map[string]any {
+	if base == nil && override == nil {
+		return nil
+	}
+
+	merged := make(map[string]any, len(base)+len(override))
+
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range override {
+		inherited, exists := merged[key]
+		if !exists {
+			merged[key] = resolveReplacements(value)
+
+			continue
+		}
+
+		merged[key] = mergeValue(inherited, value)
+	}
+
+	return merged
+}
+
+// mergeValue merges a single override value onto an inherited value.
+func mergeValue(inherited, override any) any {
+	if replacement, ok := replacementValue(override); ok {
+		return resolveReplacements(replacement)
+	}
+
+	inheritedMap, inheritedIsMap := inherited.(map[string]any)
+	overrideMap, overrideIsMap := override.(map[string]any)
+
+	if inheritedIsMap && overrideIsMap {
+		return MergeVars(inheritedMap, overrideMap)
+	}
+
+	inheritedList, inheritedIsList := inherited.([]any)
+	overrideList, overrideIsList := override.([]any)
+
+	if inheritedIsList && overrideIsList {
+		merged := make([]any, 0, len(inheritedList)+len(overrideList))
+		merged = append(merged, inheritedList...)
+
+		return append(merged, resolveReplacements(overrideList).([]any)...)
+	}
+
+	return resolveReplacements(override)
+}
+
+// replacementValue returns the wrapped value if the value is a ReplaceKey map.
+func replacementValue(value any) (any, bool) {
+	valueMap, ok := value.(map[string]any)
+	if !ok || len(valueMap) != 1 {
+		return nil, false
+	}
+
+	replacement, ok := valueMap[ReplaceKey]
+
+	return replacement, ok
+}
+
+// resolveReplacements unwraps ReplaceKey markers anywhere inside a value that has nothing to merge with.
+func resolveReplacements(value any) any {
+	if replacement, ok := replacementValue(value); ok {
+		return resolveReplacements(replacement)
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		return MergeVars(nil, typed)
+	case []any:
+		resolved := make([]any, 0, len(typed))
+		for _, item := range typed {
+			resolved = append(resolved, resolveReplacements(item))
+		}
+
+		return resolved
+	default:
+		return value
+	}
+}
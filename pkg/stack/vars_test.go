@@ -0,0 +1,75 @@
+package stack
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeVars(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     map[string]any
+		override map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "both empty",
+			expected: nil,
+		},
+		{
+			name:     "scalar override wins",
+			base:     map[string]any{"registry": "docker.io", "replicas": 1},
+			override: map[string]any{"replicas": 3},
+			expected: map[string]any{"registry": "docker.io", "replicas": 3},
+		},
+		{
+			name:     "nested maps are merged",
+			base:     map[string]any{"resources": map[string]any{"cpu": "100m", "memory": "128Mi"}},
+			override: map[string]any{"resources": map[string]any{"memory": "256Mi"}},
+			expected: map[string]any{"resources": map[string]any{"cpu": "100m", "memory": "256Mi"}},
+		},
+		{
+			name:     "lists are appended",
+			base:     map[string]any{"hosts": []any{"a.example.com"}},
+			override: map[string]any{"hosts": []any{"b.example.com"}},
+			expected: map[string]any{"hosts": []any{"a.example.com", "b.example.com"}},
+		},
+		{
+			name:     "replace marker replaces a list",
+			base:     map[string]any{"hosts": []any{"a.example.com"}},
+			override: map[string]any{"hosts": map[string]any{ReplaceKey: []any{"b.example.com"}}},
+			expected: map[string]any{"hosts": []any{"b.example.com"}},
+		},
+		{
+			name:     "replace marker replaces a map",
+			base:     map[string]any{"labels": map[string]any{"team": "a", "tier": "web"}},
+			override: map[string]any{"labels": map[string]any{ReplaceKey: map[string]any{"team": "b"}}},
+			expected: map[string]any{"labels": map[string]any{"team": "b"}},
+		},
+		{
+			name:     "replace marker without inherited value is unwrapped",
+			override: map[string]any{"nested": map[string]any{"hosts": map[string]any{ReplaceKey: []any{"a"}}}},
+			expected: map[string]any{"nested": map[string]any{"hosts": []any{"a"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MergeVars(tt.base, tt.override)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("MergeVars() = %#v, want %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergeVarsDoesNotModifyInputs(t *testing.T) {
+	base := map[string]any{"resources": map[string]any{"cpu": "100m"}, "hosts": []any{"a"}}
+	override := map[string]any{"resources": map[string]any{"cpu": "200m"}, "hosts": []any{"b"}}
+
+	MergeVars(base, override)
+
+	if base["resources"].(map[string]any)["cpu"] != "100m" || len(base["hosts"].([]any)) != 1 {
+		t.Errorf("MergeVars() modified its base: %v", base)
+	}
+}
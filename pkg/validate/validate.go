@@ -0,0 +1,314 @@
+/*
+Copyright © 2025 Ben Sapp ya.bsapp.ru
+*/
+
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/schnauzersoft/frank-cli/pkg/project"
+	"github.com/schnauzersoft/frank-cli/pkg/stack"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a single problem found in a config file.
+type Problem struct {
+	Path string
+	// Line and Column locate the problem, or are 0 when it concerns the whole file.
+	Line    int
+	Column  int
+	Message string
+}
+
+// String formats the problem as path:line:column: message.
+func (p Problem) String() string {
+	switch {
+	case p.Line == 0:
+		return fmt.Sprintf("%s: %s", p.Path, p.Message)
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", p.Path, p.Line, p.Message)
+	default:
+		return fmt.Sprintf("%s:%d:%d: %s", p.Path, p.Line, p.Column, p.Message)
+	}
+}
+
+// Report is the result of validating a config directory.
+type Report struct {
+	// Files lists every file that was checked.
+	Files []string
+	// Problems lists every problem found, in file order.
+	Problems []Problem
+}
+
+// Error is returned when a config directory has problems.
+type Error struct {
+	Problems []Problem
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		lines = append(lines, problem.String())
+	}
+
+	return fmt.Sprintf("%d problems in config files:\n%s", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+// Err returns an *Error listing the problems of the report, or nil if there are none.
+func (r *Report) Err() error {
+	if len(r.Problems) == 0 {
+		return nil
+	}
+
+	return &Error{Problems: r.Problems}
+}
+
+// ConfigDir validates every config.yaml and stack config file in a config directory. Unlike deploying,
+// it doesn't stop at the first problem but reports every problem it finds.
+func ConfigDir(configDir string) (*Report, error) {
+	report := &Report{}
+
+	var stacks []stack.StackWithDependencies
+
+	err := filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !isConfigFile(info.Name()) {
+			return nil
+		}
+
+		stackWithDeps, err := report.checkPath(path)
+		if stackWithDeps != nil {
+			stacks = append(stacks, *stackWithDeps)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.checkDependencies(configDir, stacks)
+
+	return report, nil
+}
+
+// checkDependencies checks the dependencies and names of all stacks, which can't be checked per file.
+func (r *Report) checkDependencies(configDir string, stacks []stack.StackWithDependencies) {
+	if len(stacks) == 0 {
+		return
+	}
+
+	_, err := stack.ResolveDependencyGraph(stacks)
+	if err != nil {
+		r.Problems = append(r.Problems, Problem{Path: configDir, Message: err.Error()})
+	}
+}
+
+// checkPath validates a config.yaml or a stack config file.
+func (r *Report) checkPath(path string) (*stack.StackWithDependencies, error) {
+	r.Files = append(r.Files, path)
+
+	if isConfigYAML(filepath.Base(path)) {
+		return nil, r.checkFile(path, &stack.Config{})
+	}
+
+	return r.checkStackFile(path)
+}
+
+// checkStackFile validates a stack config file and the config it inherits. It returns the stack
+// with its dependencies whenever the stack has a name, so dependencies on it can be checked.
+func (r *Report) checkStackFile(path string) (*stack.StackWithDependencies, error) {
+	var manifestConfig project.ManifestConfig
+
+	problemCount := len(r.Problems)
+
+	err := r.checkFile(path, &manifestConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.Problems) == problemCount && manifestConfig.Manifest == "" {
+		r.Problems = append(r.Problems, Problem{Path: path, Message: "manifest is required"})
+	}
+
+	if manifestConfig.OnFailure != "" && manifestConfig.OnFailure != project.OnFailureRollback {
+		r.Problems = append(r.Problems, Problem{Path: path, Message: fmt.Sprintf("invalid on_failure %q, use %q", manifestConfig.OnFailure, project.OnFailureRollback)})
+	}
+
+	stackInfo, err := stack.GetStackInfo(path)
+	if err != nil {
+		r.addStackError(path, err)
+
+		return nil, nil
+	}
+
+	return &stack.StackWithDependencies{StackInfo: stackInfo, DependsOn: manifestConfig.DependsOn}, nil
+}
+
+// addStackError records a problem with the inherited config of a stack. Problems inside config.yaml
+// files are skipped because they are reported for the config.yaml itself.
+func (r *Report) addStackError(path string, err error) {
+	var (
+		syntaxErr  *stack.SyntaxError
+		keyErr     *stack.UnknownKeyError
+		missingErr *stack.MissingFieldError
+	)
+
+	switch {
+	case errors.As(err, &syntaxErr), errors.As(err, &keyErr):
+		return
+	case errors.As(err, &missingErr):
+		r.Problems = append(r.Problems, Problem{Path: path, Message: missingErr.Field + " not specified in any config.yaml"})
+	case errors.Is(err, stack.ErrNoConfig):
+		r.Problems = append(r.Problems, Problem{Path: path, Message: stack.ErrNoConfig.Error()})
+	default:
+		r.Problems = append(r.Problems, Problem{Path: path, Message: err.Error()})
+	}
+}
+
+// checkFile decodes a YAML file into schema, rejecting unknown keys, and records every problem.
+func (r *Report) checkFile(path string, schema any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	var document yaml.Node
+
+	err = yaml.Unmarshal(data, &document)
+	if err != nil {
+		r.Problems = append(r.Problems, problemsFromYAML(path, nil, err)...)
+
+		return nil
+	}
+
+	// An empty file has nothing to check
+	if len(document.Content) == 0 {
+		return nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(schema)
+	if err != nil {
+		r.Problems = append(r.Problems, problemsFromYAML(path, document.Content[0], err)...)
+	}
+
+	return nil
+}
+
+// yamlErrorLine matches the line number in yaml.v3 error messages such as "line 3: field timout not found".
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// unknownField matches yaml.v3's message for keys that have no field in the schema.
+var unknownField = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+
+// problemsFromYAML converts a yaml.v3 error into problems. Type errors carry one message per offending value.
+func problemsFromYAML(path string, root *yaml.Node, err error) []Problem {
+	messages := []string{err.Error()}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	problems := make([]Problem, 0, len(messages))
+
+	for _, message := range messages {
+		problems = append(problems, newProblem(path, root, message))
+	}
+
+	return problems
+}
+
+// newProblem creates a problem from a yaml.v3 message, naming the offending key when it can be found.
+func newProblem(path string, root *yaml.Node, message string) Problem {
+	match := yamlErrorLine.FindStringSubmatch(message)
+	if match == nil {
+		return Problem{Path: path, Message: message}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	problem := Problem{Path: path, Line: line, Message: match[2]}
+
+	if field := unknownField.FindStringSubmatch(match[2]); field != nil {
+		problem.Message = fmt.Sprintf("unknown key %q", field[1])
+	}
+
+	key := findKey(root, line)
+	if key == nil {
+		return problem
+	}
+
+	problem.Column = key.Column
+
+	if !strings.HasPrefix(problem.Message, "unknown key") {
+		problem.Message = fmt.Sprintf("invalid value for %q: %s", key.Value, problem.Message)
+	}
+
+	if strings.HasSuffix(problem.Message, "into time.Duration") {
+		problem.Message += ", use a duration such as 90s or 10m"
+	}
+
+	return problem
+}
+
+// findKey finds the mapping key whose key or value starts on a line.
+func findKey(node *yaml.Node, line int) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+
+	if key := findMappingKey(node, line); key != nil {
+		return key
+	}
+
+	for _, child := range node.Content {
+		if key := findKey(child, line); key != nil {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// findMappingKey returns the key of a mapping node whose key or scalar value is on a line.
+func findMappingKey(node *yaml.Node, line int) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Line == line || (value.Line == line && value.Kind == yaml.ScalarNode) {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// isConfigFile checks if a file name is a config.yaml or a stack config file, matching stack
+// config files the way the other commands find stacks.
+func isConfigFile(filename string) bool {
+	return isConfigYAML(filename) || stack.IsStackConfigFile(filename)
+}
+
+// isConfigYAML checks if a file name is an inherited config.yaml rather than a stack config file.
+func isConfigYAML(filename string) bool {
+	return filename == "config.yaml" || filename == "config.yml"
+}
@@ -0,0 +1,150 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name: "valid config",
+			files: map[string]string{
+				"config.yaml":     "context: dev\nproject_code: shop\n",
+				"dev/db.yaml":     "manifest: db.yaml\ntimeout: 5m\n",
+				"dev/api.yaml":    "manifest: api.yaml\ndepends_on:\n  - dev/db.yaml\nvalues_files: [dev.yaml]\n",
+				"dev/config.yaml": "namespace: dev\nvars:\n  replicas: 2\n",
+			},
+			expected: nil,
+		},
+		{
+			name: "every problem is reported",
+			files: map[string]string{
+				"config.yaml":  "context: dev\nproject_code: shop\nnamepsace: web\n",
+				"dev/api.yaml": "manifest: api.yaml\ndepend_on:\n  - db\ntimeout: 300\n",
+				"dev/web.yaml": "timeout: soon\n",
+			},
+			expected: []string{
+				"config/config.yaml:3:1: unknown key \"namepsace\"",
+				"config/dev/api.yaml:2:1: unknown key \"depend_on\"",
+				"config/dev/api.yaml:4:1: invalid value for \"timeout\": cannot unmarshal !!int `300` into time.Duration, use a duration such as 90s or 10m",
+				"config/dev/web.yaml:1:1: invalid value for \"timeout\": cannot unmarshal !!str `soon` into time.Duration, use a duration such as 90s or 10m",
+			},
+		},
+		{
+			name: "syntax error, missing manifest and invalid setting",
+			files: map[string]string{
+				"config.yaml":  "context: dev\nproject_code: shop\n",
+				"dev/api.yaml": "manifest: api.yaml\n\tversion: 1\n",
+				"dev/web.yaml": "version: 1.0.0\n",
+				"dev/db.yaml":  "manifest: db.yaml\non_failure: retry\n",
+			},
+			expected: []string{
+				"config/dev/api.yaml:2: found a tab character that violates indentation",
+				"config/dev/db.yaml: invalid on_failure \"retry\", use \"rollback\"",
+				"config/dev/web.yaml: manifest is required",
+			},
+		},
+		{
+			name: "template stack configs are checked",
+			files: map[string]string{
+				"config.yaml":        "context: dev\nproject_code: shop\n",
+				"dev/api.yaml.jinja": "manifest: api.yaml\nnamepsace: web\n",
+				"dev/web.j2":         "timeout: soon\n",
+			},
+			expected: []string{
+				"config/dev/api.yaml.jinja:2:1: unknown key \"namepsace\"",
+				"config/dev/web.j2:1:1: invalid value for \"timeout\": cannot unmarshal !!str `soon` into time.Duration, use a duration such as 90s or 10m",
+			},
+		},
+		{
+			name: "missing context and dependency",
+			files: map[string]string{
+				"config.yaml":      "project_code: shop\n",
+				"prod/config.yaml": "context: prod\n",
+				"prod/api.yaml":    "manifest: api.yaml\ndepends_on: [shop-prod-db]\n",
+				"api.yaml":         "manifest: api.yaml\n",
+			},
+			expected: []string{
+				"config/api.yaml: context not specified in any config.yaml",
+				"config: stack 'shop-prod-api' depends on 'shop-prod-db' which does not exist",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			writeConfigFiles(t, filepath.Join(projectDir, "config"), tt.files)
+
+			report, err := ConfigDir(filepath.Join(projectDir, "config"))
+			if err != nil {
+				t.Fatalf("ConfigDir() error = %v", err)
+			}
+
+			validateReport(t, report, projectDir, len(tt.files), tt.expected)
+		})
+	}
+}
+
+// validateReport checks the number of checked files and the problems of a report.
+func validateReport(t *testing.T, report *Report, projectDir string, fileCount int, expected []string) {
+	t.Helper()
+
+	if len(report.Files) != fileCount {
+		t.Errorf("ConfigDir() checked %d files, want %d", len(report.Files), fileCount)
+	}
+
+	problems := problemStrings(t, projectDir, report.Problems)
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("ConfigDir() problems =\n%q\nwant\n%q", problems, expected)
+	}
+
+	if (report.Err() != nil) != (len(expected) > 0) {
+		t.Errorf("Report.Err() = %v with %d expected problems", report.Err(), len(expected))
+	}
+}
+
+// writeConfigFiles writes files relative to a config directory.
+func writeConfigFiles(t *testing.T, configDir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(configDir, name)
+
+		err := os.MkdirAll(filepath.Dir(path), 0o750)
+		if err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+
+		err = os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// problemStrings formats problems with paths relative to the project directory.
+func problemStrings(t *testing.T, projectDir string, problems []Problem) []string {
+	t.Helper()
+
+	var result []string
+
+	for _, problem := range problems {
+		relativePath, err := filepath.Rel(projectDir, problem.Path)
+		if err != nil {
+			t.Fatalf("Failed to make %s relative: %v", problem.Path, err)
+		}
+
+		problem.Path = relativePath
+		result = append(result, problem.String())
+	}
+
+	return result
+}